@@ -45,17 +45,40 @@ func SetupRoutes(r *gin.Engine, container *app.Container) {
 		adminStats := adminAuth.Group("/statistics")
 		container.StatisticsModule.RegisterRoutes(adminStats)
 
+		// 出站请求与页面解析指标（Prometheus 文本格式）
+		adminAuth.GET("/metrics", metricsHandler(container))
+
 		// ========== 业务模块路由 ==========
 		// 成绩模块
 		container.GradeModule.RegisterRoutes(userAuth)
 
-		// 课程模块
-		container.CourseModule.RegisterRoutes(userAuth)
+		// 课程模块（包含公开的日历订阅源）
+		container.CourseModule.RegisterRoutes(api, userAuth)
 
 		// 考试模块
 		container.ExamModule.RegisterRoutes(userAuth)
 
+		// 教评模块
+		container.EvaluationModule.RegisterRoutes(userAuth)
+
+		// 电费模块
+		container.ElectricityModule.RegisterRoutes(userAuth)
+
+		// 空教室模块
+		container.ClassroomModule.RegisterRoutes(userAuth)
+
 		// 通知模块（包含公开和管理员路由）
 		container.NoticeModule.RegisterRoutes(api, adminAuth)
 	}
 }
+
+// metricsHandler 输出出站请求指标和教务页面解析指标
+func metricsHandler(container *app.Container) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := container.OutboundGuard.Metrics().WritePrometheus(c.Writer); err != nil {
+			return
+		}
+		_ = container.ParseMonitor.WritePrometheus(c.Writer)
+	}
+}
@@ -3,18 +3,26 @@ package app
 import (
 	"fmt"
 	"os"
+	"spider-go/internal/service"
+	"spider-go/pkg/httpclient"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	App      Appconfig          `yaml:"app" mapstructure:"app"`
-	CORS     CORSConfig         `yaml:"cors" mapstructure:"cors"`
-	Database DatabaseConfig     `yaml:"database" mapstructure:"database"`
-	Redis    RedisClusterConfig `yaml:"redis" mapstructure:"redis"`
-	Jwc      JwcConfig          `yaml:"jwc" mapstructure:"jwc"`
-	JWT      JWTConfig          `yaml:"jwt" mapstructure:"jwt"`
-	Email    EmailConfig        `yaml:"email" mapstructure:"email"`
+	App         Appconfig          `yaml:"app" mapstructure:"app"`
+	CORS        CORSConfig         `yaml:"cors" mapstructure:"cors"`
+	Database    DatabaseConfig     `yaml:"database" mapstructure:"database"`
+	Redis       RedisClusterConfig `yaml:"redis" mapstructure:"redis"`
+	Jwc         JwcConfig          `yaml:"jwc" mapstructure:"jwc"`
+	JWT         JWTConfig          `yaml:"jwt" mapstructure:"jwt"`
+	Email       EmailConfig        `yaml:"email" mapstructure:"email"`
+	Security    SecurityConfig     `yaml:"security" mapstructure:"security"`
+	Electricity ElectricityConfig  `yaml:"electricity" mapstructure:"electricity"`
+	Course      CourseConfig       `yaml:"course" mapstructure:"course"`
+	Outbound    OutboundConfig     `yaml:"outbound" mapstructure:"outbound"`
+	ParseHealth ParseHealthConfig  `yaml:"parse_health" mapstructure:"parse_health"`
 }
 
 type Appconfig struct {
@@ -33,31 +41,33 @@ type CORSConfig struct {
 }
 
 // JwcConfig 教务系统配置
+// 只配置校园网地址，webvpn/auto 模式下按 WebVPN 主机名编码规则自动改写
 type JwcConfig struct {
-	Mode            string        `yaml:"mode" mapstructure:"mode"` // 模式：campus 或 webvpn
-	Campus          JwcModeConfig `yaml:"campus" mapstructure:"campus"`
-	Webvpn          JwcModeConfig `yaml:"webvpn" mapstructure:"webvpn"`
-	GetRSAKeyURL    string        `yaml:"rsa_url" mapstructure:"rsa_url"`
-	CaptchaURL      string        `yaml:"captcha_url" mapstructure:"captcha_url"`
-	CaptchaImageURL string        `yaml:"captcha_image_url" mapstructure:"captcha_image_url"`
+	Mode            string       `yaml:"mode" mapstructure:"mode"` // 模式：campus、webvpn 或 auto
+	URLs            JwcURLConfig `yaml:"urls" mapstructure:"urls"`
+	Webvpn          WebvpnConfig `yaml:"webvpn" mapstructure:"webvpn"`
+	GetRSAKeyURL    string       `yaml:"rsa_url" mapstructure:"rsa_url"`
+	CaptchaURL      string       `yaml:"captcha_url" mapstructure:"captcha_url"`
+	CaptchaImageURL string       `yaml:"captcha_image_url" mapstructure:"captcha_image_url"`
+	RecordDir       string       `yaml:"record_dir" mapstructure:"record_dir"` // 录制模式：非空时将教务系统页面匿名化后保存到该目录，用于生成测试夹具
 }
 
-// JwcModeConfig 教务系统单个模式的配置
-type JwcModeConfig struct {
+// JwcURLConfig 教务系统各功能的校园网地址
+type JwcURLConfig struct {
 	LoginURL      string `yaml:"login_url" mapstructure:"login_url"`
 	RedirectURL   string `yaml:"redirect_url" mapstructure:"redirect_url"`
 	CourseURL     string `yaml:"course_url" mapstructure:"course_url"`
 	GradeURL      string `yaml:"grade_url" mapstructure:"grade_url"`
 	GradeLevelURL string `yaml:"grade_level_url" mapstructure:"grade_level_url"`
 	ExamURL       string `yaml:"exam_url" mapstructure:"exam_url"`
+	EvaluationURL string `yaml:"evaluation_url" mapstructure:"evaluation_url"`
+	ClassroomURL  string `yaml:"classroom_url" mapstructure:"classroom_url"`
 }
 
-// GetCurrentModeConfig 获取当前模式的配置
-func (c *JwcConfig) GetCurrentModeConfig() JwcModeConfig {
-	if c.Mode == "webvpn" {
-		return c.Webvpn
-	}
-	return c.Campus // 默认使用校园网模式
+// WebvpnConfig WebVPN 配置
+type WebvpnConfig struct {
+	Host     string `yaml:"host" mapstructure:"host"`           // WebVPN 主机名，如 webvpn.csuft.edu.cn
+	LoginURL string `yaml:"login_url" mapstructure:"login_url"` // WebVPN 登录入口（service 指向 WebVPN 回调，无法由校园网地址推导）
 }
 
 type JWTConfig struct {
@@ -74,6 +84,133 @@ type EmailConfig struct {
 	FromName string `yaml:"from_name" mapstructure:"from_name"` // 发件人名称
 }
 
+// SecurityConfig 安全配置
+type SecurityConfig struct {
+	MasterKey string `yaml:"master_key" mapstructure:"master_key"` // 凭据加密主密钥
+}
+
+// GetMasterKey 获取凭据加密主密钥（环境变量 SPIDER_MASTER_KEY 优先）
+func (c *SecurityConfig) GetMasterKey() string {
+	if key := os.Getenv("SPIDER_MASTER_KEY"); key != "" {
+		return key
+	}
+	return c.MasterKey
+}
+
+// ElectricityConfig 宿舍电费查询配置
+type ElectricityConfig struct {
+	QueryURL string `yaml:"query_url" mapstructure:"query_url"` // 电费查询地址
+}
+
+// CourseConfig 课程表配置
+type CourseConfig struct {
+	// Periods 节次作息时间，第 i 项为第 i+1 节，格式 "08:00-08:45"；为空时使用默认作息
+	Periods []string `yaml:"periods" mapstructure:"periods"`
+	// CalendarBaseURL 日历订阅地址使用的公开访问地址，如 https://api.example.com；为空时不提供订阅地址
+	CalendarBaseURL string `yaml:"calendar_base_url" mapstructure:"calendar_base_url"`
+}
+
+// OutboundConfig 访问教务系统等外部服务的 HTTP 客户端、限流与熔断配置（未配置的项使用默认值）
+type OutboundConfig struct {
+	Proxy               string        `yaml:"proxy" mapstructure:"proxy"`                                     // 出站代理（为空时读取 HTTP_PROXY 等环境变量）
+	Timeout             time.Duration `yaml:"timeout" mapstructure:"timeout"`                                 // 单个请求超时
+	MaxIdleConns        int           `yaml:"max_idle_conns" mapstructure:"max_idle_conns"`                   // 最大空闲连接数
+	MaxIdleConnsPerHost int           `yaml:"max_idle_conns_per_host" mapstructure:"max_idle_conns_per_host"` // 每个主机的最大空闲连接数
+	IdleConnTimeout     time.Duration `yaml:"idle_conn_timeout" mapstructure:"idle_conn_timeout"`             // 空闲连接保持时间
+	MaxRetries          int           `yaml:"max_retries" mapstructure:"max_retries"`                         // 幂等请求最大重试次数（-1 表示不重试）
+	RetryBackoff        time.Duration `yaml:"retry_backoff" mapstructure:"retry_backoff"`                     // 首次重试等待时间
+
+	GlobalRate       float64       `yaml:"global_rate" mapstructure:"global_rate"`             // 全局每秒请求数
+	GlobalBurst      int           `yaml:"global_burst" mapstructure:"global_burst"`           // 全局突发请求数
+	HostRate         float64       `yaml:"host_rate" mapstructure:"host_rate"`                 // 单个主机每秒请求数
+	HostBurst        int           `yaml:"host_burst" mapstructure:"host_burst"`               // 单个主机突发请求数
+	MaxConcurrent    int           `yaml:"max_concurrent" mapstructure:"max_concurrent"`       // 最大并发请求数
+	FailureThreshold int           `yaml:"failure_threshold" mapstructure:"failure_threshold"` // 连续失败多少次后熔断
+	OpenTimeout      time.Duration `yaml:"open_timeout" mapstructure:"open_timeout"`           // 熔断持续时间
+}
+
+// ClientConfig 转换为出站 HTTP 客户端配置
+func (c OutboundConfig) ClientConfig() *httpclient.Config {
+	cfg := httpclient.DefaultConfig()
+	cfg.Proxy = c.Proxy
+	if c.Timeout > 0 {
+		cfg.Timeout = c.Timeout
+	}
+	if c.MaxIdleConns > 0 {
+		cfg.MaxIdleConns = c.MaxIdleConns
+	}
+	if c.MaxIdleConnsPerHost > 0 {
+		cfg.MaxIdleConnsPerHost = c.MaxIdleConnsPerHost
+	}
+	if c.IdleConnTimeout > 0 {
+		cfg.IdleConnTimeout = c.IdleConnTimeout
+	}
+	if c.MaxRetries != 0 {
+		cfg.MaxRetries = max(c.MaxRetries, 0)
+	}
+	if c.RetryBackoff > 0 {
+		cfg.RetryBackoff = c.RetryBackoff
+	}
+	return cfg
+}
+
+// GuardConfig 转换为出站请求保护配置
+func (c OutboundConfig) GuardConfig() *httpclient.GuardConfig {
+	cfg := httpclient.DefaultGuardConfig()
+	if c.GlobalRate > 0 {
+		cfg.GlobalRate = c.GlobalRate
+	}
+	if c.GlobalBurst > 0 {
+		cfg.GlobalBurst = c.GlobalBurst
+	}
+	if c.HostRate > 0 {
+		cfg.HostRate = c.HostRate
+	}
+	if c.HostBurst > 0 {
+		cfg.HostBurst = c.HostBurst
+	}
+	if c.MaxConcurrent > 0 {
+		cfg.MaxConcurrent = c.MaxConcurrent
+	}
+	if c.FailureThreshold > 0 {
+		cfg.FailureThreshold = c.FailureThreshold
+	}
+	if c.OpenTimeout > 0 {
+		cfg.OpenTimeout = c.OpenTimeout
+	}
+	return cfg
+}
+
+// ParseHealthConfig 教务页面解析健康度监控配置（未配置的项使用默认值）
+type ParseHealthConfig struct {
+	Window        int           `yaml:"window" mapstructure:"window"`                 // 计算失败率的最近解析次数
+	MinSamples    int           `yaml:"min_samples" mapstructure:"min_samples"`       // 窗口内至少解析多少次才判断失败率
+	Threshold     float64       `yaml:"threshold" mapstructure:"threshold"`           // 失败率告警阈值（0~1）
+	AlertCooldown time.Duration `yaml:"alert_cooldown" mapstructure:"alert_cooldown"` // 同一页面两次告警的最小间隔
+	SampleLimit   int           `yaml:"sample_limit" mapstructure:"sample_limit"`     // 每种页面保留的失败样本数
+}
+
+// MonitorConfig 转换为解析健康度监控配置
+func (c ParseHealthConfig) MonitorConfig() *service.ParseMonitorConfig {
+	cfg := service.DefaultParseMonitorConfig()
+	if c.Window > 0 {
+		cfg.Window = c.Window
+	}
+	if c.MinSamples > 0 {
+		cfg.MinSamples = c.MinSamples
+	}
+	if c.Threshold > 0 {
+		cfg.Threshold = c.Threshold
+	}
+	if c.AlertCooldown > 0 {
+		cfg.AlertCooldown = c.AlertCooldown
+	}
+	if c.SampleLimit > 0 {
+		cfg.SampleLimit = c.SampleLimit
+	}
+	return cfg
+}
+
 type DatabaseConfig struct {
 	Host string `yaml:"source" mapstructure:"source"`
 	Port int    `yaml:"port" mapstructure:"port"`
@@ -7,8 +7,11 @@ import (
 	"spider-go/internal/cache"
 	"spider-go/internal/middleware"
 	"spider-go/internal/modules/admin"
+	"spider-go/internal/modules/classroom"
 	"spider-go/internal/modules/config"
 	"spider-go/internal/modules/course"
+	"spider-go/internal/modules/electricity"
+	"spider-go/internal/modules/evaluation"
 	"spider-go/internal/modules/exam"
 	"spider-go/internal/modules/grade"
 	"spider-go/internal/modules/notice"
@@ -16,7 +19,9 @@ import (
 	"spider-go/internal/modules/user"
 	"spider-go/internal/service"
 	"spider-go/internal/shared"
+	"spider-go/pkg/httpclient"
 	pkgredis "spider-go/pkg/redis"
+	"spider-go/pkg/webvpn"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
@@ -39,28 +44,39 @@ type Container struct {
 	UserQuery shared.UserQuery
 
 	// Caches
-	SessionCache  cache.SessionCache
-	CaptchaCache  cache.CaptchaCache
-	DAUCache      cache.DAUCache
-	ConfigCache   cache.ConfigCache
-	UserDataCache cache.UserDataCache
+	SessionCache     cache.SessionCache
+	CaptchaCache     cache.CaptchaCache
+	DAUCache         cache.DAUCache
+	ConfigCache      cache.ConfigCache
+	UserDataCache    cache.UserDataCache
+	ParseHealthCache cache.ParseHealthCache
+	TaskLockCache    cache.TaskLockCache
+
+	// 出站 HTTP（共享连接池、代理、重试，经保护层限流、熔断并计数）
+	HTTPClient    httpclient.Client
+	OutboundGuard *httpclient.Guard
 
 	// Services (infrastructure services only)
-	RSAKeyService  service.RSAKeyService
-	SessionService service.SessionService
-	CrawlerService service.CrawlerService
-	EmailService   service.EmailService
-	DAUService     service.DAUService
+	RSAKeyService     service.RSAKeyService
+	SessionService    service.SessionService
+	CrawlerService    service.CrawlerService
+	EmailService      service.EmailService
+	DAUService        service.DAUService
+	CredentialService service.CredentialService
+	ParseMonitor      service.ParseMonitor
 
 	// Modules (new architecture)
-	UserModule       *user.Module
-	AdminModule      *admin.Module
-	GradeModule      *grade.Module
-	CourseModule     *course.Module
-	ExamModule       *exam.Module
-	NoticeModule     *notice.Module
-	ConfigModule     *config.Module
-	StatisticsModule *statistics.Module
+	UserModule        *user.Module
+	AdminModule       *admin.Module
+	GradeModule       *grade.Module
+	CourseModule      *course.Module
+	ExamModule        *exam.Module
+	EvaluationModule  *evaluation.Module
+	ElectricityModule *electricity.Module
+	ClassroomModule   *classroom.Module
+	NoticeModule      *notice.Module
+	ConfigModule      *config.Module
+	StatisticsModule  *statistics.Module
 }
 
 // NewContainer 创建依赖注入容器
@@ -82,20 +98,24 @@ func NewContainer(configPath string) (*Container, error) {
 		return nil, fmt.Errorf("初始化Redis失败: %w", err)
 	}
 
-	// 初始化共享查询服务
-	c.initSharedServices()
-
 	// 初始化 Caches
 	c.initCaches()
 
 	// 初始化 Services
-	c.initServices()
+	if err := c.initServices(); err != nil {
+		return nil, fmt.Errorf("初始化服务失败: %w", err)
+	}
+
+	// 初始化共享查询服务（依赖凭据加密服务）
+	c.initSharedServices()
 
 	// 初始化中间件
 	c.initMiddlewares()
 
 	// 初始化 Modules
-	c.initModules()
+	if err := c.initModules(); err != nil {
+		return nil, fmt.Errorf("初始化模块失败: %w", err)
+	}
 
 	// 初始化 RSA 公钥（首次获取）
 	if err := c.initRSAPublicKey(); err != nil {
@@ -107,6 +127,11 @@ func NewContainer(configPath string) (*Container, error) {
 		return nil, fmt.Errorf("初始化默认管理员失败: %w", err)
 	}
 
+	// 迁移存量明文教务密码
+	if err := c.migrateCredentials(); err != nil {
+		return nil, fmt.Errorf("迁移教务密码失败: %w", err)
+	}
+
 	return c, nil
 }
 
@@ -162,7 +187,7 @@ func (c *Container) createRedisClient(config RedisConfig) (*redis.Client, error)
 
 // initSharedServices 初始化共享服务
 func (c *Container) initSharedServices() {
-	c.UserQuery = shared.NewUserQuery(c.DB)
+	c.UserQuery = shared.NewUserQuery(c.DB, c.CredentialService)
 }
 
 // initCaches 初始化 Caches
@@ -177,30 +202,53 @@ func (c *Container) initCaches() {
 	c.ConfigCache = cache.NewRedisConfigCache(c.SessionRedis)
 	// 用户数据缓存（DB 0，与会话共用）
 	c.UserDataCache = cache.NewRedisUserDataCache(c.SessionRedis)
+	// 解析健康度缓存（DB 0，与会话共用）
+	c.ParseHealthCache = cache.NewRedisParseHealthCache(c.SessionRedis)
+	// 定时任务锁（DB 0，与会话共用）
+	c.TaskLockCache = cache.NewRedisTaskLockCache(c.SessionRedis)
 }
 
 // initServices 初始化 Services（仅基础设施服务）
-func (c *Container) initServices() {
-	// 获取当前模式的配置
-	currentMode := c.Config.Jwc.GetCurrentModeConfig()
-	log.Printf("教务系统模式: %s", c.Config.Jwc.Mode)
+func (c *Container) initServices() error {
+	jwc := c.Config.Jwc
+	log.Printf("教务系统模式: %s", jwc.Mode)
+
+	// Login Strategy（访问策略：校园网、WebVPN 或自动探测）
+	loginStrategy := service.NewLoginStrategy(
+		jwc.Mode,
+		service.LoginEndpoints{LoginURL: jwc.URLs.LoginURL, RedirectURL: jwc.URLs.RedirectURL},
+		jwc.Webvpn.LoginURL,
+		webvpn.NewTranslator(jwc.Webvpn.Host),
+	)
+
+	// Outbound HTTP（所有出站请求共享的连接池、代理、限流、并发池和熔断器）
+	clientConfig := c.Config.Outbound.ClientConfig()
+	transport, err := httpclient.NewTransport(clientConfig)
+	if err != nil {
+		return err
+	}
+	c.OutboundGuard = httpclient.NewGuard(transport, c.Config.Outbound.GuardConfig())
+	c.HTTPClient = httpclient.New(clientConfig, c.OutboundGuard)
 
 	// RSA Key Service（RSA 公钥服务）
-	c.RSAKeyService = service.NewRSAKeyService(c.Config.Jwc.GetRSAKeyURL)
+	c.RSAKeyService = service.NewRSAKeyService(c.Config.Jwc.GetRSAKeyURL, c.HTTPClient)
 
-	// Session Service（根据配置模式注入对应的 URL）
+	// Session Service（登录地址由访问策略决定）
 	c.SessionService = service.NewJwcSessionService(
 		c.SessionCache,
 		c.RSAKeyService,
-		c.Config.Jwc.Mode, // 注入当前模式
-		currentMode.LoginURL,
-		currentMode.RedirectURL,
+		c.HTTPClient,
+		loginStrategy,
 		c.Config.Jwc.CaptchaURL,
 		c.Config.Jwc.CaptchaImageURL,
 	)
 
 	// Crawler Service
-	c.CrawlerService = service.NewHttpCrawlerService()
+	c.CrawlerService = service.NewHttpCrawlerService(loginStrategy, c.SessionService, c.HTTPClient)
+	if jwc.RecordDir != "" {
+		log.Printf("爬虫录制模式已开启，页面保存到: %s", jwc.RecordDir)
+		c.CrawlerService = service.NewRecordingCrawlerService(c.CrawlerService, jwc.RecordDir)
+	}
 
 	// Email Service（邮件服务）
 	c.EmailService = service.NewEmailService(
@@ -213,6 +261,15 @@ func (c *Container) initServices() {
 
 	// DAU Service（日活统计服务）
 	c.DAUService = service.NewDAUService(c.DAUCache)
+
+	// Credential Service（教务密码加密服务）
+	credentialService, err := service.NewCredentialService(c.DB, c.Config.Security.GetMasterKey())
+	if err != nil {
+		return err
+	}
+	c.CredentialService = credentialService
+
+	return nil
 }
 
 // initMiddlewares 初始化中间件
@@ -229,20 +286,9 @@ func (c *Container) initMiddlewares() {
 }
 
 // initModules 初始化模块
-func (c *Container) initModules() {
-	// 获取当前模式的配置
-	currentMode := c.Config.Jwc.GetCurrentModeConfig()
-
-	// User Module（用户模块）
-	c.UserModule = user.NewModule(
-		c.DB,
-		c.SessionService,
-		c.CaptchaCache,
-		c.EmailService,
-		c.DAUService,
-		c.Config.JWT.Secret,
-		c.Config.JWT.Issuer,
-	)
+func (c *Container) initModules() error {
+	// 教务系统地址（校园网地址，由 CrawlerService 按访问策略改写）
+	jwcURLs := c.Config.Jwc.URLs
 
 	// Admin Module（管理员模块）
 	c.AdminModule = admin.NewModule(
@@ -253,32 +299,94 @@ func (c *Container) initModules() {
 		c.Config.JWT.Issuer,
 	)
 
+	// Parse Monitor（教务页面解析健康度，失败率过高时邮件通知管理员）
+	c.ParseMonitor = service.NewParseMonitor(
+		c.ParseHealthCache,
+		c.EmailService,
+		c.AdminModule.GetService(),
+		c.Config.ParseHealth.MonitorConfig(),
+	)
+
 	// Grade Module（成绩模块）
 	c.GradeModule = grade.NewModule(
+		c.DB,
 		c.UserQuery,
-		c.SessionService,
 		c.CrawlerService,
+		c.ParseMonitor,
 		c.UserDataCache,
-		currentMode.GradeURL,
-		currentMode.GradeLevelURL,
+		jwcURLs.GradeURL,
+		jwcURLs.GradeLevelURL,
 	)
 
 	// Course Module（课程模块）
-	c.CourseModule = course.NewModule(
+	courseModule, err := course.NewModule(
+		c.DB,
 		c.UserQuery,
-		c.SessionService,
 		c.CrawlerService,
+		c.ParseMonitor,
 		c.UserDataCache,
-		currentMode.CourseURL,
+		c.ConfigCache,
+		jwcURLs.CourseURL,
+		c.Config.Course.Periods,
+		c.Config.Course.CalendarBaseURL,
 	)
+	if err != nil {
+		return fmt.Errorf("初始化课程模块失败: %w", err)
+	}
+	c.CourseModule = courseModule
 
 	// Exam Module（考试模块）
 	c.ExamModule = exam.NewModule(
+		c.DB,
 		c.UserQuery,
+		c.CrawlerService,
+		c.ParseMonitor,
+		c.UserDataCache,
+		c.ConfigCache,
+		jwcURLs.ExamURL,
+	)
+
+	// User Module（用户模块，解除教务绑定时由成绩、课程、考试模块清理各自的数据）
+	c.UserModule = user.NewModule(
+		c.DB,
 		c.SessionService,
+		c.UserDataCache,
+		c.CaptchaCache,
+		c.EmailService,
+		c.DAUService,
+		c.CredentialService,
+		[]shared.JwcUnbindHook{
+			c.GradeModule.GetService(),
+			c.CourseModule.GetService(),
+			c.ExamModule.GetService(),
+		},
+		c.Config.JWT.Secret,
+		c.Config.JWT.Issuer,
+	)
+
+	// Evaluation Module（教评模块）
+	c.EvaluationModule = evaluation.NewModule(
+		c.UserQuery,
+		c.CrawlerService,
+		c.ConfigCache,
+		jwcURLs.EvaluationURL,
+	)
+
+	// Electricity Module（电费模块）
+	c.ElectricityModule = electricity.NewModule(
+		c.DB,
+		c.CrawlerService,
+		c.UserDataCache,
+		c.Config.Electricity.QueryURL,
+	)
+
+	// Classroom Module（空教室模块）
+	c.ClassroomModule = classroom.NewModule(
+		c.UserQuery,
 		c.CrawlerService,
 		c.UserDataCache,
-		currentMode.ExamURL,
+		c.ConfigCache,
+		jwcURLs.ClassroomURL,
 	)
 
 	// Notice Module（通知模块）
@@ -288,7 +396,9 @@ func (c *Container) initModules() {
 	c.ConfigModule = config.NewModule(c.ConfigCache)
 
 	// Statistics Module（统计模块）
-	c.StatisticsModule = statistics.NewModule(c.DAUService)
+	c.StatisticsModule = statistics.NewModule(c.DAUService, c.ParseMonitor)
+
+	return nil
 }
 
 // initRSAPublicKey 初始化 RSA 公钥
@@ -301,6 +411,18 @@ func (c *Container) initRSAPublicKey() error {
 	return nil
 }
 
+// migrateCredentials 加密存量明文教务密码
+func (c *Container) migrateCredentials() error {
+	count, err := c.UserModule.GetService().ReencryptCredentials(context.Background())
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		log.Printf("已加密 %d 条存量教务密码", count)
+	}
+	return nil
+}
+
 // Close 关闭资源
 func (c *Container) Close() error {
 	// 关闭会话 Redis
@@ -3,8 +3,13 @@ package app
 import (
 	"fmt"
 	"spider-go/internal/modules/admin"
+	"spider-go/internal/modules/course"
+	"spider-go/internal/modules/electricity"
+	"spider-go/internal/modules/exam"
+	"spider-go/internal/modules/grade"
 	"spider-go/internal/modules/notice"
 	"spider-go/internal/modules/user"
+	"spider-go/internal/service"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -20,8 +25,25 @@ func InitDBWithConfig(config *Config) (*gorm.DB, error) {
 		return nil, err
 	}
 
+	// 成绩历史表唯一键变更（需在自动迁移创建新索引之前去重）
+	if err := grade.MigrateGradeRecords(db); err != nil {
+		return nil, fmt.Errorf("迁移成绩历史表失败: %w", err)
+	}
+
 	// 自动迁移（使用新模块中的模型）
-	if err := db.AutoMigrate(&user.User{}, &notice.Notice{}, &admin.Admin{}); err != nil {
+	if err := db.AutoMigrate(
+		&user.User{},
+		&notice.Notice{},
+		&admin.Admin{},
+		&service.CredentialKey{},
+		&electricity.DormBinding{},
+		&grade.GradeSubscription{},
+		&grade.GradeSnapshot{},
+		&grade.GradeRecord{},
+		&course.CalendarToken{},
+		&exam.ExamReminder{},
+		&exam.ExamReminderLog{},
+	); err != nil {
 		return nil, err
 	}
 
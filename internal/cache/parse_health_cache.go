@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ParseHealthCache 解析健康度缓存接口（解析失败的页面样本、告警去重）
+type ParseHealthCache interface {
+	// SaveSample 保存解析失败的页面样本（每种页面只保留最近 limit 条）
+	SaveSample(ctx context.Context, page string, sample []byte, limit int, ttl time.Duration) error
+
+	// ListSamples 获取页面样本（最新的在前）
+	ListSamples(ctx context.Context, page string) ([]string, error)
+
+	// AcquireAlert 抢占告警名额（ttl 内同一页面只告警一次，多实例部署时避免重复发邮件）
+	AcquireAlert(ctx context.Context, page string, ttl time.Duration) (bool, error)
+}
+
+// RedisParseHealthCache Redis 实现的解析健康度缓存
+type RedisParseHealthCache struct {
+	client *redis.Client
+}
+
+// NewRedisParseHealthCache 创建 Redis 解析健康度缓存
+func NewRedisParseHealthCache(client *redis.Client) ParseHealthCache {
+	return &RedisParseHealthCache{
+		client: client,
+	}
+}
+
+// SaveSample 保存页面样本
+func (c *RedisParseHealthCache) SaveSample(ctx context.Context, page string, sample []byte, limit int, ttl time.Duration) error {
+	key := c.getSampleKey(page)
+
+	pipe := c.client.TxPipeline()
+	pipe.LPush(ctx, key, sample)
+	pipe.LTrim(ctx, key, 0, int64(limit-1))
+	pipe.Expire(ctx, key, ttl)
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// ListSamples 获取页面样本
+func (c *RedisParseHealthCache) ListSamples(ctx context.Context, page string) ([]string, error) {
+	return c.client.LRange(ctx, c.getSampleKey(page), 0, -1).Result()
+}
+
+// AcquireAlert 抢占告警名额
+func (c *RedisParseHealthCache) AcquireAlert(ctx context.Context, page string, ttl time.Duration) (bool, error) {
+	return c.client.SetNX(ctx, c.getAlertKey(page), time.Now().Unix(), ttl).Result()
+}
+
+// getSampleKey 页面样本 key
+// 格式: parse:sample:{page}
+func (c *RedisParseHealthCache) getSampleKey(page string) string {
+	return fmt.Sprintf("parse:sample:%s", page)
+}
+
+// getAlertKey 告警去重 key
+// 格式: parse:alert:{page}
+func (c *RedisParseHealthCache) getAlertKey(page string) string {
+	return fmt.Sprintf("parse:alert:%s", page)
+}
@@ -20,8 +20,35 @@ type SessionCache interface {
 	DeleteCookies(ctx context.Context, uid int) error
 	// HasCookies 检查用户是否有缓存的 cookies
 	HasCookies(ctx context.Context, uid int) (bool, error)
+
+	// MarkCredentialInvalid 标记用户的教务密码已失效（不过期，重新绑定时清除）
+	MarkCredentialInvalid(ctx context.Context, uid int) error
+	// IsCredentialInvalid 检查用户的教务密码是否已失效
+	IsCredentialInvalid(ctx context.Context, uid int) (bool, error)
+	// ClearCredentialInvalid 清除教务密码失效标记
+	ClearCredentialInvalid(ctx context.Context, uid int) error
+
+	// SetCaptchaChallenge 暂存等待验证码的登录状态
+	SetCaptchaChallenge(ctx context.Context, challengeID string, data []byte, expiration time.Duration) error
+	// TakeCaptchaChallenge 取出并删除暂存的登录状态（不存在时返回 nil）
+	TakeCaptchaChallenge(ctx context.Context, challengeID string) ([]byte, error)
+
+	// AcquireLoginLock 获取用户的登录锁（跨实例保证同一账号同时只有一个登录）
+	AcquireLoginLock(ctx context.Context, uid int, token string, expiration time.Duration) (bool, error)
+	// ReleaseLoginLock 释放登录锁（只释放自己持有的锁）
+	ReleaseLoginLock(ctx context.Context, uid int, token string) error
+	// IsLoginLocked 检查用户是否正在登录
+	IsLoginLocked(ctx context.Context, uid int) (bool, error)
 }
 
+// releaseLockScript 仅当锁仍由自己持有时删除（避免锁过期后误删其他实例的锁）
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
 // RedisSessionCache Redis 实现的会话缓存
 type RedisSessionCache struct {
 	client *redis.Client
@@ -80,7 +107,77 @@ func (c *RedisSessionCache) HasCookies(ctx context.Context, uid int) (bool, erro
 	return count > 0, nil
 }
 
+// MarkCredentialInvalid 标记用户的教务密码已失效
+func (c *RedisSessionCache) MarkCredentialInvalid(ctx context.Context, uid int) error {
+	return c.client.Set(ctx, c.getInvalidKey(uid), time.Now().Unix(), 0).Err()
+}
+
+// IsCredentialInvalid 检查用户的教务密码是否已失效
+func (c *RedisSessionCache) IsCredentialInvalid(ctx context.Context, uid int) (bool, error) {
+	count, err := c.client.Exists(ctx, c.getInvalidKey(uid)).Result()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ClearCredentialInvalid 清除教务密码失效标记
+func (c *RedisSessionCache) ClearCredentialInvalid(ctx context.Context, uid int) error {
+	return c.client.Del(ctx, c.getInvalidKey(uid)).Err()
+}
+
+// SetCaptchaChallenge 暂存等待验证码的登录状态
+func (c *RedisSessionCache) SetCaptchaChallenge(ctx context.Context, challengeID string, data []byte, expiration time.Duration) error {
+	return c.client.Set(ctx, c.getChallengeKey(challengeID), data, expiration).Err()
+}
+
+// TakeCaptchaChallenge 取出并删除暂存的登录状态（每个挑战只能使用一次）
+func (c *RedisSessionCache) TakeCaptchaChallenge(ctx context.Context, challengeID string) ([]byte, error) {
+	data, err := c.client.GetDel(ctx, c.getChallengeKey(challengeID)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// AcquireLoginLock 获取用户的登录锁
+func (c *RedisSessionCache) AcquireLoginLock(ctx context.Context, uid int, token string, expiration time.Duration) (bool, error) {
+	return c.client.SetNX(ctx, c.getLockKey(uid), token, expiration).Result()
+}
+
+// ReleaseLoginLock 释放登录锁（只释放自己持有的锁）
+func (c *RedisSessionCache) ReleaseLoginLock(ctx context.Context, uid int, token string) error {
+	return releaseLockScript.Run(ctx, c.client, []string{c.getLockKey(uid)}, token).Err()
+}
+
+// IsLoginLocked 检查用户是否正在登录
+func (c *RedisSessionCache) IsLoginLocked(ctx context.Context, uid int) (bool, error) {
+	n, err := c.client.Exists(ctx, c.getLockKey(uid)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
 // getUserKey 获取用户的 Redis key
 func (c *RedisSessionCache) getUserKey(uid int) string {
 	return "session:" + strconv.Itoa(uid)
 }
+
+// getInvalidKey 获取教务密码失效标记的 Redis key
+func (c *RedisSessionCache) getInvalidKey(uid int) string {
+	return "session:invalid:" + strconv.Itoa(uid)
+}
+
+// getChallengeKey 获取验证码挑战的 Redis key
+func (c *RedisSessionCache) getChallengeKey(challengeID string) string {
+	return "session:captcha:" + challengeID
+}
+
+// getLockKey 获取登录锁的 Redis key
+func (c *RedisSessionCache) getLockKey(uid int) string {
+	return "session:lock:" + strconv.Itoa(uid)
+}
@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TaskLockCache 定时任务锁接口（多实例部署时同一任务同时只在一个实例上执行）
+type TaskLockCache interface {
+	// AcquireTaskLock 获取任务锁（token 标识持有者，expiration 为任务执行超时后的兜底过期时间）
+	AcquireTaskLock(ctx context.Context, task string, token string, expiration time.Duration) (bool, error)
+
+	// ReleaseTaskLock 释放任务锁（只释放自己持有的锁）
+	ReleaseTaskLock(ctx context.Context, task string, token string) error
+}
+
+// RedisTaskLockCache Redis 实现的定时任务锁
+type RedisTaskLockCache struct {
+	client *redis.Client
+}
+
+// NewRedisTaskLockCache 创建 Redis 定时任务锁
+func NewRedisTaskLockCache(client *redis.Client) TaskLockCache {
+	return &RedisTaskLockCache{
+		client: client,
+	}
+}
+
+// AcquireTaskLock 获取任务锁
+func (c *RedisTaskLockCache) AcquireTaskLock(ctx context.Context, task string, token string, expiration time.Duration) (bool, error) {
+	return c.client.SetNX(ctx, c.getLockKey(task), token, expiration).Result()
+}
+
+// ReleaseTaskLock 释放任务锁（与登录锁共用释放脚本）
+func (c *RedisTaskLockCache) ReleaseTaskLock(ctx context.Context, task string, token string) error {
+	return releaseLockScript.Run(ctx, c.client, []string{c.getLockKey(task)}, token).Err()
+}
+
+// getLockKey 任务锁 key
+// 格式: task:lock:{task}
+func (c *RedisTaskLockCache) getLockKey(task string) string {
+	return fmt.Sprintf("task:lock:%s", task)
+}
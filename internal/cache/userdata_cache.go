@@ -16,17 +16,36 @@ type UserDataCache interface {
 	// GetGrades 获取成绩缓存
 	GetGrades(ctx context.Context, uid int, term string, target interface{}) error
 
-	// CacheCourseTable 缓存课表数据
-	CacheCourseTable(ctx context.Context, uid int, term string, week int, data interface{}, expiration time.Duration) error
-	// GetCourseTable 获取课表缓存
-	GetCourseTable(ctx context.Context, uid int, term string, week int, target interface{}) error
+	// CacheTermCourseTable 缓存整学期课表
+	CacheTermCourseTable(ctx context.Context, uid int, term string, data interface{}, expiration time.Duration) error
+	// GetTermCourseTable 获取整学期课表缓存
+	GetTermCourseTable(ctx context.Context, uid int, term string, target interface{}) error
+	// GetStaleTermCourseTable 获取整学期课表的历史副本（教务系统不可用时使用）
+	GetStaleTermCourseTable(ctx context.Context, uid int, term string, target interface{}) error
+	// ScanTermCourseTables 遍历某学期所有已缓存的整学期课表（传入原始 JSON）
+	ScanTermCourseTables(ctx context.Context, term string, fn func(data []byte) error) error
 
 	// CacheExams 缓存考试安排
 	CacheExams(ctx context.Context, uid int, term string, data interface{}, expiration time.Duration) error
 	// GetExams 获取考试安排缓存
 	GetExams(ctx context.Context, uid int, term string, target interface{}) error
+	// GetStaleExams 获取考试安排的历史副本（教务系统不可用时使用）
+	GetStaleExams(ctx context.Context, uid int, term string, target interface{}) error
+
+	// CacheElectricity 缓存电费数据
+	CacheElectricity(ctx context.Context, uid int, data interface{}, expiration time.Duration) error
+	// GetElectricity 获取电费缓存
+	GetElectricity(ctx context.Context, uid int, target interface{}) error
+	// DeleteElectricity 删除电费缓存
+	DeleteElectricity(ctx context.Context, uid int) error
+
+	// DeleteUserData 删除用户的全部数据缓存
+	DeleteUserData(ctx context.Context, uid int) error
 }
 
+// staleExpiration 历史副本保留时间（正常缓存过期后，教务系统不可用时仍可返回）
+const staleExpiration = 7 * 24 * time.Hour
+
 // RedisUserDataCache Redis 实现的用户数据缓存
 type RedisUserDataCache struct {
 	client *redis.Client
@@ -59,19 +78,29 @@ func (c *RedisUserDataCache) GetGrades(ctx context.Context, uid int, term string
 	return json.Unmarshal(bytes, target)
 }
 
-// CacheCourseTable 缓存课表数据
-func (c *RedisUserDataCache) CacheCourseTable(ctx context.Context, uid int, term string, week int, data interface{}, expiration time.Duration) error {
-	key := c.getCourseKey(uid, term, week)
+// CacheTermCourseTable 缓存整学期课表
+func (c *RedisUserDataCache) CacheTermCourseTable(ctx context.Context, uid int, term string, data interface{}, expiration time.Duration) error {
+	key := c.getCourseKey(uid, term)
 	bytes, err := json.Marshal(data)
 	if err != nil {
 		return err
 	}
-	return c.client.Set(ctx, key, bytes, expiration).Err()
+	return c.setWithStale(ctx, key, bytes, expiration)
+}
+
+// GetTermCourseTable 获取整学期课表缓存
+func (c *RedisUserDataCache) GetTermCourseTable(ctx context.Context, uid int, term string, target interface{}) error {
+	key := c.getCourseKey(uid, term)
+	bytes, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes, target)
 }
 
-// GetCourseTable 获取课表缓存
-func (c *RedisUserDataCache) GetCourseTable(ctx context.Context, uid int, term string, week int, target interface{}) error {
-	key := c.getCourseKey(uid, term, week)
+// GetStaleTermCourseTable 获取整学期课表的历史副本
+func (c *RedisUserDataCache) GetStaleTermCourseTable(ctx context.Context, uid int, term string, target interface{}) error {
+	key := getStaleKey(c.getCourseKey(uid, term))
 	bytes, err := c.client.Get(ctx, key).Bytes()
 	if err != nil {
 		return err
@@ -79,6 +108,41 @@ func (c *RedisUserDataCache) GetCourseTable(ctx context.Context, uid int, term s
 	return json.Unmarshal(bytes, target)
 }
 
+// ScanTermCourseTables 遍历某学期所有已缓存的整学期课表
+func (c *RedisUserDataCache) ScanTermCourseTables(ctx context.Context, term string, fn func(data []byte) error) error {
+	pattern := fmt.Sprintf("data:course:*:%s:term", term)
+	var cursor uint64
+
+	for {
+		keys, next, err := c.client.Scan(ctx, cursor, pattern, 200).Result()
+		if err != nil {
+			return err
+		}
+
+		if len(keys) > 0 {
+			values, err := c.client.MGet(ctx, keys...).Result()
+			if err != nil {
+				return err
+			}
+			for _, v := range values {
+				// 扫描期间过期的键返回 nil
+				str, ok := v.(string)
+				if !ok {
+					continue
+				}
+				if err := fn([]byte(str)); err != nil {
+					return err
+				}
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
 // CacheExams 缓存考试安排
 func (c *RedisUserDataCache) CacheExams(ctx context.Context, uid int, term string, data interface{}, expiration time.Duration) error {
 	key := c.getExamKey(uid, term)
@@ -86,7 +150,7 @@ func (c *RedisUserDataCache) CacheExams(ctx context.Context, uid int, term strin
 	if err != nil {
 		return err
 	}
-	return c.client.Set(ctx, key, bytes, expiration).Err()
+	return c.setWithStale(ctx, key, bytes, expiration)
 }
 
 // GetExams 获取考试安排缓存
@@ -99,6 +163,74 @@ func (c *RedisUserDataCache) GetExams(ctx context.Context, uid int, term string,
 	return json.Unmarshal(bytes, target)
 }
 
+// GetStaleExams 获取考试安排的历史副本
+func (c *RedisUserDataCache) GetStaleExams(ctx context.Context, uid int, term string, target interface{}) error {
+	key := getStaleKey(c.getExamKey(uid, term))
+	bytes, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes, target)
+}
+
+// CacheElectricity 缓存电费数据
+func (c *RedisUserDataCache) CacheElectricity(ctx context.Context, uid int, data interface{}, expiration time.Duration) error {
+	key := c.getElectricityKey(uid)
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(ctx, key, bytes, expiration).Err()
+}
+
+// GetElectricity 获取电费缓存
+func (c *RedisUserDataCache) GetElectricity(ctx context.Context, uid int, target interface{}) error {
+	key := c.getElectricityKey(uid)
+	bytes, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes, target)
+}
+
+// DeleteElectricity 删除电费缓存
+func (c *RedisUserDataCache) DeleteElectricity(ctx context.Context, uid int) error {
+	return c.client.Del(ctx, c.getElectricityKey(uid)).Err()
+}
+
+// DeleteUserData 删除用户的全部数据缓存（成绩、课表、考试、电费）
+func (c *RedisUserDataCache) DeleteUserData(ctx context.Context, uid int) error {
+	patterns := []string{
+		fmt.Sprintf("data:grades:%d:*", uid),
+		fmt.Sprintf("data:course:%d:*", uid),
+		fmt.Sprintf("data:exam:%d:*", uid),
+		fmt.Sprintf("stale:data:course:%d:*", uid),
+		fmt.Sprintf("stale:data:exam:%d:*", uid),
+	}
+
+	keys := []string{c.getElectricityKey(uid)}
+	for _, pattern := range patterns {
+		iter := c.client.Scan(ctx, 0, pattern, 200).Iterator()
+		for iter.Next(ctx) {
+			keys = append(keys, iter.Val())
+		}
+		if err := iter.Err(); err != nil {
+			return err
+		}
+	}
+
+	return c.client.Del(ctx, keys...).Err()
+}
+
+// setWithStale 写入缓存，同时写入保留更久的历史副本
+func (c *RedisUserDataCache) setWithStale(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	pipe := c.client.TxPipeline()
+	pipe.Set(ctx, key, value, expiration)
+	pipe.Set(ctx, getStaleKey(key), value, staleExpiration)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
 // 键生成辅助方法
 func (c *RedisUserDataCache) getGradesKey(uid int, term string) string {
 	if term == "" {
@@ -107,10 +239,18 @@ func (c *RedisUserDataCache) getGradesKey(uid int, term string) string {
 	return fmt.Sprintf("data:grades:%d:%s", uid, term)
 }
 
-func (c *RedisUserDataCache) getCourseKey(uid int, term string, week int) string {
-	return fmt.Sprintf("data:course:%d:%s:%d", uid, term, week)
+func (c *RedisUserDataCache) getCourseKey(uid int, term string) string {
+	return fmt.Sprintf("data:course:%d:%s:term", uid, term)
 }
 
 func (c *RedisUserDataCache) getExamKey(uid int, term string) string {
 	return fmt.Sprintf("data:exam:%d:%s", uid, term)
 }
+
+func (c *RedisUserDataCache) getElectricityKey(uid int) string {
+	return fmt.Sprintf("data:electricity:%d", uid)
+}
+
+func getStaleKey(key string) string {
+	return "stale:" + key
+}
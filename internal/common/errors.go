@@ -20,6 +20,14 @@ const (
 	CodeJwcLoginFailed    = pkgerrors.CodeJwcLoginFailed
 	CodeJwcParseFailed    = pkgerrors.CodeJwcParseFailed
 	CodeJwcRequestFailed  = pkgerrors.CodeJwcRequestFailed
+	CodeDormNotBound      = pkgerrors.CodeDormNotBound
+	CodeJwcBindInvalid    = pkgerrors.CodeJwcBindInvalid
+	CodeJwcWrongPassword  = pkgerrors.CodeJwcWrongPassword
+	CodeJwcAccountLocked  = pkgerrors.CodeJwcAccountLocked
+	CodeJwcNeedCaptcha    = pkgerrors.CodeJwcNeedCaptcha
+	CodeJwcPwdExpired     = pkgerrors.CodeJwcPwdExpired
+	CodeJwcMaintenance    = pkgerrors.CodeJwcMaintenance
+	CodeJwcUnavailable    = pkgerrors.CodeJwcUnavailable
 	CodeCacheError        = pkgerrors.CodeCacheError
 )
 
@@ -30,7 +30,11 @@ func Error(c *gin.Context, code int, message string) {
 	})
 }
 
-// ErrorWithAppError 使用 AppError 响应
+// ErrorWithAppError 使用 AppError 响应（附加数据放在 data 中）
 func ErrorWithAppError(c *gin.Context, err *AppError) {
-	Error(c, err.Code, err.Message)
+	c.JSON(http.StatusOK, Response{
+		Code:    err.Code,
+		Message: err.Message,
+		Data:    err.Data,
+	})
 }
@@ -18,6 +18,7 @@ type Repository interface {
 	FindByEmail(ctx context.Context, email string) (*Admin, error)
 	UpdatePassword(ctx context.Context, uid int, password string) error
 	CheckExists(ctx context.Context) (bool, error)
+	ListEmails(ctx context.Context) ([]string, error)
 }
 
 // adminRepository 管理员数据访问实现
@@ -72,3 +73,12 @@ func (r *adminRepository) CheckExists(ctx context.Context) (bool, error) {
 	}
 	return count > 0, nil
 }
+
+// ListEmails 获取所有管理员邮箱
+func (r *adminRepository) ListEmails(ctx context.Context) ([]string, error) {
+	var emails []string
+	if err := r.db.WithContext(ctx).Model(&Admin{}).Pluck("email", &emails).Error; err != nil {
+		return nil, err
+	}
+	return emails, nil
+}
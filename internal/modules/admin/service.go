@@ -26,6 +26,8 @@ type Service interface {
 	// 系统管理
 	InitDefaultAdmin(ctx context.Context) error
 	BroadcastEmail(ctx context.Context, subject, content string) (successCount, failCount, totalCount int, err error)
+	// ListAdminEmails 获取所有管理员邮箱（系统告警收件人）
+	ListAdminEmails(ctx context.Context) ([]string, error)
 }
 
 // adminService 管理员服务实现
@@ -178,3 +180,12 @@ func (s *adminService) BroadcastEmail(ctx context.Context, subject, content stri
 
 	return successCount, failCount, len(emails), nil
 }
+
+// ListAdminEmails 获取所有管理员邮箱
+func (s *adminService) ListAdminEmails(ctx context.Context) ([]string, error) {
+	emails, err := s.repo.ListEmails(ctx)
+	if err != nil {
+		return nil, errors.New("获取管理员邮箱列表失败")
+	}
+	return emails, nil
+}
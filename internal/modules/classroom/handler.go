@@ -0,0 +1,98 @@
+package classroom
+
+import (
+	"spider-go/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler 空教室HTTP处理器
+type Handler struct {
+	service Service
+}
+
+// NewHandler 创建空教室处理器
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// RegisterRoutes 注册路由
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	classrooms := r.Group("/classrooms")
+	{
+		classrooms.GET("/free", h.FindFreeRooms)     // 查询空教室
+		classrooms.GET("/buildings", h.GetBuildings) // 获取教学楼列表
+	}
+}
+
+// FindFreeRooms 查询空教室
+// @Summary 查询空教室
+// @Tags Classroom
+// @Produce json
+// @Param term query string false "学期（默认当前学期）" example(2024-2025-1)
+// @Param building query string false "教学楼（为空时查询全部）"
+// @Param week query int true "周次" minimum(1) maximum(30)
+// @Param weekday query int true "星期" minimum(1) maximum(7)
+// @Param periods query string true "节次" example(1-2)
+// @Success 200 {object} FreeRoomsResponse
+// @Router /classrooms/free [get]
+func (h *Handler) FindFreeRooms(c *gin.Context) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		common.Error(c, common.CodeUnauthorized, "未授权")
+		return
+	}
+
+	var req FreeRoomsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.Error(c, common.CodeInvalidParams, err.Error())
+		return
+	}
+
+	resp, err := h.service.FindFreeRooms(c.Request.Context(), uid.(int), &req)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.ErrorWithAppError(c, appErr)
+		} else {
+			common.Error(c, common.CodeInternalError, "查询空教室失败")
+		}
+		return
+	}
+
+	common.Success(c, resp)
+}
+
+// GetBuildings 获取教学楼列表
+// @Summary 获取教学楼列表
+// @Tags Classroom
+// @Produce json
+// @Param term query string false "学期（默认当前学期）" example(2024-2025-1)
+// @Success 200 {array} string
+// @Router /classrooms/buildings [get]
+func (h *Handler) GetBuildings(c *gin.Context) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		common.Error(c, common.CodeUnauthorized, "未授权")
+		return
+	}
+
+	var req GetBuildingsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.Error(c, common.CodeInvalidParams, err.Error())
+		return
+	}
+
+	buildings, err := h.service.GetBuildings(c.Request.Context(), uid.(int), req.Term)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.ErrorWithAppError(c, appErr)
+		} else {
+			common.Error(c, common.CodeInternalError, "获取教学楼列表失败")
+		}
+		return
+	}
+
+	common.Success(c, buildings)
+}
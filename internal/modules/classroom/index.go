@@ -0,0 +1,144 @@
+package classroom
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// maxPeriods 每天最多节次
+	maxPeriods = 16
+	// maxWeeks 支持的最大周次（周次以位图存储）
+	maxWeeks = 63
+	// allWeeks 整学期占用
+	allWeeks = ^uint64(0)
+)
+
+// roomSchedule 教室占用位图：[星期][节次] -> 周次位图（第 n 位为 1 表示第 n 周被占用）
+type roomSchedule [7][maxPeriods]uint64
+
+// occupancyIndex 某学期的教室占用索引（构建后只读，可并发查询）
+type occupancyIndex struct {
+	term      string
+	source    string
+	builtAt   time.Time
+	buildings []string                 // 教学楼（升序）
+	rooms     map[string][]string      // 教学楼 -> 教室（升序）
+	schedules map[string]*roomSchedule // 教室 -> 占用位图
+}
+
+// newOccupancyIndex 根据占用记录构建索引
+func newOccupancyIndex(term, source string, items []occupancy, builtAt time.Time) *occupancyIndex {
+	idx := &occupancyIndex{
+		term:      term,
+		source:    source,
+		builtAt:   builtAt,
+		rooms:     make(map[string][]string),
+		schedules: make(map[string]*roomSchedule),
+	}
+
+	for _, o := range items {
+		room := normalizeRoom(o.Room)
+		if room == "" {
+			continue
+		}
+
+		sch, ok := idx.schedules[room]
+		if !ok {
+			sch = &roomSchedule{}
+			idx.schedules[room] = sch
+			building := buildingOf(room)
+			idx.rooms[building] = append(idx.rooms[building], room)
+		}
+
+		if o.Weekday < 1 || o.Weekday > 7 || o.StartPeriod < 1 || o.StartPeriod > o.EndPeriod {
+			continue // 只登记教室，不登记占用
+		}
+
+		mask := weeksMask(o.Weeks)
+		for p := o.StartPeriod; p <= o.EndPeriod && p <= maxPeriods; p++ {
+			sch[o.Weekday-1][p-1] |= mask
+		}
+	}
+
+	for building, rooms := range idx.rooms {
+		sort.Strings(rooms)
+		idx.buildings = append(idx.buildings, building)
+	}
+	sort.Strings(idx.buildings)
+	return idx
+}
+
+// freeRooms 查询指定周次、星期、节次全部空闲的教室，building 为空时查询全部教学楼
+func (idx *occupancyIndex) freeRooms(building string, week, weekday int, periods []int) []BuildingRooms {
+	buildings := idx.buildings
+	if building != "" {
+		buildings = nil
+		for _, b := range idx.buildings {
+			if strings.Contains(b, building) {
+				buildings = append(buildings, b)
+			}
+		}
+	}
+
+	bit := uint64(1) << uint(week)
+	res := make([]BuildingRooms, 0, len(buildings))
+	for _, b := range buildings {
+		var free []string
+		for _, room := range idx.rooms[b] {
+			sch := idx.schedules[room]
+			busy := false
+			for _, p := range periods {
+				if sch[weekday-1][p-1]&bit != 0 {
+					busy = true
+					break
+				}
+			}
+			if !busy {
+				free = append(free, room)
+			}
+		}
+		if len(free) > 0 {
+			res = append(res, BuildingRooms{Building: b, Rooms: free})
+		}
+	}
+	return res
+}
+
+// weeksMask 周次列表转位图，为空表示整学期
+func weeksMask(weeks []int) uint64 {
+	if len(weeks) == 0 {
+		return allWeeks
+	}
+	var mask uint64
+	for _, w := range weeks {
+		if w >= 1 && w <= maxWeeks {
+			mask |= 1 << uint(w)
+		}
+	}
+	return mask
+}
+
+// normalizeRoom 规范化教室名：去掉空白与容量后缀，如 "东A101(120)" -> "东A101"
+func normalizeRoom(room string) string {
+	room = strings.Join(strings.Fields(room), "")
+	if i := strings.IndexAny(room, "(（"); i > 0 {
+		room = room[:i]
+	}
+	return room
+}
+
+// buildingOf 从教室名推断教学楼：取第一个数字之前的部分，
+// 如 "东A101" -> "东A"、"理科楼-201" -> "理科楼"；无法推断时返回教室名本身
+func buildingOf(room string) string {
+	i := strings.IndexFunc(room, unicode.IsDigit)
+	if i <= 0 {
+		return room
+	}
+	if building := strings.TrimRight(room[:i], "-_ "); building != "" {
+		return building
+	}
+	return room
+}
@@ -0,0 +1,49 @@
+package classroom
+
+import "time"
+
+// 数据来源
+const (
+	SourceJwc          = "jwc"           // 教务系统教室课表
+	SourceCourseTables = "course_tables" // 由已缓存的学生课表推断
+)
+
+// FreeRoomsRequest 空教室查询请求
+type FreeRoomsRequest struct {
+	Term     string `form:"term"`                                   // 学期：2024-2025-1（为空时使用当前学期）
+	Building string `form:"building"`                               // 教学楼（为空时查询全部）
+	Week     int    `form:"week" binding:"required,min=1,max=30"`   // 周次
+	Weekday  int    `form:"weekday" binding:"required,min=1,max=7"` // 星期：1~7
+	Periods  string `form:"periods" binding:"required"`             // 节次：如 "1-2"、"3,4"
+}
+
+// GetBuildingsRequest 教学楼列表请求
+type GetBuildingsRequest struct {
+	Term string `form:"term"` // 学期：2024-2025-1（为空时使用当前学期）
+}
+
+// BuildingRooms 某栋教学楼的教室
+type BuildingRooms struct {
+	Building string   `json:"building"` // 教学楼
+	Rooms    []string `json:"rooms"`    // 教室
+}
+
+// FreeRoomsResponse 空教室查询结果
+type FreeRoomsResponse struct {
+	Term      string          `json:"term"`       // 学期
+	Week      int             `json:"week"`       // 周次
+	Weekday   int             `json:"weekday"`    // 星期
+	Periods   []int           `json:"periods"`    // 节次
+	Source    string          `json:"source"`     // 数据来源：jwc / course_tables
+	UpdatedAt time.Time       `json:"updated_at"` // 索引构建时间
+	Buildings []BuildingRooms `json:"buildings"`  // 按教学楼分组的空教室
+}
+
+// occupancy 教室的一段占用
+type occupancy struct {
+	Room        string // 教室全名，如 "东A101"
+	Weekday     int    // 星期：1~7
+	StartPeriod int    // 开始节次
+	EndPeriod   int    // 结束节次
+	Weeks       []int  // 占用周次（为空表示整学期）
+}
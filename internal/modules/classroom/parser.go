@@ -0,0 +1,166 @@
+package classroom
+
+import (
+	"io"
+	"regexp"
+	"spider-go/internal/common"
+	"spider-go/internal/shared"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var (
+	// weekdayNames 表头中的星期
+	weekdayNames = map[string]int{"一": 1, "二": 2, "三": 3, "四": 4, "五": 5, "六": 6, "日": 7, "天": 7}
+	// weekdayPattern 匹配 "星期一"、"周一"
+	weekdayPattern = regexp.MustCompile(`(?:星期|周)([一二三四五六日天])`)
+	// periodPairPattern 匹配 "0102" 形式的节次
+	periodPairPattern = regexp.MustCompile(`^(\d{2})(\d{2})$`)
+	// periodRangePattern 匹配 "1-2节"、"第01-02节"
+	periodRangePattern = regexp.MustCompile(`(\d{1,2})\s*[-~－]\s*(\d{1,2})`)
+	// cellWeeksPattern 匹配单元格中的周次，如 "1-16(周)"、"(1-8,10周)"、"1-15单周"
+	cellWeeksPattern = regexp.MustCompile(`(\d[\d,，\-单双]*)\s*[(（]?周`)
+)
+
+// slot 教室课表中的一列
+type slot struct {
+	weekday     int
+	startPeriod int
+	endPeriod   int
+}
+
+// parseRoomTableFromHTML 解析教务系统教室课表（行为教室，列为 星期×节次）
+func parseRoomTableFromHTML(r io.Reader) ([]occupancy, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return nil, common.NewAppError(common.CodeJwcParseFailed, "解析HTML失败")
+	}
+
+	table := doc.Find("#kbtable")
+	if table.Length() == 0 {
+		table = doc.Find("table").FilterFunction(func(_ int, t *goquery.Selection) bool {
+			return weekdayPattern.MatchString(t.Find("tr").First().Text())
+		}).First()
+	}
+	if table.Length() == 0 {
+		return nil, common.NewAppError(common.CodeJwcParseFailed, "未找到教室课表")
+	}
+
+	rows := table.Find("tr")
+	if rows.Length() == 0 {
+		return nil, common.NewAppError(common.CodeJwcParseFailed, "未找到教室课表")
+	}
+
+	// 表头：第一行为星期（colspan 为当天的列数），第二行（可选）为节次
+	var periodLabels []string
+	if second := rows.Eq(1); second.Find("th").Length() > 0 {
+		second.Find("th").Each(func(_ int, th *goquery.Selection) {
+			periodLabels = append(periodLabels, strings.TrimSpace(th.Text()))
+		})
+	}
+	slots := buildSlots(rows.Eq(0), periodLabels)
+	if len(slots) == 0 {
+		return nil, common.NewAppError(common.CodeJwcParseFailed, "教室课表表头格式错误")
+	}
+
+	var items []occupancy
+	rows.Each(func(_ int, tr *goquery.Selection) {
+		tds := tr.Find("td")
+		if tds.Length() < 2 {
+			return // 表头或空行
+		}
+
+		room := normalizeRoom(tds.Eq(0).Text())
+		if room == "" {
+			return
+		}
+		// 先登记教室（即使整学期无课也会出现在空教室结果中）
+		items = append(items, occupancy{Room: room})
+
+		tds.Slice(1, tds.Length()).Each(func(i int, td *goquery.Selection) {
+			if i >= len(slots) {
+				return
+			}
+			text := strings.TrimSpace(strings.ReplaceAll(td.Text(), "\u00A0", ""))
+			if text == "" {
+				return
+			}
+			items = append(items, occupancy{
+				Room:        room,
+				Weekday:     slots[i].weekday,
+				StartPeriod: slots[i].startPeriod,
+				EndPeriod:   slots[i].endPeriod,
+				Weeks:       cellWeeks(text),
+			})
+		})
+	})
+
+	return items, nil
+}
+
+// buildSlots 根据表头计算每一数据列对应的星期与节次
+func buildSlots(header *goquery.Selection, periodLabels []string) []slot {
+	var slots []slot
+	labelIdx := 0
+
+	header.Find("th,td").Each(func(_ int, th *goquery.Selection) {
+		m := weekdayPattern.FindStringSubmatch(th.Text())
+		if m == nil {
+			return // 左上角 "教室\时间" 等
+		}
+		weekday := weekdayNames[m[1]]
+
+		span := 1
+		if v, err := strconv.Atoi(th.AttrOr("colspan", "1")); err == nil && v > 0 {
+			span = v
+		}
+
+		for k := 1; k <= span; k++ {
+			label := ""
+			if labelIdx < len(periodLabels) {
+				label = periodLabels[labelIdx]
+			}
+			labelIdx++
+
+			start, end := parsePeriodLabel(label, k)
+			slots = append(slots, slot{weekday: weekday, startPeriod: start, endPeriod: end})
+		}
+	})
+
+	return slots
+}
+
+// parsePeriodLabel 解析节次表头，如 "0102"、"1-2节"；无法识别时按当天第 ordinal 个大节（两小节）处理
+func parsePeriodLabel(label string, ordinal int) (start, end int) {
+	label = strings.TrimSpace(label)
+	if m := periodPairPattern.FindStringSubmatch(label); m != nil {
+		start, _ = strconv.Atoi(m[1])
+		end, _ = strconv.Atoi(m[2])
+	} else if m := periodRangePattern.FindStringSubmatch(label); m != nil {
+		start, _ = strconv.Atoi(m[1])
+		end, _ = strconv.Atoi(m[2])
+	}
+	if start < 1 || end < start || end > maxPeriods {
+		return ordinal*2 - 1, ordinal * 2
+	}
+	return start, end
+}
+
+// cellWeeks 提取单元格中所有课程的周次（并集），未标注周次时返回 nil（视为整学期）
+func cellWeeks(text string) []int {
+	matches := cellWeeksPattern.FindAllStringSubmatch(text, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	var weeks []int
+	for _, m := range matches {
+		weeks = append(weeks, shared.ParseWeeks(strings.ReplaceAll(m[1], "，", ","))...)
+	}
+	if len(weeks) == 0 {
+		return nil
+	}
+	return weeks
+}
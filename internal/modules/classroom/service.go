@@ -0,0 +1,310 @@
+package classroom
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/url"
+	"regexp"
+	"sort"
+	"spider-go/internal/cache"
+	"spider-go/internal/common"
+	"spider-go/internal/service"
+	"spider-go/internal/shared"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// jwcIndexTTL 教务系统教室课表索引的有效期（整学期排课很少变动）
+	jwcIndexTTL = 12 * time.Hour
+	// inferredIndexTTL 推断索引的有效期（缓存的学生课表会陆续增加，需较快刷新）
+	inferredIndexTTL = 30 * time.Minute
+)
+
+// Service 空教室服务接口
+type Service interface {
+	// FindFreeRooms 查询空教室
+	FindFreeRooms(ctx context.Context, uid int, req *FreeRoomsRequest) (*FreeRoomsResponse, error)
+	// GetBuildings 获取教学楼列表
+	GetBuildings(ctx context.Context, uid int, term string) ([]string, error)
+}
+
+// classroomService 空教室服务实现
+type classroomService struct {
+	userQuery      shared.UserQuery
+	crawlerService service.CrawlerService
+	userDataCache  cache.UserDataCache
+	configCache    cache.ConfigCache
+	classroomURL   string
+
+	mu      sync.RWMutex
+	indexes map[string]*occupancyIndex // 学期 -> 占用索引
+	buildMu sync.Mutex                 // 串行化索引构建，避免并发请求重复抓取
+}
+
+// NewService 创建空教室服务
+func NewService(
+	userQuery shared.UserQuery,
+	crawlerService service.CrawlerService,
+	userDataCache cache.UserDataCache,
+	configCache cache.ConfigCache,
+	classroomURL string,
+) Service {
+	return &classroomService{
+		userQuery:      userQuery,
+		crawlerService: crawlerService,
+		userDataCache:  userDataCache,
+		configCache:    configCache,
+		classroomURL:   classroomURL,
+		indexes:        make(map[string]*occupancyIndex),
+	}
+}
+
+// FindFreeRooms 查询指定周次、星期、节次都空闲的教室
+func (s *classroomService) FindFreeRooms(ctx context.Context, uid int, req *FreeRoomsRequest) (*FreeRoomsResponse, error) {
+	periods, err := parsePeriods(req.Periods)
+	if err != nil {
+		return nil, err
+	}
+	if req.Week > maxWeeks {
+		return nil, common.NewAppError(common.CodeInvalidParams, "周次超出范围")
+	}
+
+	term, err := s.resolveTerm(ctx, req.Term)
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := s.getIndex(ctx, uid, term)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FreeRoomsResponse{
+		Term:      term,
+		Week:      req.Week,
+		Weekday:   req.Weekday,
+		Periods:   periods,
+		Source:    idx.source,
+		UpdatedAt: idx.builtAt,
+		Buildings: idx.freeRooms(strings.TrimSpace(req.Building), req.Week, req.Weekday, periods),
+	}, nil
+}
+
+// GetBuildings 获取教学楼列表
+func (s *classroomService) GetBuildings(ctx context.Context, uid int, term string) ([]string, error) {
+	term, err := s.resolveTerm(ctx, term)
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := s.getIndex(ctx, uid, term)
+	if err != nil {
+		return nil, err
+	}
+	return idx.buildings, nil
+}
+
+// resolveTerm 校验学期，为空时使用当前学期
+func (s *classroomService) resolveTerm(ctx context.Context, term string) (string, error) {
+	if term == "" {
+		current, err := s.configCache.GetCurrentTerm(ctx)
+		if err != nil {
+			return "", common.NewAppError(common.CodeInternalError, err.Error())
+		}
+		term = current
+	}
+
+	re := regexp.MustCompile(`^\d{4}-\d{4}-[12]$`)
+	if !re.MatchString(term) {
+		return "", common.NewAppError(common.CodeJwcInvalidParams, "学期格式错误")
+	}
+	return term, nil
+}
+
+// getIndex 获取学期的占用索引，过期或不存在时重新构建
+// 优先抓取教务系统教室课表；失败时由已缓存的学生课表推断
+func (s *classroomService) getIndex(ctx context.Context, uid int, term string) (*occupancyIndex, error) {
+	if idx := s.freshIndex(term); idx != nil {
+		return idx, nil
+	}
+
+	s.buildMu.Lock()
+	defer s.buildMu.Unlock()
+
+	// 等锁期间可能已被其他请求构建
+	if idx := s.freshIndex(term); idx != nil {
+		return idx, nil
+	}
+
+	s.mu.RLock()
+	old := s.indexes[term]
+	s.mu.RUnlock()
+
+	items, err := s.fetchRoomTable(ctx, uid, term)
+	if err == nil && len(items) > 0 {
+		return s.storeIndex(newOccupancyIndex(term, SourceJwc, items, time.Now())), nil
+	}
+	if err != nil {
+		log.Printf("抓取教室课表失败 (term=%s): %v", term, err)
+	}
+
+	// 教务数据暂不可用时，继续使用旧的教务索引（比推断结果更完整）
+	if old != nil && old.source == SourceJwc {
+		return old, nil
+	}
+
+	items, err = s.inferFromCourseTables(ctx, term)
+	if err != nil {
+		if old != nil {
+			return old, nil
+		}
+		return nil, common.NewAppError(common.CodeCacheError, "读取课表缓存失败")
+	}
+	if len(items) == 0 {
+		return nil, common.NewAppError(common.CodeNotFound, "暂无该学期的教室数据")
+	}
+
+	return s.storeIndex(newOccupancyIndex(term, SourceCourseTables, items, time.Now())), nil
+}
+
+// freshIndex 返回未过期的索引
+func (s *classroomService) freshIndex(term string) *occupancyIndex {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	idx := s.indexes[term]
+	if idx == nil {
+		return nil
+	}
+	ttl := inferredIndexTTL
+	if idx.source == SourceJwc {
+		ttl = jwcIndexTTL
+	}
+	if time.Since(idx.builtAt) > ttl {
+		return nil
+	}
+	return idx
+}
+
+// storeIndex 保存索引
+func (s *classroomService) storeIndex(idx *occupancyIndex) *occupancyIndex {
+	s.mu.Lock()
+	s.indexes[idx.term] = idx
+	s.mu.Unlock()
+	return idx
+}
+
+// fetchRoomTable 使用当前用户的教务会话抓取整学期教室课表
+func (s *classroomService) fetchRoomTable(ctx context.Context, uid int, term string) ([]occupancy, error) {
+	if s.classroomURL == "" {
+		return nil, nil
+	}
+
+	user, err := s.userQuery.GetUserByUid(ctx, uid)
+	if err != nil {
+		return nil, common.NewAppError(common.CodeUserNotFound, "用户不存在")
+	}
+	if user.Sid == "" || user.Spwd == "" {
+		return nil, common.NewAppError(common.CodeJwcNotBound, "")
+	}
+
+	form := url.Values{}
+	form.Add("xnxqh", term)
+
+	account := &service.JwcAccount{Uid: uid, Username: user.Sid, Password: user.Spwd}
+	body, err := s.crawlerService.FetchWithSession(ctx, account, "POST", s.classroomURL, form)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return parseRoomTableFromHTML(body)
+}
+
+// cachedTermSchedule 缓存的整学期课表（只解析需要的字段）
+type cachedTermSchedule struct {
+	Courses []struct {
+		Classroom   string `json:"classroom"`
+		Weekday     int    `json:"weekday"`
+		StartPeriod int    `json:"start_period"`
+		EndPeriod   int    `json:"end_period"`
+		Weeks       string `json:"weeks"`
+	} `json:"courses"`
+}
+
+// inferFromCourseTables 由所有已缓存的学生课表推断教室占用
+// 只能覆盖出现在这些课表中的教室，结果可能不完整
+func (s *classroomService) inferFromCourseTables(ctx context.Context, term string) ([]occupancy, error) {
+	var items []occupancy
+	err := s.userDataCache.ScanTermCourseTables(ctx, term, func(data []byte) error {
+		var schedule cachedTermSchedule
+		if err := json.Unmarshal(data, &schedule); err != nil {
+			return nil // 跳过格式异常的缓存
+		}
+		for _, c := range schedule.Courses {
+			if strings.TrimSpace(c.Classroom) == "" {
+				continue
+			}
+			items = append(items, occupancy{
+				Room:        c.Classroom,
+				Weekday:     c.Weekday,
+				StartPeriod: c.StartPeriod,
+				EndPeriod:   c.EndPeriod,
+				Weeks:       shared.ParseWeeks(c.Weeks),
+			})
+		}
+		return nil
+	})
+	return items, err
+}
+
+// parsePeriods 解析节次参数，如 "1-2"、"3,4"、"1-2,5"，返回升序去重的节次
+func parsePeriods(s string) ([]int, error) {
+	invalid := common.NewAppError(common.CodeInvalidParams, "节次格式错误")
+
+	seen := make(map[int]struct{})
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		start, end := 0, 0
+		if se := strings.SplitN(part, "-", 2); len(se) == 2 {
+			a, err1 := strconv.Atoi(strings.TrimSpace(se[0]))
+			b, err2 := strconv.Atoi(strings.TrimSpace(se[1]))
+			if err1 != nil || err2 != nil {
+				return nil, invalid
+			}
+			start, end = a, b
+		} else {
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, invalid
+			}
+			start, end = n, n
+		}
+
+		if start < 1 || end < start || end > maxPeriods {
+			return nil, invalid
+		}
+		for p := start; p <= end; p++ {
+			seen[p] = struct{}{}
+		}
+	}
+
+	if len(seen) == 0 {
+		return nil, invalid
+	}
+
+	periods := make([]int, 0, len(seen))
+	for p := range seen {
+		periods = append(periods, p)
+	}
+	sort.Ints(periods)
+	return periods, nil
+}
@@ -1,28 +1,44 @@
 package course
 
 import (
+	"fmt"
+	"net/url"
 	"spider-go/internal/common"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 // Handler 课程HTTP处理器
 type Handler struct {
-	service Service
+	service         Service
+	calendarBaseURL string // 日历订阅地址使用的公开访问地址，为空时不提供订阅地址
 }
 
 // NewHandler 创建课程处理器
-func NewHandler(service Service) *Handler {
+func NewHandler(service Service, calendarBaseURL string) *Handler {
 	return &Handler{
-		service: service,
+		service:         service,
+		calendarBaseURL: calendarBaseURL,
 	}
 }
 
 // RegisterRoutes 注册路由
-func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
-	courses := r.Group("/courses")
+func (h *Handler) RegisterRoutes(public *gin.RouterGroup, authenticated *gin.RouterGroup) {
+	// 公开路由（日历客户端订阅，凭令牌访问）
+	calendar := public.Group("/calendar")
 	{
-		courses.GET("", h.GetCourseTable) // 获取课程表
+		calendar.GET("/:token", h.GetCalendarFeed) // 日历订阅源
+	}
+
+	courses := authenticated.Group("/courses")
+	{
+		courses.GET("", h.GetCourseTable)                         // 获取课程表
+		courses.GET("/term", h.GetTermCourseTable)                // 获取整学期课程表
+		courses.GET("/ics", h.ExportCalendar)                     // 导出整学期日历
+		courses.GET("/calendar-token", h.GetCalendarSubscription) // 获取日历订阅地址
+		courses.POST("/calendar-token", h.ResetCalendarToken)     // 生成/重置日历订阅地址
+		courses.DELETE("/calendar-token", h.RevokeCalendarToken)  // 取消日历订阅
 	}
 }
 
@@ -59,3 +75,205 @@ func (h *Handler) GetCourseTable(c *gin.Context) {
 
 	common.Success(c, schedule)
 }
+
+// GetTermCourseTable 获取整学期课程表
+// @Summary 获取整学期课程表
+// @Tags Course
+// @Produce json
+// @Param term query string true "学期" example(2024-2025-1)
+// @Success 200 {object} TermSchedule
+// @Router /courses/term [get]
+func (h *Handler) GetTermCourseTable(c *gin.Context) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		common.Error(c, common.CodeUnauthorized, "未授权")
+		return
+	}
+
+	var req GetTermCourseTableRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.Error(c, common.CodeInvalidParams, err.Error())
+		return
+	}
+
+	schedule, err := h.service.GetTermCourseTable(c.Request.Context(), uid.(int), req.Term)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.ErrorWithAppError(c, appErr)
+		} else {
+			common.Error(c, common.CodeInternalError, "获取课程表失败")
+		}
+		return
+	}
+
+	common.Success(c, schedule)
+}
+
+// ExportCalendar 导出整学期课程表为 iCalendar 文件
+// @Summary 导出课程表日历
+// @Tags Course
+// @Produce text/calendar
+// @Param term query string false "学期（默认当前学期）" example(2024-2025-1)
+// @Success 200 {file} file
+// @Router /courses/ics [get]
+func (h *Handler) ExportCalendar(c *gin.Context) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		common.Error(c, common.CodeUnauthorized, "未授权")
+		return
+	}
+
+	var req ExportCalendarRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.Error(c, common.CodeInvalidParams, err.Error())
+		return
+	}
+
+	data, err := h.service.ExportCalendar(c.Request.Context(), uid.(int), req.Term)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.ErrorWithAppError(c, appErr)
+		} else {
+			common.Error(c, common.CodeInternalError, "导出日历失败")
+		}
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="courses.ics"`)
+	c.Data(200, "text/calendar; charset=utf-8", data)
+}
+
+// GetCalendarFeed 日历订阅源（无需登录，凭令牌访问当前学期课程表）
+// @Summary 日历订阅源
+// @Tags Course
+// @Produce text/calendar
+// @Param token path string true "订阅令牌（可带 .ics 后缀）"
+// @Success 200 {file} file
+// @Router /calendar/{token} [get]
+func (h *Handler) GetCalendarFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	data, err := h.service.ExportCalendarByToken(c.Request.Context(), token)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.ErrorWithAppError(c, appErr)
+		} else {
+			common.Error(c, common.CodeInternalError, "获取日历失败")
+		}
+		return
+	}
+
+	c.Data(200, "text/calendar; charset=utf-8", data)
+}
+
+// GetCalendarSubscription 获取日历订阅地址
+// @Summary 获取日历订阅地址
+// @Tags Course
+// @Produce json
+// @Success 200 {object} CalendarSubscription
+// @Router /courses/calendar-token [get]
+func (h *Handler) GetCalendarSubscription(c *gin.Context) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		common.Error(c, common.CodeUnauthorized, "未授权")
+		return
+	}
+
+	if h.calendarBaseURL == "" {
+		common.Error(c, common.CodeInternalError, "日历订阅暂未开放")
+		return
+	}
+
+	token, err := h.service.GetCalendarToken(c.Request.Context(), uid.(int))
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.ErrorWithAppError(c, appErr)
+		} else {
+			common.Error(c, common.CodeInternalError, "获取订阅地址失败")
+		}
+		return
+	}
+
+	common.Success(c, newCalendarSubscription(h.calendarBaseURL, token))
+}
+
+// ResetCalendarToken 生成/重置日历订阅地址
+// @Summary 生成/重置日历订阅地址
+// @Tags Course
+// @Produce json
+// @Success 200 {object} CalendarSubscription
+// @Router /courses/calendar-token [post]
+func (h *Handler) ResetCalendarToken(c *gin.Context) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		common.Error(c, common.CodeUnauthorized, "未授权")
+		return
+	}
+
+	if h.calendarBaseURL == "" {
+		common.Error(c, common.CodeInternalError, "日历订阅暂未开放")
+		return
+	}
+
+	token, err := h.service.ResetCalendarToken(c.Request.Context(), uid.(int))
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.ErrorWithAppError(c, appErr)
+		} else {
+			common.Error(c, common.CodeInternalError, "生成订阅地址失败")
+		}
+		return
+	}
+
+	common.Success(c, newCalendarSubscription(h.calendarBaseURL, token))
+}
+
+// RevokeCalendarToken 取消日历订阅
+// @Summary 取消日历订阅
+// @Tags Course
+// @Produce json
+// @Success 200 {object} gin.H
+// @Router /courses/calendar-token [delete]
+func (h *Handler) RevokeCalendarToken(c *gin.Context) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		common.Error(c, common.CodeUnauthorized, "未授权")
+		return
+	}
+
+	if err := h.service.RevokeCalendarToken(c.Request.Context(), uid.(int)); err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.ErrorWithAppError(c, appErr)
+		} else {
+			common.Error(c, common.CodeInternalError, "取消订阅失败")
+		}
+		return
+	}
+
+	common.Success(c, gin.H{"message": "已取消订阅"})
+}
+
+// newCalendarSubscription 根据配置的公开访问地址生成订阅地址
+// 不使用请求的 Host：反向代理后 Host 可能是内网地址，且 Host 可被客户端伪造
+func newCalendarSubscription(baseURL string, token *CalendarToken) CalendarSubscription {
+	httpURL := fmt.Sprintf("%s/api/calendar/%s.ics", baseURL, token.Token)
+	return CalendarSubscription{
+		Token:     token.Token,
+		WebcalURL: "webcal://" + httpURL[strings.Index(httpURL, "://")+3:],
+		HTTPURL:   httpURL,
+		CreatedAt: token.CreatedAt,
+	}
+}
+
+// parseCalendarBaseURL 校验日历订阅的公开访问地址，去掉末尾的 /；为空时返回空字符串
+func parseCalendarBaseURL(raw string) (string, error) {
+	raw = strings.TrimRight(strings.TrimSpace(raw), "/")
+	if raw == "" {
+		return "", nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("日历订阅地址格式错误（需要 http:// 或 https:// 开头的地址）: %s", raw)
+	}
+	return raw, nil
+}
@@ -0,0 +1,168 @@
+package course
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"spider-go/internal/shared"
+	"spider-go/pkg/ical"
+	"strings"
+	"time"
+)
+
+// chinaTZ 课程时间统一按北京时间计算（不依赖系统时区数据）
+var chinaTZ = ical.Location
+
+// defaultPeriods 默认节次作息
+var defaultPeriods = []string{
+	"08:00-08:45", "08:55-09:40", "10:00-10:45", "10:55-11:40",
+	"14:00-14:45", "14:55-15:40", "16:00-16:45", "16:55-17:40",
+	"19:00-19:45", "19:55-20:40", "20:50-21:35", "21:45-22:30",
+}
+
+// PeriodTime 节次的上下课时间（距当天零点的偏移）
+type PeriodTime struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// ParsePeriods 解析节次作息配置，第 i 项为第 i+1 节；为空时使用默认作息
+func ParsePeriods(periods []string) ([]PeriodTime, error) {
+	if len(periods) == 0 {
+		periods = defaultPeriods
+	}
+
+	res := make([]PeriodTime, 0, len(periods))
+	for i, p := range periods {
+		se := strings.SplitN(p, "-", 2)
+		if len(se) != 2 {
+			return nil, fmt.Errorf("第 %d 节作息格式错误: %s", i+1, p)
+		}
+		start, err := parseClock(se[0])
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 节作息格式错误: %s", i+1, p)
+		}
+		end, err := parseClock(se[1])
+		if err != nil || end <= start {
+			return nil, fmt.Errorf("第 %d 节作息格式错误: %s", i+1, p)
+		}
+		res = append(res, PeriodTime{Start: start, End: end})
+	}
+	return res, nil
+}
+
+// parseClock 解析 "HH:MM"
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// calendarOptions 生成日历的参数
+type calendarOptions struct {
+	Term       string       // 学期
+	Week1      time.Time    // 第一周周一（北京时间零点）
+	TotalWeeks int          // 学期总周数（周次为空的课程按整学期处理）
+	Periods    []PeriodTime // 节次作息
+	Now        time.Time    // DTSTAMP
+}
+
+// buildCalendar 根据整学期课程生成 iCalendar 文本
+// 每门课（同一时间段）生成一个每周重复的事件：RRULE 覆盖首周到末周，中间不上课的周用 EXDATE 排除
+func buildCalendar(courses []Course, opts calendarOptions) []byte {
+	cal := ical.NewCalendar("-//spider-go//course table//CN", "课程表 "+opts.Term)
+	w := cal.Line
+
+	stamp := ical.FormatUTC(opts.Now)
+	seen := make(map[string]struct{})
+
+	for _, c := range courses {
+		if c.StartPeriod < 1 || c.EndPeriod > len(opts.Periods) || c.StartPeriod > c.EndPeriod {
+			continue
+		}
+		if c.Weekday < 1 || c.Weekday > 7 {
+			continue
+		}
+
+		weeks := shared.ParseWeeks(c.Weeks)
+		if len(weeks) == 0 {
+			for i := 1; i <= opts.TotalWeeks; i++ {
+				weeks = append(weeks, i)
+			}
+		}
+		if len(weeks) == 0 {
+			continue
+		}
+
+		uid := eventUID(opts.Term, c)
+		if _, ok := seen[uid]; ok {
+			continue
+		}
+		seen[uid] = struct{}{}
+
+		first, last := weeks[0], weeks[len(weeks)-1]
+		day := func(week int) time.Time {
+			return opts.Week1.AddDate(0, 0, (week-1)*7+c.Weekday-1)
+		}
+		startOffset := opts.Periods[c.StartPeriod-1].Start
+		endOffset := opts.Periods[c.EndPeriod-1].End
+
+		w("BEGIN:VEVENT")
+		w("UID:" + uid)
+		w("DTSTAMP:" + stamp)
+		w("DTSTART;TZID=" + ical.TZID + ":" + ical.FormatLocal(day(first).Add(startOffset)))
+		w("DTEND;TZID=" + ical.TZID + ":" + ical.FormatLocal(day(first).Add(endOffset)))
+		if last > first {
+			w(fmt.Sprintf("RRULE:FREQ=WEEKLY;COUNT=%d", last-first+1))
+
+			inWeeks := make(map[int]struct{}, len(weeks))
+			for _, wk := range weeks {
+				inWeeks[wk] = struct{}{}
+			}
+			var exdates []string
+			for wk := first + 1; wk < last; wk++ {
+				if _, ok := inWeeks[wk]; !ok {
+					exdates = append(exdates, ical.FormatLocal(day(wk).Add(startOffset)))
+				}
+			}
+			if len(exdates) > 0 {
+				w("EXDATE;TZID=" + ical.TZID + ":" + strings.Join(exdates, ","))
+			}
+		}
+		w("SUMMARY:" + ical.EscapeText(c.Name))
+		if c.Classroom != "" {
+			w("LOCATION:" + ical.EscapeText(c.Classroom))
+		}
+
+		desc := fmt.Sprintf("第%d-%d节", c.StartPeriod, c.EndPeriod)
+		if c.Teacher != "" {
+			desc += "\n教师：" + c.Teacher
+		}
+		if c.Weeks != "" {
+			desc += "\n周次：" + c.Weeks
+		}
+		w("DESCRIPTION:" + ical.EscapeText(desc))
+		w("END:VEVENT")
+	}
+
+	return cal.Bytes()
+}
+
+// weekOneMonday 根据开学日期求第一周周一
+func weekOneMonday(startDate string) (time.Time, error) {
+	t, err := time.ParseInLocation("2006-01-02", startDate, chinaTZ)
+	if err != nil {
+		return time.Time{}, err
+	}
+	offset := (int(t.Weekday()) + 6) % 7 // 周一为 0
+	return t.AddDate(0, 0, -offset), nil
+}
+
+// eventUID 生成稳定的事件 UID（同一门课重复导出时不变，客户端可正确更新）
+func eventUID(term string, c Course) string {
+	key := fmt.Sprintf("%s|%s|%s|%s|%d|%d|%d|%s", term, c.Name, c.Teacher, c.Classroom, c.Weekday, c.StartPeriod, c.EndPeriod, c.Weeks)
+	sum := sha1.Sum([]byte(key))
+	return hex.EncodeToString(sum[:]) + "@spider-go"
+}
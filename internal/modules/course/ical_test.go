@@ -0,0 +1,88 @@
+package course
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildCalendar(t *testing.T) {
+	periods, err := ParsePeriods(nil)
+	if err != nil {
+		t.Fatalf("ParsePeriods: %v", err)
+	}
+	week1, err := weekOneMonday("2024-09-04") // 周三开学，第一周周一为 9 月 2 日
+	if err != nil {
+		t.Fatalf("weekOneMonday: %v", err)
+	}
+
+	courses := []Course{
+		{Name: "高等数学", Teacher: "张三", Classroom: "理科楼101", Weekday: 2, StartPeriod: 1, EndPeriod: 2, Weeks: "1-4,6(周)"},
+		{Name: "体育", Weekday: 5, StartPeriod: 5, EndPeriod: 6},
+		{Name: "高等数学", Teacher: "张三", Classroom: "理科楼101", Weekday: 2, StartPeriod: 1, EndPeriod: 2, Weeks: "1-4,6(周)"}, // 重复行
+		{Name: "节次越界", Weekday: 1, StartPeriod: 13, EndPeriod: 14},
+		{Name: "无效星期", Weekday: 0, StartPeriod: 1, EndPeriod: 2},
+	}
+	text := string(buildCalendar(courses, calendarOptions{
+		Term:       "2024-2025-1",
+		Week1:      week1,
+		TotalWeeks: 16,
+		Periods:    periods,
+		Now:        time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC),
+	}))
+
+	if got := strings.Count(text, "BEGIN:VEVENT"); got != 2 {
+		t.Fatalf("got %d events, want 2 (duplicate and invalid courses skipped)\n%s", got, text)
+	}
+	for _, want := range []string{
+		"X-WR-CALNAME:课程表 2024-2025-1\r\n",
+		// 周二第 1-2 节，第 1-6 周重复，第 5 周不上课
+		"DTSTART;TZID=Asia/Shanghai:20240903T080000\r\n",
+		"DTEND;TZID=Asia/Shanghai:20240903T094000\r\n",
+		"RRULE:FREQ=WEEKLY;COUNT=6\r\n",
+		"EXDATE;TZID=Asia/Shanghai:20241001T080000\r\n",
+		"LOCATION:理科楼101\r\n",
+		`DESCRIPTION:第1-2节\n教师：张三\n周次：1-4\,6(周)` + "\r\n",
+		// 周次为空按整学期处理
+		"DTSTART;TZID=Asia/Shanghai:20240906T140000\r\n",
+		"RRULE:FREQ=WEEKLY;COUNT=16\r\n",
+		"DTSTAMP:20240901T000000Z\r\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("calendar missing %q", want)
+		}
+	}
+}
+
+func TestCalendarSubscriptionURLs(t *testing.T) {
+	token := &CalendarToken{Token: "abc123"}
+
+	tests := []struct {
+		base       string
+		wantHTTP   string
+		wantWebcal string
+	}{
+		{base: "https://api.example.com", wantHTTP: "https://api.example.com/api/calendar/abc123.ics", wantWebcal: "webcal://api.example.com/api/calendar/abc123.ics"},
+		{base: " https://example.com/spider/ ", wantHTTP: "https://example.com/spider/api/calendar/abc123.ics", wantWebcal: "webcal://example.com/spider/api/calendar/abc123.ics"},
+		{base: "http://localhost:8080", wantHTTP: "http://localhost:8080/api/calendar/abc123.ics", wantWebcal: "webcal://localhost:8080/api/calendar/abc123.ics"},
+	}
+	for _, tt := range tests {
+		base, err := parseCalendarBaseURL(tt.base)
+		if err != nil {
+			t.Fatalf("parseCalendarBaseURL(%q): %v", tt.base, err)
+		}
+		sub := newCalendarSubscription(base, token)
+		if sub.HTTPURL != tt.wantHTTP || sub.WebcalURL != tt.wantWebcal {
+			t.Errorf("base %q: got %q, %q", tt.base, sub.HTTPURL, sub.WebcalURL)
+		}
+	}
+
+	if base, err := parseCalendarBaseURL(""); err != nil || base != "" {
+		t.Errorf("empty base = %q, %v", base, err)
+	}
+	for _, bad := range []string{"api.example.com", "ftp://example.com", "https://", "https://example.com/?a=1"} {
+		if _, err := parseCalendarBaseURL(bad); err == nil {
+			t.Errorf("parseCalendarBaseURL(%q) accepted", bad)
+		}
+	}
+}
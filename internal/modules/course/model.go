@@ -1,5 +1,7 @@
 package course
 
+import "time"
+
 // Course 课程信息
 type Course struct {
 	Name        string `json:"name"`         // 课程名称
@@ -8,6 +10,7 @@ type Course struct {
 	Weekday     int    `json:"weekday"`      // 周几：1~7
 	StartPeriod int    `json:"start_period"` // 开始节次
 	EndPeriod   int    `json:"end_period"`   // 结束节次
+	Weeks       string `json:"weeks"`        // 上课周次原文，如 "1-16(周)"
 }
 
 // DaySchedule 一天的课程安排
@@ -24,8 +27,73 @@ type WeekSchedule struct {
 	Days      []DaySchedule `json:"days"`      // 7天的课程安排
 }
 
+// TermSchedule 整学期课程表
+type TermSchedule struct {
+	Term      string    `json:"term"`       // 学期
+	Courses   []Course  `json:"courses"`    // 所有课程（含周次原文）
+	FetchedAt time.Time `json:"fetched_at"` // 抓取时间
+	Stale     bool      `json:"stale"`      // 是否为教务系统不可用时返回的历史数据
+}
+
+// Week 推算指定周的课程安排
+func (t *TermSchedule) Week(weekNo int) *WeekSchedule {
+	days := make([]DaySchedule, 7)
+	for i := 0; i < 7; i++ {
+		days[i] = DaySchedule{
+			Weekday: i + 1,
+			Courses: nil,
+		}
+	}
+
+	for _, c := range t.Courses {
+		if c.Weekday < 1 || c.Weekday > 7 {
+			continue
+		}
+		if !weekInWeeks(weekNo, c.Weeks) {
+			continue
+		}
+		days[c.Weekday-1].Courses = append(days[c.Weekday-1].Courses, c)
+	}
+
+	return &WeekSchedule{
+		WeekNo: weekNo,
+		Days:   days,
+	}
+}
+
+// GetTermCourseTableRequest 获取整学期课程表请求
+type GetTermCourseTableRequest struct {
+	Term string `form:"term" binding:"required"` // 学期：2024-2025-1
+}
+
 // GetCourseTableRequest 获取课程表请求
 type GetCourseTableRequest struct {
 	Week int    `form:"week" binding:"required,min=1,max=20"` // 周次：1-20
 	Term string `form:"term" binding:"required"`              // 学期：2024-2025-1
 }
+
+// ExportCalendarRequest 导出日历请求
+type ExportCalendarRequest struct {
+	Term string `form:"term"` // 学期（可选，默认当前学期）
+}
+
+// CalendarToken 日历订阅令牌
+type CalendarToken struct {
+	Uid       int       `gorm:"primaryKey;autoIncrement:false" json:"-"`
+	Token     string    `gorm:"type:varchar(64);uniqueIndex;not null" json:"token"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (CalendarToken) TableName() string {
+	return "calendar_tokens"
+}
+
+// CalendarSubscription 日历订阅信息
+type CalendarSubscription struct {
+	Token     string    `json:"token"`      // 订阅令牌
+	WebcalURL string    `json:"webcal_url"` // webcal:// 订阅地址
+	HTTPURL   string    `json:"http_url"`   // https:// 订阅地址（部分客户端需要）
+	CreatedAt time.Time `json:"created_at"` // 创建时间
+}
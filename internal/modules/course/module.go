@@ -1,11 +1,13 @@
 package course
 
 import (
+	"log"
 	"spider-go/internal/cache"
 	"spider-go/internal/service"
 	"spider-go/internal/shared"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // Module 课程模块
@@ -16,24 +18,41 @@ type Module struct {
 
 // NewModule 创建课程模块
 func NewModule(
+	db *gorm.DB,
 	userQuery shared.UserQuery,
-	sessionService service.SessionService,
 	crawlerService service.CrawlerService,
+	parseMonitor service.ParseMonitor,
 	userDataCache cache.UserDataCache,
+	configCache cache.ConfigCache,
 	courseURL string,
-) *Module {
-	svc := NewService(userQuery, sessionService, crawlerService, userDataCache, courseURL)
-	handler := NewHandler(svc)
+	periods []string,
+	calendarBaseURL string,
+) (*Module, error) {
+	periodTimes, err := ParsePeriods(periods)
+	if err != nil {
+		return nil, err
+	}
+	calendarBaseURL, err = parseCalendarBaseURL(calendarBaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if calendarBaseURL == "" {
+		log.Println("警告: 日历订阅地址未配置（course.calendar_base_url），日历订阅功能不可用")
+	}
+
+	repo := NewRepository(db)
+	svc := NewService(repo, userQuery, crawlerService, parseMonitor, userDataCache, configCache, courseURL, periodTimes)
+	handler := NewHandler(svc, calendarBaseURL)
 
 	return &Module{
 		handler: handler,
 		service: svc,
-	}
+	}, nil
 }
 
-// RegisterRoutes 注册路由
-func (m *Module) RegisterRoutes(r *gin.RouterGroup) {
-	m.handler.RegisterRoutes(r)
+// RegisterRoutes 注册路由（公开路由 + 需要认证的路由）
+func (m *Module) RegisterRoutes(public *gin.RouterGroup, authenticated *gin.RouterGroup) {
+	m.handler.RegisterRoutes(public, authenticated)
 }
 
 // GetService 获取服务实例（用于跨模块调用）
@@ -0,0 +1,68 @@
+package course
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrCalendarTokenNotFound = errors.New("calendar token not found")
+)
+
+// Repository 日历订阅令牌数据访问接口
+type Repository interface {
+	SaveToken(ctx context.Context, token *CalendarToken) error
+	FindTokenByUid(ctx context.Context, uid int) (*CalendarToken, error)
+	FindByToken(ctx context.Context, token string) (*CalendarToken, error)
+	DeleteToken(ctx context.Context, uid int) error
+}
+
+// repository 日历订阅令牌数据访问实现
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository 创建日历订阅令牌数据访问层
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// SaveToken 保存令牌（已存在则替换）
+func (r *repository) SaveToken(ctx context.Context, token *CalendarToken) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "uid"}},
+		DoUpdates: clause.AssignmentColumns([]string{"token", "created_at", "updated_at"}),
+	}).Create(token).Error
+}
+
+// FindTokenByUid 根据用户ID查找令牌
+func (r *repository) FindTokenByUid(ctx context.Context, uid int) (*CalendarToken, error) {
+	var token CalendarToken
+	if err := r.db.WithContext(ctx).First(&token, uid).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCalendarTokenNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// FindByToken 根据令牌查找
+func (r *repository) FindByToken(ctx context.Context, token string) (*CalendarToken, error) {
+	var t CalendarToken
+	if err := r.db.WithContext(ctx).Where("token = ?", token).First(&t).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCalendarTokenNotFound
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// DeleteToken 删除令牌
+func (r *repository) DeleteToken(ctx context.Context, uid int) error {
+	return r.db.WithContext(ctx).Delete(&CalendarToken{}, uid).Error
+}
@@ -1,9 +1,12 @@
 package course
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"io"
-	"net/http"
 	"net/url"
 	"regexp"
 	"spider-go/internal/cache"
@@ -20,35 +23,61 @@ import (
 // Service 课程服务接口
 type Service interface {
 	GetCourseTableByWeek(ctx context.Context, uid int, week int, term string) (*WeekSchedule, error)
+	// GetTermCourseTable 获取整学期课程表（一次抓取，各周课表由此推算）
+	GetTermCourseTable(ctx context.Context, uid int, term string) (*TermSchedule, error)
+	// Prewarm 重新抓取当前学期课程表并写入缓存（供数据预热任务使用）
+	Prewarm(ctx context.Context, uid int) error
+
+	// ExportCalendar 导出整学期课程表为 iCalendar（term 为空时使用当前学期）
+	ExportCalendar(ctx context.Context, uid int, term string) ([]byte, error)
+	// ExportCalendarByToken 通过订阅令牌导出当前学期课程表（无需登录）
+	ExportCalendarByToken(ctx context.Context, token string) ([]byte, error)
+	// GetCalendarToken 获取日历订阅令牌
+	GetCalendarToken(ctx context.Context, uid int) (*CalendarToken, error)
+	// ResetCalendarToken 生成（或重置）日历订阅令牌，旧的订阅地址随之失效
+	ResetCalendarToken(ctx context.Context, uid int) (*CalendarToken, error)
+	// RevokeCalendarToken 取消日历订阅
+	RevokeCalendarToken(ctx context.Context, uid int) error
+	// OnJwcUnbind 解除教务绑定时吊销日历订阅令牌
+	OnJwcUnbind(ctx context.Context, uid int) error
 }
 
 // courseService 课程服务实现
 type courseService struct {
+	repo           Repository
 	userQuery      shared.UserQuery
-	sessionService service.SessionService
 	crawlerService service.CrawlerService
+	parseMonitor   service.ParseMonitor
 	userDataCache  cache.UserDataCache
+	configCache    cache.ConfigCache
 	courseURL      string
+	periods        []PeriodTime
 }
 
 // NewService 创建课程服务
 func NewService(
+	repo Repository,
 	userQuery shared.UserQuery,
-	sessionService service.SessionService,
 	crawlerService service.CrawlerService,
+	parseMonitor service.ParseMonitor,
 	userDataCache cache.UserDataCache,
+	configCache cache.ConfigCache,
 	courseURL string,
+	periods []PeriodTime,
 ) Service {
 	return &courseService{
+		repo:           repo,
 		userQuery:      userQuery,
-		sessionService: sessionService,
 		crawlerService: crawlerService,
+		parseMonitor:   parseMonitor,
 		userDataCache:  userDataCache,
+		configCache:    configCache,
 		courseURL:      courseURL,
+		periods:        periods,
 	}
 }
 
-// GetCourseTableByWeek 获取指定周的课程表
+// GetCourseTableByWeek 获取指定周的课程表（由整学期课表本地推算）
 func (s *courseService) GetCourseTableByWeek(ctx context.Context, uid int, week int, term string) (*WeekSchedule, error) {
 	// 校验参数
 	if week > 20 || week < 1 {
@@ -59,6 +88,21 @@ func (s *courseService) GetCourseTableByWeek(ctx context.Context, uid int, week
 		return nil, common.NewAppError(common.CodeJwcInvalidParams, "学期不能为空")
 	}
 
+	termSchedule, err := s.GetTermCourseTable(ctx, uid, term)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := termSchedule.Week(week)
+
+	// 填充本周起止日期（学期日期未配置时留空）
+	s.fillWeekDates(ctx, term, schedule)
+
+	return schedule, nil
+}
+
+// GetTermCourseTable 获取整学期课程表（一次抓取，按学期缓存）
+func (s *courseService) GetTermCourseTable(ctx context.Context, uid int, term string) (*TermSchedule, error) {
 	re := regexp.MustCompile(`^\d{4}-\d{4}-[12]$`)
 	if !re.MatchString(term) {
 		return nil, common.NewAppError(common.CodeJwcInvalidParams, "学期格式错误")
@@ -75,96 +119,235 @@ func (s *courseService) GetCourseTableByWeek(ctx context.Context, uid int, week
 	}
 
 	// 先查询缓存
-	var cachedSchedule WeekSchedule
-	if err := s.userDataCache.GetCourseTable(ctx, uid, term, week, &cachedSchedule); err == nil {
-		return &cachedSchedule, nil
+	var cached TermSchedule
+	if err := s.userDataCache.GetTermCourseTable(ctx, uid, term, &cached); err == nil {
+		return &cached, nil
+	}
+
+	schedule, err := s.refreshTermCourseTable(ctx, uid, user.Sid, user.Spwd, term)
+	if err != nil {
+		// 教务系统熔断中，返回历史课表
+		if appErr, ok := err.(*common.AppError); ok && appErr.Code == common.CodeJwcUnavailable {
+			if err := s.userDataCache.GetStaleTermCourseTable(ctx, uid, term, &cached); err == nil {
+				cached.Stale = true
+				return &cached, nil
+			}
+		}
+		return nil, err
 	}
 
-	// 获取或创建会话
-	cookies, err := s.getCookiesOrLogin(ctx, uid, user.Sid, user.Spwd)
+	return schedule, nil
+}
+
+// Prewarm 重新抓取当前学期的课程表并写入缓存（忽略已有缓存）
+func (s *courseService) Prewarm(ctx context.Context, uid int) error {
+	term, err := s.configCache.GetCurrentTerm(ctx)
+	if err != nil || term == "" {
+		return common.NewAppError(common.CodeInternalError, "未设置当前学期")
+	}
+
+	user, err := s.userQuery.GetUserByUid(ctx, uid)
 	if err != nil {
-		return nil, common.NewAppError(common.CodeJwcLoginFailed, "获取cookie失败")
+		return common.NewAppError(common.CodeUserNotFound, "用户不存在")
 	}
 
-	// 构造请求
+	if user.Sid == "" || user.Spwd == "" {
+		return common.NewAppError(common.CodeJwcNotBound, "")
+	}
+
+	_, err = s.refreshTermCourseTable(ctx, uid, user.Sid, user.Spwd, term)
+	return err
+}
+
+// refreshTermCourseTable 从教务系统抓取整学期课程表并写入缓存
+func (s *courseService) refreshTermCourseTable(ctx context.Context, uid int, sid, spwd, term string) (*TermSchedule, error) {
+	// 构造请求（周次留空即返回整学期课表）
 	form := url.Values{}
-	form.Add("zc", strconv.Itoa(week))
+	form.Add("zc", "")
 	form.Add("xnxq01id", term)
 
-	// 发起请求
-	body, err := s.crawlerService.FetchWithCookies(ctx, "POST", s.courseURL, cookies, form)
+	// 发起请求（会话过期时自动重新登录）
+	account := &service.JwcAccount{Uid: uid, Username: sid, Password: spwd}
+	body, err := s.crawlerService.FetchWithSession(ctx, account, "POST", s.courseURL, form)
 	if err != nil {
 		return nil, err
 	}
 	defer body.Close()
 
-	// 解析响应
-	schedule, err := s.parseCourseTableFromHTML(body, week)
+	page, err := io.ReadAll(body)
+	if err != nil {
+		return nil, common.NewAppError(common.CodeJwcRequestFailed, "读取课表页面失败")
+	}
+
+	// 解析响应（记录解析结果，页面结构变化时告警）
+	courses, err := s.parseCoursesFromHTML(bytes.NewReader(page))
+	s.parseMonitor.Record(ctx, service.PageCourseTable, sid, page, err)
 	if err != nil {
 		return nil, err
 	}
 
-	// 写入缓存（1小时过期）
-	_ = s.userDataCache.CacheCourseTable(ctx, uid, term, week, schedule, time.Hour)
+	schedule := &TermSchedule{
+		Term:      term,
+		Courses:   courses,
+		FetchedAt: time.Now(),
+	}
+
+	// 写入缓存（课表学期内很少变动，缓存6小时）
+	_ = s.userDataCache.CacheTermCourseTable(ctx, uid, term, schedule, 6*time.Hour)
 
 	return schedule, nil
 }
 
-// getCookiesOrLogin 获取缓存的 cookies 或登录
-func (s *courseService) getCookiesOrLogin(ctx context.Context, uid int, sid, spwd string) ([]*http.Cookie, error) {
-	cookies, err := s.sessionService.GetCachedCookies(ctx, uid)
-	if err != nil {
-		return nil, common.NewAppError(common.CodeCacheError, "缓存错误")
+// ExportCalendar 导出整学期课程表为 iCalendar
+func (s *courseService) ExportCalendar(ctx context.Context, uid int, term string) ([]byte, error) {
+	if term == "" {
+		current, err := s.configCache.GetCurrentTerm(ctx)
+		if err != nil {
+			return nil, common.NewAppError(common.CodeInternalError, err.Error())
+		}
+		term = current
+	}
+
+	re := regexp.MustCompile(`^\d{4}-\d{4}-[12]$`)
+	if !re.MatchString(term) {
+		return nil, common.NewAppError(common.CodeJwcInvalidParams, "学期格式错误")
 	}
 
-	if len(cookies) > 0 {
-		return cookies, nil
+	// 以开学日期所在周的周一作为第一周
+	startDate, endDate, err := s.configCache.GetSemesterDates(ctx, term)
+	if err != nil {
+		return nil, common.NewAppError(common.CodeNotFound, err.Error())
+	}
+	week1, err := weekOneMonday(startDate)
+	if err != nil {
+		return nil, common.NewAppError(common.CodeInternalError, "开学日期格式错误")
+	}
+	totalWeeks := 20
+	if end, err := time.ParseInLocation("2006-01-02", endDate, chinaTZ); err == nil {
+		if weeks := int(end.Sub(week1).Hours()/24)/7 + 1; weeks > 0 && weeks <= 30 {
+			totalWeeks = weeks
+		}
 	}
 
-	if err := s.sessionService.LoginAndCache(ctx, uid, sid, spwd); err != nil {
+	schedule, err := s.GetTermCourseTable(ctx, uid, term)
+	if err != nil {
 		return nil, err
 	}
 
-	cookies, err = s.sessionService.GetCachedCookies(ctx, uid)
-	if err != nil || len(cookies) == 0 {
-		return nil, common.NewAppError(common.CodeJwcLoginFailed, "获取会话失败")
+	return buildCalendar(schedule.Courses, calendarOptions{
+		Term:       term,
+		Week1:      week1,
+		TotalWeeks: totalWeeks,
+		Periods:    s.periods,
+		Now:        time.Now(),
+	}), nil
+}
+
+// ExportCalendarByToken 通过订阅令牌导出当前学期课程表
+func (s *courseService) ExportCalendarByToken(ctx context.Context, token string) ([]byte, error) {
+	t, err := s.repo.FindByToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, ErrCalendarTokenNotFound) {
+			return nil, common.NewAppError(common.CodeNotFound, "订阅地址无效或已失效")
+		}
+		return nil, common.NewAppError(common.CodeInternalError, "查询订阅失败")
+	}
+
+	return s.ExportCalendar(ctx, t.Uid, "")
+}
+
+// GetCalendarToken 获取日历订阅令牌
+func (s *courseService) GetCalendarToken(ctx context.Context, uid int) (*CalendarToken, error) {
+	t, err := s.repo.FindTokenByUid(ctx, uid)
+	if err != nil {
+		if errors.Is(err, ErrCalendarTokenNotFound) {
+			return nil, common.NewAppError(common.CodeNotFound, "尚未开启日历订阅")
+		}
+		return nil, common.NewAppError(common.CodeInternalError, "查询订阅失败")
+	}
+	return t, nil
+}
+
+// ResetCalendarToken 生成（或重置）日历订阅令牌
+func (s *courseService) ResetCalendarToken(ctx context.Context, uid int) (*CalendarToken, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, common.NewAppError(common.CodeInternalError, "生成订阅令牌失败")
+	}
+
+	now := time.Now()
+	t := &CalendarToken{
+		Uid:       uid,
+		Token:     hex.EncodeToString(buf),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.repo.SaveToken(ctx, t); err != nil {
+		return nil, common.NewAppError(common.CodeInternalError, "保存订阅令牌失败")
+	}
+	return t, nil
+}
+
+// RevokeCalendarToken 取消日历订阅
+func (s *courseService) RevokeCalendarToken(ctx context.Context, uid int) error {
+	if err := s.repo.DeleteToken(ctx, uid); err != nil {
+		return common.NewAppError(common.CodeInternalError, "取消订阅失败")
+	}
+	return nil
+}
+
+// OnJwcUnbind 解除教务绑定时吊销日历订阅令牌（旧地址立即失效）
+func (s *courseService) OnJwcUnbind(ctx context.Context, uid int) error {
+	return s.repo.DeleteToken(ctx, uid)
+}
+
+// fillWeekDates 根据学期开学日期填充周起止日期
+func (s *courseService) fillWeekDates(ctx context.Context, term string, schedule *WeekSchedule) {
+	startDate, _, err := s.configCache.GetSemesterDates(ctx, term)
+	if err != nil {
+		return
+	}
+	week1, err := weekOneMonday(startDate)
+	if err != nil {
+		return
 	}
 
-	return cookies, nil
+	monday := week1.AddDate(0, 0, (schedule.WeekNo-1)*7)
+	schedule.Starttime = monday.Format("2006-01-02")
+	schedule.Endtime = monday.AddDate(0, 0, 6).Format("2006-01-02")
 }
 
-// parseCourseTableFromHTML 解析课程表 HTML
-func (s *courseService) parseCourseTableFromHTML(r io.Reader, requestWeek int) (*WeekSchedule, error) {
+// weekdayHeaders 课表表头（周一到周五必须存在，周末列可省略）
+var weekdayHeaders = []string{"星期一", "星期二", "星期三", "星期四", "星期五", "星期六", "星期日"}
+
+// parseCoursesFromHTML 解析整学期课程表 HTML（保留每门课的周次原文，不按周过滤）
+func (s *courseService) parseCoursesFromHTML(r io.Reader) ([]Course, error) {
 	doc, err := goquery.NewDocumentFromReader(r)
 	if err != nil {
 		return nil, common.NewAppError(common.CodeJwcParseFailed, "解析HTML失败")
 	}
 
-	title := strings.TrimSpace(doc.Find("title").Text())
-	if title != "学期理论课表" {
-		return nil, common.NewAppError(common.CodeJwcParseFailed, "页面错误")
+	table := doc.Find("#kbtable")
+	if table.Length() == 0 {
+		return nil, common.NewAppError(common.CodeJwcParseFailed, "未找到课表数据")
 	}
 
-	// 解析当前周次
-	weekNo := requestWeek
-	if opt := doc.Find("select#zc option[selected]"); opt.Length() > 0 {
-		val, _ := opt.Attr("value")
-		if v, err := strconv.Atoi(strings.TrimSpace(val)); err == nil {
-			weekNo = v
-		}
+	// 按表头确定每列对应星期几（第一列为节次）
+	cols, err := shared.MapTableColumns(table, weekdayHeaders[:5]...)
+	if err != nil {
+		return nil, common.NewAppError(common.CodeJwcParseFailed, err.Error())
 	}
-
-	// 初始化 7 天
-	days := make([]DaySchedule, 7)
-	for i := 0; i < 7; i++ {
-		days[i] = DaySchedule{
-			Weekday: i + 1,
-			Courses: nil,
+	weekdays := make(map[int]int, len(weekdayHeaders))
+	for i, header := range weekdayHeaders {
+		if col := cols.Index(header); col > 0 {
+			weekdays[col-1] = i + 1
 		}
 	}
 
+	courses := []Course{}
+
 	// 遍历课表行
-	doc.Find("#kbtable tr").Each(func(i int, tr *goquery.Selection) {
+	table.Find("tr").Each(func(i int, tr *goquery.Selection) {
 		if i == 0 {
 			return // 跳过表头
 		}
@@ -179,55 +362,82 @@ func (s *courseService) parseCourseTableFromHTML(r io.Reader, requestWeek int) (
 			return
 		}
 
-		// 遍历一行的 7 列（周一到周日）
+		// 遍历一行的各列（周一到周日）
 		tr.Find("td").Each(func(col int, td *goquery.Selection) {
-			weekday := col + 1
+			weekday, ok := weekdays[col]
+			if !ok {
+				return
+			}
 
-			td.Find("div.kbcontent").Each(func(_ int, div *goquery.Selection) {
-				name := extractCourseName(div)
-				if name == "" || name == "&nbsp;" {
-					return
+			td.Find("div.kbcontent").Each(func(_ int, cell *goquery.Selection) {
+				for _, div := range splitCourseCell(cell) {
+					if c, ok := parseCourseBlock(div, weekday, startP, endP); ok {
+						courses = append(courses, c)
+					}
 				}
+			})
+		})
+	})
 
-				var teacher, classroom, weeksStr string
-				div.Find("font").Each(func(_ int, f *goquery.Selection) {
-					title, _ := f.Attr("title")
-					text := strings.TrimSpace(f.Text())
-					switch {
-					case strings.Contains(title, "老师"):
-						teacher = text
-					case strings.Contains(title, "周次"):
-						weeksStr = text
-					case strings.Contains(title, "教室"):
-						classroom = text
-					}
-				})
+	return courses, nil
+}
 
-				// 按周次过滤
-				if weekNo > 0 && weeksStr != "" && !weekInWeeks(weekNo, weeksStr) {
-					return
-				}
+// splitCourseCell 整学期课表中同一格可能有多门课（不同周次），以 "-----" 分隔
+func splitCourseCell(cell *goquery.Selection) []*goquery.Selection {
+	html, err := cell.Html()
+	if err != nil {
+		return []*goquery.Selection{cell}
+	}
 
-				c := Course{
-					Name:        name,
-					Teacher:     teacher,
-					Classroom:   classroom,
-					Weekday:     weekday,
-					StartPeriod: startP,
-					EndPeriod:   endP,
-				}
+	parts := courseSeparator.Split(html, -1)
+	if len(parts) == 1 {
+		return []*goquery.Selection{cell}
+	}
 
-				days[weekday-1].Courses = append(days[weekday-1].Courses, c)
-			})
-		})
+	blocks := make([]*goquery.Selection, 0, len(parts))
+	for _, part := range parts {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader("<div>" + part + "</div>"))
+		if err != nil {
+			continue
+		}
+		blocks = append(blocks, doc.Find("body > div").First())
+	}
+	return blocks
+}
+
+// courseSeparator 同一格内多门课之间的分隔线
+var courseSeparator = regexp.MustCompile(`-{5,}\s*(<br\s*/?>)?`)
+
+// parseCourseBlock 解析单门课程，名称为空时返回 false
+func parseCourseBlock(div *goquery.Selection, weekday, startP, endP int) (Course, bool) {
+	name := extractCourseName(div)
+	if name == "" || name == "&nbsp;" {
+		return Course{}, false
+	}
+
+	var teacher, classroom, weeksStr string
+	div.Find("font").Each(func(_ int, f *goquery.Selection) {
+		title, _ := f.Attr("title")
+		text := strings.TrimSpace(f.Text())
+		switch {
+		case strings.Contains(title, "老师"):
+			teacher = text
+		case strings.Contains(title, "周次"):
+			weeksStr = text
+		case strings.Contains(title, "教室"):
+			classroom = text
+		}
 	})
 
-	return &WeekSchedule{
-		WeekNo:    weekNo,
-		Starttime: "",
-		Endtime:   "",
-		Days:      days,
-	}, nil
+	return Course{
+		Name:        name,
+		Teacher:     teacher,
+		Classroom:   classroom,
+		Weekday:     weekday,
+		StartPeriod: startP,
+		EndPeriod:   endP,
+		Weeks:       weeksStr,
+	}, true
 }
 
 // parsePeriodRange 解析节次范围
@@ -267,43 +477,12 @@ func extractCourseName(div *goquery.Selection) string {
 
 // weekInWeeks 判断某周是否在周次范围内
 func weekInWeeks(weekNo int, weeksStr string) bool {
-	// 去掉 "(周)" 后缀
-	if idx := strings.Index(weeksStr, "("); idx >= 0 {
-		weeksStr = weeksStr[:idx]
-	}
-	weeksStr = strings.TrimSpace(weeksStr)
-	if weeksStr == "" {
+	if strings.TrimSpace(weeksStr) == "" {
 		return true
 	}
-
-	parts := strings.Split(weeksStr, ",")
-	for _, part := range parts {
-		part = strings.TrimSpace(part)
-		if part == "" {
-			continue
-		}
-
-		if strings.Contains(part, "-") {
-			se := strings.SplitN(part, "-", 2)
-			if len(se) != 2 {
-				continue
-			}
-			start, err1 := strconv.Atoi(strings.TrimSpace(se[0]))
-			end, err2 := strconv.Atoi(strings.TrimSpace(se[1]))
-			if err1 != nil || err2 != nil {
-				continue
-			}
-			if weekNo >= start && weekNo <= end {
-				return true
-			}
-		} else {
-			n, err := strconv.Atoi(part)
-			if err != nil {
-				continue
-			}
-			if weekNo == n {
-				return true
-			}
+	for _, w := range shared.ParseWeeks(weeksStr) {
+		if w == weekNo {
+			return true
 		}
 	}
 	return false
@@ -0,0 +1,45 @@
+package course
+
+import (
+	"bytes"
+	"spider-go/internal/common"
+	"spider-go/internal/service/jwctest"
+	"strings"
+	"testing"
+)
+
+func TestParseCoursesFromHTML(t *testing.T) {
+	s := &courseService{}
+
+	courses, err := s.parseCoursesFromHTML(bytes.NewReader(jwctest.ReadFixture(t, "testdata/xskb_list.html")))
+	if err != nil {
+		t.Fatalf("parseCoursesFromHTML: %v", err)
+	}
+	jwctest.AssertGolden(t, "testdata/xskb_list.golden.json", courses)
+}
+
+func TestParseCoursesFromHTMLRejectsOtherPages(t *testing.T) {
+	s := &courseService{}
+
+	page := `<html><head><title>统一身份认证</title></head><body><input name="execution" value="e1s1"/></body></html>`
+	_, err := s.parseCoursesFromHTML(strings.NewReader(page))
+	if appErr, ok := err.(*common.AppError); !ok || appErr.Code != common.CodeJwcParseFailed {
+		t.Errorf("got %v, want CodeJwcParseFailed", err)
+	}
+}
+
+func TestTermScheduleWeek(t *testing.T) {
+	s := &courseService{}
+
+	courses, err := s.parseCoursesFromHTML(bytes.NewReader(jwctest.ReadFixture(t, "testdata/xskb_list.html")))
+	if err != nil {
+		t.Fatalf("parseCoursesFromHTML: %v", err)
+	}
+
+	schedule := &TermSchedule{Term: "2024-2025-1", Courses: courses}
+	weeks := map[int]*WeekSchedule{}
+	for _, weekNo := range []int{1, 2, 4, 9} {
+		weeks[weekNo] = schedule.Week(weekNo)
+	}
+	jwctest.AssertGolden(t, "testdata/xskb_list_weeks.golden.json", weeks)
+}
@@ -0,0 +1,175 @@
+package electricity
+
+import (
+	"spider-go/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler 电费HTTP处理器
+type Handler struct {
+	service Service
+}
+
+// NewHandler 创建电费处理器
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// RegisterRoutes 注册路由
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	electricity := r.Group("/electricity")
+	{
+		electricity.GET("", h.GetBalance)         // 查询电费
+		electricity.GET("/bind", h.GetBinding)    // 获取宿舍绑定
+		electricity.POST("/bind", h.BindDorm)     // 绑定宿舍
+		electricity.DELETE("/bind", h.UnbindDorm) // 解除宿舍绑定
+		electricity.PUT("/alert", h.SetAlert)     // 设置低电费提醒
+	}
+}
+
+// GetBalance 查询电费余额和最近记录
+// @Summary 查询电费
+// @Tags Electricity
+// @Produce json
+// @Success 200 {object} Balance
+// @Router /electricity [get]
+func (h *Handler) GetBalance(c *gin.Context) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		common.Error(c, common.CodeUnauthorized, "未授权")
+		return
+	}
+
+	balance, err := h.service.GetBalance(c.Request.Context(), uid.(int))
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.ErrorWithAppError(c, appErr)
+		} else {
+			common.Error(c, common.CodeInternalError, "查询电费失败")
+		}
+		return
+	}
+
+	common.Success(c, balance)
+}
+
+// GetBinding 获取宿舍绑定
+// @Summary 获取宿舍绑定
+// @Tags Electricity
+// @Produce json
+// @Success 200 {object} DormBinding
+// @Router /electricity/bind [get]
+func (h *Handler) GetBinding(c *gin.Context) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		common.Error(c, common.CodeUnauthorized, "未授权")
+		return
+	}
+
+	binding, err := h.service.GetBinding(c.Request.Context(), uid.(int))
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.ErrorWithAppError(c, appErr)
+		} else {
+			common.Error(c, common.CodeInternalError, "获取宿舍绑定失败")
+		}
+		return
+	}
+
+	common.Success(c, binding)
+}
+
+// BindDorm 绑定宿舍
+// @Summary 绑定宿舍
+// @Tags Electricity
+// @Accept json
+// @Produce json
+// @Param request body BindDormRequest true "绑定请求"
+// @Success 200 {object} gin.H
+// @Router /electricity/bind [post]
+func (h *Handler) BindDorm(c *gin.Context) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		common.Error(c, common.CodeUnauthorized, "未授权")
+		return
+	}
+
+	var req BindDormRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.Error(c, common.CodeInvalidParams, err.Error())
+		return
+	}
+
+	if err := h.service.BindDorm(c.Request.Context(), uid.(int), req.Building, req.Room); err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.ErrorWithAppError(c, appErr)
+		} else {
+			common.Error(c, common.CodeInternalError, "绑定宿舍失败")
+		}
+		return
+	}
+
+	common.Success(c, gin.H{"message": "绑定成功"})
+}
+
+// UnbindDorm 解除宿舍绑定
+// @Summary 解除宿舍绑定
+// @Tags Electricity
+// @Produce json
+// @Success 200 {object} gin.H
+// @Router /electricity/bind [delete]
+func (h *Handler) UnbindDorm(c *gin.Context) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		common.Error(c, common.CodeUnauthorized, "未授权")
+		return
+	}
+
+	if err := h.service.UnbindDorm(c.Request.Context(), uid.(int)); err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.ErrorWithAppError(c, appErr)
+		} else {
+			common.Error(c, common.CodeInternalError, "解除绑定失败")
+		}
+		return
+	}
+
+	common.Success(c, gin.H{"message": "解除绑定成功"})
+}
+
+// SetAlert 设置低电费提醒
+// @Summary 设置低电费提醒
+// @Tags Electricity
+// @Accept json
+// @Produce json
+// @Param request body AlertSettingRequest true "提醒设置"
+// @Success 200 {object} DormBinding
+// @Router /electricity/alert [put]
+func (h *Handler) SetAlert(c *gin.Context) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		common.Error(c, common.CodeUnauthorized, "未授权")
+		return
+	}
+
+	var req AlertSettingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.Error(c, common.CodeInvalidParams, err.Error())
+		return
+	}
+
+	binding, err := h.service.SetAlert(c.Request.Context(), uid.(int), req.Enabled, req.Threshold)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.ErrorWithAppError(c, appErr)
+		} else {
+			common.Error(c, common.CodeInternalError, "设置提醒失败")
+		}
+		return
+	}
+
+	common.Success(c, binding)
+}
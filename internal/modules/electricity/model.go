@@ -0,0 +1,52 @@
+package electricity
+
+import "time"
+
+// DormBinding 宿舍绑定信息
+type DormBinding struct {
+	Uid       int       `gorm:"primaryKey;autoIncrement:false" json:"uid"`
+	Building  string    `gorm:"type:varchar(64);not null" json:"building"` // 楼栋
+	Room      string    `gorm:"type:varchar(32);not null" json:"room"`     // 房间号
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// 低电费提醒
+	AlertEnabled   bool       `gorm:"not null;default:false;index" json:"alert_enabled"` // 是否开启提醒
+	AlertThreshold float64    `gorm:"not null;default:0" json:"alert_threshold"`         // 提醒阈值（元）
+	AlertedAt      *time.Time `json:"alerted_at"`                                        // 本轮已提醒时间（余额恢复后清空）
+}
+
+// TableName 指定表名
+func (DormBinding) TableName() string {
+	return "dorm_bindings"
+}
+
+// Balance 宿舍电费信息
+type Balance struct {
+	Building     string   `json:"building"`      // 楼栋
+	Room         string   `json:"room"`          // 房间号
+	Balance      float64  `json:"balance"`       // 剩余金额（元）
+	RemainingKwh float64  `json:"remaining_kwh"` // 剩余电量（度）
+	UpdatedAt    string   `json:"updated_at"`    // 电费系统数据更新时间
+	Records      []Record `json:"records"`       // 最近充值/用电记录
+}
+
+// Record 充值/用电记录
+type Record struct {
+	Time   string  `json:"time"`   // 时间
+	Type   string  `json:"type"`   // 类型：充值/用电
+	Amount float64 `json:"amount"` // 金额（元）
+	Kwh    float64 `json:"kwh"`    // 电量（度）
+}
+
+// AlertSettingRequest 低电费提醒设置请求
+type AlertSettingRequest struct {
+	Enabled   bool    `json:"enabled"`                   // 是否开启
+	Threshold float64 `json:"threshold" binding:"gte=0"` // 提醒阈值（元），为 0 时使用默认值
+}
+
+// BindDormRequest 绑定宿舍请求
+type BindDormRequest struct {
+	Building string `json:"building" binding:"required"` // 楼栋
+	Room     string `json:"room" binding:"required"`     // 房间号
+}
@@ -0,0 +1,48 @@
+package electricity
+
+import (
+	"log"
+	"spider-go/internal/cache"
+	"spider-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Module 电费模块
+type Module struct {
+	handler *Handler
+	service Service
+}
+
+// NewModule 创建电费模块
+// 未配置电费查询地址时模块仍可创建（不影响服务启动），查询、绑定接口返回"电费查询暂未开放"
+func NewModule(
+	db *gorm.DB,
+	crawlerService service.CrawlerService,
+	userDataCache cache.UserDataCache,
+	queryURL string,
+) *Module {
+	if queryURL == "" {
+		log.Println("警告: 电费查询地址未配置（electricity.query_url），电费查询功能不可用")
+	}
+
+	repo := NewRepository(db)
+	svc := NewService(repo, crawlerService, userDataCache, queryURL)
+	handler := NewHandler(svc)
+
+	return &Module{
+		handler: handler,
+		service: svc,
+	}
+}
+
+// RegisterRoutes 注册路由
+func (m *Module) RegisterRoutes(r *gin.RouterGroup) {
+	m.handler.RegisterRoutes(r)
+}
+
+// GetService 获取服务实例（用于跨模块调用）
+func (m *Module) GetService() Service {
+	return m.service
+}
@@ -0,0 +1,84 @@
+package electricity
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrDormNotBound = errors.New("dorm not bound")
+)
+
+// Repository 宿舍绑定数据访问接口
+type Repository interface {
+	Save(ctx context.Context, binding *DormBinding) error
+	FindByUid(ctx context.Context, uid int) (*DormBinding, error)
+	Delete(ctx context.Context, uid int) error
+	UpdateAlert(ctx context.Context, uid int, enabled bool, threshold float64) error
+	FindAlertSubscribers(ctx context.Context, afterUid, limit int) ([]DormBinding, error)
+	UpdateAlertedAt(ctx context.Context, uid int, alertedAt *time.Time) error
+}
+
+// repository 宿舍绑定数据访问实现
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository 创建宿舍绑定数据访问层
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Save 保存宿舍绑定（存在则更新）
+func (r *repository) Save(ctx context.Context, binding *DormBinding) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "uid"}},
+		DoUpdates: clause.AssignmentColumns([]string{"building", "room", "alerted_at", "updated_at"}),
+	}).Create(binding).Error
+}
+
+// FindByUid 根据用户ID查找宿舍绑定
+func (r *repository) FindByUid(ctx context.Context, uid int) (*DormBinding, error) {
+	var binding DormBinding
+	if err := r.db.WithContext(ctx).First(&binding, uid).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDormNotBound
+		}
+		return nil, err
+	}
+	return &binding, nil
+}
+
+// Delete 解除宿舍绑定
+func (r *repository) Delete(ctx context.Context, uid int) error {
+	return r.db.WithContext(ctx).Delete(&DormBinding{}, uid).Error
+}
+
+// UpdateAlert 更新低电费提醒设置（同时重置提醒状态）
+func (r *repository) UpdateAlert(ctx context.Context, uid int, enabled bool, threshold float64) error {
+	return r.db.WithContext(ctx).Model(&DormBinding{}).Where("uid = ?", uid).Updates(map[string]interface{}{
+		"alert_enabled":   enabled,
+		"alert_threshold": threshold,
+		"alerted_at":      nil,
+	}).Error
+}
+
+// FindAlertSubscribers 按 uid 分批查询开启了低电费提醒的绑定
+func (r *repository) FindAlertSubscribers(ctx context.Context, afterUid, limit int) ([]DormBinding, error) {
+	var bindings []DormBinding
+	err := r.db.WithContext(ctx).
+		Where("alert_enabled = ? AND uid > ?", true, afterUid).
+		Order("uid ASC").
+		Limit(limit).
+		Find(&bindings).Error
+	return bindings, err
+}
+
+// UpdateAlertedAt 更新提醒时间（nil 表示清空）
+func (r *repository) UpdateAlertedAt(ctx context.Context, uid int, alertedAt *time.Time) error {
+	return r.db.WithContext(ctx).Model(&DormBinding{}).Where("uid = ?", uid).Update("alerted_at", alertedAt).Error
+}
@@ -0,0 +1,296 @@
+package electricity
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"regexp"
+	"spider-go/internal/cache"
+	"spider-go/internal/common"
+	"spider-go/internal/service"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Service 电费服务接口
+type Service interface {
+	BindDorm(ctx context.Context, uid int, building, room string) error
+	UnbindDorm(ctx context.Context, uid int) error
+	GetBinding(ctx context.Context, uid int) (*DormBinding, error)
+	GetBalance(ctx context.Context, uid int) (*Balance, error)
+	QueryBalance(ctx context.Context, building, room string) (*Balance, error)
+
+	// SetAlert 设置低电费提醒
+	SetAlert(ctx context.Context, uid int, enabled bool, threshold float64) (*DormBinding, error)
+	// ListAlertSubscribers 按 uid 分批获取开启提醒的绑定（供定时任务使用）
+	ListAlertSubscribers(ctx context.Context, afterUid, limit int) ([]DormBinding, error)
+	// MarkAlerted 标记/清除本轮提醒状态
+	MarkAlerted(ctx context.Context, uid int, alerted bool) error
+}
+
+// DefaultAlertThreshold 默认低电费提醒阈值（元）
+const DefaultAlertThreshold = 10.0
+
+// electricityService 电费服务实现
+type electricityService struct {
+	repo           Repository
+	crawlerService service.CrawlerService
+	userDataCache  cache.UserDataCache
+	queryURL       string
+}
+
+// NewService 创建电费服务
+func NewService(
+	repo Repository,
+	crawlerService service.CrawlerService,
+	userDataCache cache.UserDataCache,
+	queryURL string,
+) Service {
+	return &electricityService{
+		repo:           repo,
+		crawlerService: crawlerService,
+		userDataCache:  userDataCache,
+		queryURL:       queryURL,
+	}
+}
+
+// BindDorm 绑定宿舍（先查询一次确认宿舍存在）
+func (s *electricityService) BindDorm(ctx context.Context, uid int, building, room string) error {
+	building = strings.TrimSpace(building)
+	room = strings.TrimSpace(room)
+	if building == "" || room == "" {
+		return common.NewAppError(common.CodeInvalidParams, "楼栋和房间号不能为空")
+	}
+
+	balance, err := s.QueryBalance(ctx, building, room)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Save(ctx, &DormBinding{Uid: uid, Building: building, Room: room}); err != nil {
+		return common.NewAppError(common.CodeInternalError, "绑定宿舍失败")
+	}
+
+	_ = s.userDataCache.CacheElectricity(ctx, uid, balance, 10*time.Minute)
+	return nil
+}
+
+// UnbindDorm 解除宿舍绑定
+func (s *electricityService) UnbindDorm(ctx context.Context, uid int) error {
+	if err := s.repo.Delete(ctx, uid); err != nil {
+		return common.NewAppError(common.CodeInternalError, "解除绑定失败")
+	}
+	_ = s.userDataCache.DeleteElectricity(ctx, uid)
+	return nil
+}
+
+// GetBinding 获取宿舍绑定
+func (s *electricityService) GetBinding(ctx context.Context, uid int) (*DormBinding, error) {
+	binding, err := s.repo.FindByUid(ctx, uid)
+	if err != nil {
+		if errors.Is(err, ErrDormNotBound) {
+			return nil, common.NewAppError(common.CodeDormNotBound, "宿舍未绑定，请先绑定宿舍")
+		}
+		return nil, common.NewAppError(common.CodeInternalError, "获取宿舍绑定失败")
+	}
+	return binding, nil
+}
+
+// GetBalance 获取用户绑定宿舍的电费信息
+func (s *electricityService) GetBalance(ctx context.Context, uid int) (*Balance, error) {
+	binding, err := s.GetBinding(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	// 先查询缓存
+	var cached Balance
+	if err := s.userDataCache.GetElectricity(ctx, uid, &cached); err == nil &&
+		cached.Building == binding.Building && cached.Room == binding.Room {
+		return &cached, nil
+	}
+
+	balance, err := s.QueryBalance(ctx, binding.Building, binding.Room)
+	if err != nil {
+		return nil, err
+	}
+
+	// 写入缓存（电费系统很慢，缓存10分钟）
+	_ = s.userDataCache.CacheElectricity(ctx, uid, balance, 10*time.Minute)
+
+	return balance, nil
+}
+
+// QueryBalance 查询指定宿舍的电费信息（不走缓存）
+func (s *electricityService) QueryBalance(ctx context.Context, building, room string) (*Balance, error) {
+	if s.queryURL == "" {
+		return nil, common.NewAppError(common.CodeInternalError, "电费查询暂未开放")
+	}
+
+	form := url.Values{}
+	form.Set("building", building)
+	form.Set("room", room)
+
+	body, err := s.crawlerService.FetchWithCookies(ctx, "POST", s.queryURL, nil, form)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	balance, err := parseBalanceFromHTML(body)
+	if err != nil {
+		return nil, err
+	}
+	balance.Building = building
+	balance.Room = room
+
+	return balance, nil
+}
+
+// SetAlert 设置低电费提醒
+func (s *electricityService) SetAlert(ctx context.Context, uid int, enabled bool, threshold float64) (*DormBinding, error) {
+	binding, err := s.GetBinding(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	if threshold <= 0 {
+		threshold = DefaultAlertThreshold
+	}
+
+	if err := s.repo.UpdateAlert(ctx, uid, enabled, threshold); err != nil {
+		return nil, common.NewAppError(common.CodeInternalError, "设置提醒失败")
+	}
+
+	binding.AlertEnabled = enabled
+	binding.AlertThreshold = threshold
+	binding.AlertedAt = nil
+	return binding, nil
+}
+
+// ListAlertSubscribers 按 uid 分批获取开启提醒的绑定
+func (s *electricityService) ListAlertSubscribers(ctx context.Context, afterUid, limit int) ([]DormBinding, error) {
+	return s.repo.FindAlertSubscribers(ctx, afterUid, limit)
+}
+
+// MarkAlerted 标记/清除本轮提醒状态
+func (s *electricityService) MarkAlerted(ctx context.Context, uid int, alerted bool) error {
+	if !alerted {
+		return s.repo.UpdateAlertedAt(ctx, uid, nil)
+	}
+	now := time.Now()
+	return s.repo.UpdateAlertedAt(ctx, uid, &now)
+}
+
+// parseBalanceFromHTML 解析电费查询页面
+func parseBalanceFromHTML(r io.Reader) (*Balance, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return nil, common.NewAppError(common.CodeJwcParseFailed, "解析HTML失败")
+	}
+
+	text := normalizeSpace(doc.Text())
+	if strings.Contains(text, "房间不存在") || strings.Contains(text, "未找到房间") {
+		return nil, common.NewAppError(common.CodeInvalidParams, "宿舍不存在，请检查楼栋和房间号")
+	}
+
+	amount, hasAmount := matchNumber(text, `剩余(?:金额|电费|余额)\s*[:：]\s*(-?\d+(?:\.\d+)?)`)
+	kwh, hasKwh := matchNumber(text, `剩余电量\s*[:：]\s*(-?\d+(?:\.\d+)?)`)
+	if !hasAmount && !hasKwh {
+		return nil, common.NewAppError(common.CodeJwcParseFailed, "未找到电费数据")
+	}
+
+	balance := &Balance{
+		Balance:      amount,
+		RemainingKwh: kwh,
+		Records:      []Record{},
+	}
+	if m := regexp.MustCompile(`(?:更新|抄表)时间\s*[:：]\s*(\d{4}-\d{2}-\d{2}(?:\s+\d{2}:\d{2}(?::\d{2})?)?)`).FindStringSubmatch(text); len(m) == 2 {
+		balance.UpdatedAt = m[1]
+	}
+
+	// 历史记录表：按表头文字定位列
+	doc.Find("table").Each(func(_ int, table *goquery.Selection) {
+		rows := table.Find("tr")
+		if rows.Length() < 2 {
+			return
+		}
+
+		cols := map[string]int{}
+		rows.First().Find("th, td").Each(func(i int, cell *goquery.Selection) {
+			header := normalizeSpace(cell.Text())
+			switch {
+			case strings.Contains(header, "时间"):
+				cols["time"] = i
+			case strings.Contains(header, "类型"):
+				cols["type"] = i
+			case strings.Contains(header, "金额"):
+				cols["amount"] = i
+			case strings.Contains(header, "电量"):
+				cols["kwh"] = i
+			}
+		})
+		if _, ok := cols["time"]; !ok {
+			return
+		}
+
+		rows.Slice(1, rows.Length()).Each(func(_ int, tr *goquery.Selection) {
+			tds := tr.Find("td")
+			cell := func(key string) string {
+				i, ok := cols[key]
+				if !ok || i >= tds.Length() {
+					return ""
+				}
+				return normalizeSpace(tds.Eq(i).Text())
+			}
+
+			t := cell("time")
+			if t == "" {
+				return
+			}
+
+			amount, _ := strconv.ParseFloat(cell("amount"), 64)
+			kwh, _ := strconv.ParseFloat(cell("kwh"), 64)
+			recordType := cell("type")
+			if recordType == "" {
+				if amount > 0 {
+					recordType = "充值"
+				} else {
+					recordType = "用电"
+				}
+			}
+
+			balance.Records = append(balance.Records, Record{
+				Time:   t,
+				Type:   recordType,
+				Amount: amount,
+				Kwh:    kwh,
+			})
+		})
+	})
+
+	return balance, nil
+}
+
+// matchNumber 从文本中提取数字
+func matchNumber(text, pattern string) (float64, bool) {
+	m := regexp.MustCompile(pattern).FindStringSubmatch(text)
+	if len(m) != 2 {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// normalizeSpace 去除不间断空格并合并空白
+func normalizeSpace(s string) string {
+	s = strings.ReplaceAll(s, "\u00A0", " ")
+	return strings.Join(strings.Fields(s), " ")
+}
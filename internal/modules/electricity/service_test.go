@@ -0,0 +1,48 @@
+package electricity
+
+import (
+	"bytes"
+	"context"
+	"spider-go/internal/common"
+	"spider-go/internal/service/jwctest"
+	"testing"
+)
+
+func TestParseBalanceFromHTML(t *testing.T) {
+	for _, name := range []string{"balance", "history"} {
+		t.Run(name, func(t *testing.T) {
+			balance, err := parseBalanceFromHTML(bytes.NewReader(jwctest.ReadFixture(t, "testdata/"+name+".html")))
+			if err != nil {
+				t.Fatalf("parseBalanceFromHTML: %v", err)
+			}
+			jwctest.AssertGolden(t, "testdata/"+name+".golden.json", balance)
+		})
+	}
+}
+
+func TestParseBalanceFromHTMLErrors(t *testing.T) {
+	tests := []struct {
+		fixture string
+		code    int
+	}{
+		{"room_not_found", common.CodeInvalidParams},
+		{"maintenance", common.CodeJwcParseFailed},
+	}
+	for _, tt := range tests {
+		_, err := parseBalanceFromHTML(bytes.NewReader(jwctest.ReadFixture(t, "testdata/"+tt.fixture+".html")))
+		if appErr, ok := err.(*common.AppError); !ok || appErr.Code != tt.code {
+			t.Errorf("%s: got %v, want code %d", tt.fixture, err, tt.code)
+		}
+	}
+}
+
+func TestQueryBalanceWithoutQueryURL(t *testing.T) {
+	// 未配置查询地址时模块照常创建，查询返回明确的错误而不是发出请求
+	m := NewModule(nil, nil, nil, "")
+
+	_, err := m.GetService().QueryBalance(context.Background(), "1栋", "101")
+	appErr, ok := err.(*common.AppError)
+	if !ok || appErr.Code != common.CodeInternalError || appErr.Message != "电费查询暂未开放" {
+		t.Fatalf("QueryBalance error = %v, want 电费查询暂未开放", err)
+	}
+}
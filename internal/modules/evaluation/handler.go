@@ -1,5 +1,158 @@
 package evaluation
 
-type handler struct {
-	s
+import (
+	"spider-go/internal/common"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler 教评HTTP处理器
+type Handler struct {
+	service Service
+}
+
+// NewHandler 创建教评处理器
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// RegisterRoutes 注册路由
+func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	evaluations := r.Group("/evaluations")
+	{
+		evaluations.GET("", h.GetPending)               // 获取待评价课程
+		evaluations.POST("/submit-all", h.SubmitAll)    // 一键评价
+		evaluations.GET("/:id", h.GetQuestionnaire)     // 获取评价问卷
+		evaluations.POST("/:id", h.SubmitQuestionnaire) // 提交评价
+	}
+}
+
+// GetPending 获取待评价课程
+// @Summary 获取待评价课程
+// @Tags Evaluation
+// @Produce json
+// @Param term query string false "学期" example(2024-2025-1)
+// @Success 200 {array} PendingEvaluation
+// @Router /evaluations [get]
+func (h *Handler) GetPending(c *gin.Context) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		common.Error(c, common.CodeUnauthorized, "未授权")
+		return
+	}
+
+	var req GetPendingRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.Error(c, common.CodeInvalidParams, err.Error())
+		return
+	}
+
+	list, err := h.service.GetPendingEvaluations(c.Request.Context(), uid.(int), req.Term)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.ErrorWithAppError(c, appErr)
+		} else {
+			common.Error(c, common.CodeInternalError, "获取待评价课程失败")
+		}
+		return
+	}
+
+	common.Success(c, list)
+}
+
+// GetQuestionnaire 获取评价问卷
+// @Summary 获取评价问卷
+// @Tags Evaluation
+// @Produce json
+// @Param id path string true "评价ID"
+// @Success 200 {object} Questionnaire
+// @Router /evaluations/{id} [get]
+func (h *Handler) GetQuestionnaire(c *gin.Context) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		common.Error(c, common.CodeUnauthorized, "未授权")
+		return
+	}
+
+	questionnaire, err := h.service.GetQuestionnaire(c.Request.Context(), uid.(int), c.Param("id"))
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.ErrorWithAppError(c, appErr)
+		} else {
+			common.Error(c, common.CodeInternalError, "获取评价问卷失败")
+		}
+		return
+	}
+
+	common.Success(c, questionnaire)
+}
+
+// SubmitQuestionnaire 提交评价
+// @Summary 提交评价
+// @Tags Evaluation
+// @Accept json
+// @Produce json
+// @Param id path string true "评价ID"
+// @Param request body SubmitRequest true "评价内容"
+// @Success 200 {object} gin.H
+// @Router /evaluations/{id} [post]
+func (h *Handler) SubmitQuestionnaire(c *gin.Context) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		common.Error(c, common.CodeUnauthorized, "未授权")
+		return
+	}
+
+	var req SubmitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.Error(c, common.CodeInvalidParams, err.Error())
+		return
+	}
+
+	if err := h.service.Submit(c.Request.Context(), uid.(int), c.Param("id"), &req); err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.ErrorWithAppError(c, appErr)
+		} else {
+			common.Error(c, common.CodeInternalError, "提交评价失败")
+		}
+		return
+	}
+
+	common.Success(c, gin.H{"message": "评价成功"})
+}
+
+// SubmitAll 一键评价（所有未提交课程使用默认评分）
+// @Summary 一键评价
+// @Tags Evaluation
+// @Accept json
+// @Produce json
+// @Param request body SubmitAllRequest true "一键评价请求"
+// @Success 200 {object} SubmitAllResponse
+// @Router /evaluations/submit-all [post]
+func (h *Handler) SubmitAll(c *gin.Context) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		common.Error(c, common.CodeUnauthorized, "未授权")
+		return
+	}
+
+	var req SubmitAllRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.Error(c, common.CodeInvalidParams, err.Error())
+		return
+	}
+
+	resp, err := h.service.SubmitAll(c.Request.Context(), uid.(int), &req)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.ErrorWithAppError(c, appErr)
+		} else {
+			common.Error(c, common.CodeInternalError, "一键评价失败")
+		}
+		return
+	}
+
+	common.Success(c, resp)
 }
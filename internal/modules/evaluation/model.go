@@ -1 +1,62 @@
 package evaluation
+
+// PendingEvaluation 待评价课程
+type PendingEvaluation struct {
+	ID         string `json:"id"`          // 评价ID（用于获取问卷和提交）
+	CourseCode string `json:"course_code"` // 课程编号
+	CourseName string `json:"course_name"` // 课程名称
+	Teacher    string `json:"teacher"`     // 授课教师
+	Category   string `json:"category"`    // 评教类别
+	Score      string `json:"score"`       // 总评分
+	Evaluated  bool   `json:"evaluated"`   // 是否已评
+	Submitted  bool   `json:"submitted"`   // 是否已提交
+}
+
+// Questionnaire 评价问卷
+type Questionnaire struct {
+	ID         string      `json:"id"`          // 评价ID
+	CourseName string      `json:"course_name"` // 课程名称
+	Teacher    string      `json:"teacher"`     // 授课教师
+	Indicators []Indicator `json:"indicators"`  // 评价指标
+	HasComment bool        `json:"has_comment"` // 是否有文字评价
+}
+
+// Indicator 评价指标
+type Indicator struct {
+	ID      string   `json:"id"`      // 指标ID（提交时作为 answers 的 key）
+	Title   string   `json:"title"`   // 指标内容
+	Options []Option `json:"options"` // 可选项（按从高到低排列）
+}
+
+// Option 指标选项
+type Option struct {
+	Value string `json:"value"` // 选项值（提交时作为 answers 的 value）
+	Label string `json:"label"` // 选项名称：优/良/中/及格/差
+}
+
+// SubmitRequest 提交评价请求
+type SubmitRequest struct {
+	Answers map[string]string `json:"answers"` // 指标ID -> 选项值，未填写的指标使用默认评分
+	Comment string            `json:"comment"` // 文字评价（可选）
+	Rating  int               `json:"rating"`  // 默认评分：选项序号，0 为最高档
+}
+
+// SubmitAllRequest 一键评价请求
+type SubmitAllRequest struct {
+	Term    string `json:"term"`    // 学期（可选，默认当前学期）
+	Rating  int    `json:"rating"`  // 默认评分：选项序号，0 为最高档
+	Comment string `json:"comment"` // 文字评价（可选）
+}
+
+// SubmitAllResponse 一键评价响应
+type SubmitAllResponse struct {
+	SuccessCount int      `json:"success_count"`
+	FailCount    int      `json:"fail_count"`
+	TotalCount   int      `json:"total_count"`
+	Failures     []string `json:"failures"` // 失败的课程及原因
+}
+
+// GetPendingRequest 获取待评价课程请求
+type GetPendingRequest struct {
+	Term string `form:"term"` // 学期（可选），格式：2024-2025-1，默认当前学期
+}
@@ -1 +1,41 @@
 package evaluation
+
+import (
+	"spider-go/internal/cache"
+	"spider-go/internal/service"
+	"spider-go/internal/shared"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Module 教评模块
+type Module struct {
+	handler *Handler
+	service Service
+}
+
+// NewModule 创建教评模块
+func NewModule(
+	userQuery shared.UserQuery,
+	crawlerService service.CrawlerService,
+	configCache cache.ConfigCache,
+	evaluationURL string,
+) *Module {
+	svc := NewService(userQuery, crawlerService, configCache, evaluationURL)
+	handler := NewHandler(svc)
+
+	return &Module{
+		handler: handler,
+		service: svc,
+	}
+}
+
+// RegisterRoutes 注册路由
+func (m *Module) RegisterRoutes(r *gin.RouterGroup) {
+	m.handler.RegisterRoutes(r)
+}
+
+// GetService 获取服务实例（用于跨模块调用）
+func (m *Module) GetService() Service {
+	return m.service
+}
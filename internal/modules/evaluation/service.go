@@ -1 +1,554 @@
 package evaluation
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"spider-go/internal/cache"
+	"spider-go/internal/common"
+	"spider-go/internal/service"
+	"spider-go/internal/shared"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// Service 教评服务接口
+type Service interface {
+	GetPendingEvaluations(ctx context.Context, uid int, term string) ([]PendingEvaluation, error)
+	GetQuestionnaire(ctx context.Context, uid int, id string) (*Questionnaire, error)
+	Submit(ctx context.Context, uid int, id string, req *SubmitRequest) error
+	SubmitAll(ctx context.Context, uid int, req *SubmitAllRequest) (*SubmitAllResponse, error)
+}
+
+// evaluationService 教评服务实现
+type evaluationService struct {
+	userQuery      shared.UserQuery
+	crawlerService service.CrawlerService
+	configCache    cache.ConfigCache
+	evaluationURL  string
+}
+
+// NewService 创建教评服务
+func NewService(
+	userQuery shared.UserQuery,
+	crawlerService service.CrawlerService,
+	configCache cache.ConfigCache,
+	evaluationURL string,
+) Service {
+	return &evaluationService{
+		userQuery:      userQuery,
+		crawlerService: crawlerService,
+		configCache:    configCache,
+		evaluationURL:  evaluationURL,
+	}
+}
+
+// evaluationForm 评价表单（问卷 + 提交所需的隐藏字段）
+type evaluationForm struct {
+	action        string
+	hidden        url.Values
+	commentField  string
+	questionnaire *Questionnaire
+}
+
+// GetPendingEvaluations 获取指定学期（默认当前学期）的待评价课程
+func (s *evaluationService) GetPendingEvaluations(ctx context.Context, uid int, term string) ([]PendingEvaluation, error) {
+	term, err := s.resolveTerm(ctx, term)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.getAccount(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. 获取评价批次
+	body, err := s.crawlerService.FetchWithSession(ctx, account, "GET", s.evaluationURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	batchLinks, err := s.parseBatchesFromHTML(body, term)
+	body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. 获取每个批次下的课程
+	var list []PendingEvaluation
+	for _, link := range batchLinks {
+		listURL, err := s.resolveURL(link)
+		if err != nil {
+			continue
+		}
+
+		body, err := s.crawlerService.FetchWithSession(ctx, account, "GET", listURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		items, err := s.parseCoursesFromHTML(body)
+		body.Close()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, items...)
+	}
+
+	return list, nil
+}
+
+// GetQuestionnaire 获取评价问卷
+func (s *evaluationService) GetQuestionnaire(ctx context.Context, uid int, id string) (*Questionnaire, error) {
+	account, err := s.getAccount(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	form, err := s.fetchForm(ctx, account, id)
+	if err != nil {
+		return nil, err
+	}
+	return form.questionnaire, nil
+}
+
+// Submit 提交评价（未填写的指标使用默认评分）
+func (s *evaluationService) Submit(ctx context.Context, uid int, id string, req *SubmitRequest) error {
+	account, err := s.getAccount(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	form, err := s.fetchForm(ctx, account, id)
+	if err != nil {
+		return err
+	}
+
+	data, err := s.buildSubmitForm(form, req)
+	if err != nil {
+		return err
+	}
+
+	body, err := s.crawlerService.FetchWithSession(ctx, account, "POST", form.action, data)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	return s.checkSubmitResult(body)
+}
+
+// SubmitAll 一键评价：所有未提交的课程使用默认评分
+func (s *evaluationService) SubmitAll(ctx context.Context, uid int, req *SubmitAllRequest) (*SubmitAllResponse, error) {
+	list, err := s.GetPendingEvaluations(ctx, uid, req.Term)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &SubmitAllResponse{Failures: []string{}}
+	for _, item := range list {
+		if item.Submitted {
+			continue
+		}
+
+		resp.TotalCount++
+		err := s.Submit(ctx, uid, item.ID, &SubmitRequest{
+			Rating:  req.Rating,
+			Comment: req.Comment,
+		})
+		if err != nil {
+			resp.FailCount++
+			resp.Failures = append(resp.Failures, fmt.Sprintf("%s: %v", item.CourseName, err))
+			continue
+		}
+		resp.SuccessCount++
+	}
+
+	return resp, nil
+}
+
+// resolveTerm 校验学期，未指定时使用当前学期
+func (s *evaluationService) resolveTerm(ctx context.Context, term string) (string, error) {
+	if term == "" {
+		current, err := s.configCache.GetCurrentTerm(ctx)
+		if err != nil {
+			return "", common.NewAppError(common.CodeInvalidParams, err.Error())
+		}
+		term = current
+	}
+
+	re := regexp.MustCompile(`^\d{4}-\d{4}-[12]$`)
+	if !re.MatchString(term) {
+		return "", common.NewAppError(common.CodeJwcInvalidParams, "学期格式错误")
+	}
+	return term, nil
+}
+
+// getAccount 获取用户的教务系统账号
+func (s *evaluationService) getAccount(ctx context.Context, uid int) (*service.JwcAccount, error) {
+	user, err := s.userQuery.GetUserByUid(ctx, uid)
+	if err != nil {
+		return nil, common.NewAppError(common.CodeUserNotFound, "用户不存在")
+	}
+
+	if user.Sid == "" || user.Spwd == "" {
+		return nil, common.NewAppError(common.CodeJwcNotBound, "")
+	}
+
+	return &service.JwcAccount{Uid: uid, Username: user.Sid, Password: user.Spwd}, nil
+}
+
+// fetchForm 获取并解析评价表单
+func (s *evaluationService) fetchForm(ctx context.Context, account *service.JwcAccount, id string) (*evaluationForm, error) {
+	editURL, err := s.decodeID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := s.crawlerService.FetchWithSession(ctx, account, "GET", editURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	form, err := s.parseFormFromHTML(body, editURL)
+	if err != nil {
+		return nil, err
+	}
+	form.questionnaire.ID = id
+
+	return form, nil
+}
+
+// buildSubmitForm 构造提交表单
+func (s *evaluationService) buildSubmitForm(form *evaluationForm, req *SubmitRequest) (url.Values, error) {
+	data := url.Values{}
+	for k, v := range form.hidden {
+		data[k] = append([]string(nil), v...)
+	}
+
+	for _, indicator := range form.questionnaire.Indicators {
+		if len(indicator.Options) == 0 {
+			continue
+		}
+
+		value, ok := req.Answers[indicator.ID]
+		if ok {
+			if !hasOption(indicator, value) {
+				return nil, common.NewAppError(common.CodeInvalidParams, fmt.Sprintf("指标「%s」的选项无效", indicator.Title))
+			}
+		} else {
+			rating := req.Rating
+			if rating < 0 {
+				rating = 0
+			}
+			if rating >= len(indicator.Options) {
+				rating = len(indicator.Options) - 1
+			}
+			value = indicator.Options[rating].Value
+		}
+
+		data.Set(indicator.ID, value)
+	}
+
+	if form.commentField != "" {
+		data.Set(form.commentField, req.Comment)
+	}
+
+	// issubmit=1 表示提交（0 为仅保存）
+	data.Set("issubmit", "1")
+
+	return data, nil
+}
+
+// submitSuccessMessages 教务系统提交成功时的提示（去掉结尾标点后比较）
+// "保存成功"是 issubmit=0 仅保存时的提示，评价并未提交，不能算作成功
+var submitSuccessMessages = map[string]bool{
+	"提交成功":   true,
+	"评价成功":   true,
+	"评价提交成功": true,
+}
+
+// submitAlertRegexp 提取 alert 弹窗中的提示文字
+var submitAlertRegexp = regexp.MustCompile(`alert\(\s*['"](.+?)['"]\s*\)`)
+
+// checkSubmitResult 检查提交结果（教务系统以 alert 弹窗返回结果，只有已知的成功提示才视为成功）
+func (s *evaluationService) checkSubmitResult(r io.Reader) error {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return common.NewAppError(common.CodeJwcRequestFailed, "读取提交结果失败")
+	}
+
+	matches := submitAlertRegexp.FindAllStringSubmatch(string(raw), -1)
+	saved := false
+	for _, m := range matches {
+		message := strings.TrimRight(strings.TrimSpace(m[1]), "！!。.")
+		if submitSuccessMessages[message] {
+			return nil
+		}
+		saved = saved || message == "保存成功"
+	}
+
+	if saved {
+		return common.NewAppError(common.CodeJwcRequestFailed, "评价已保存但未提交，请重新提交")
+	}
+
+	if len(matches) > 0 {
+		return common.NewAppError(common.CodeJwcRequestFailed, strings.TrimSpace(matches[0][1]))
+	}
+	return common.NewAppError(common.CodeJwcRequestFailed, "提交评价失败")
+}
+
+// parseBatchesFromHTML 解析评价批次页面，返回指定学期的课程列表链接
+func (s *evaluationService) parseBatchesFromHTML(r io.Reader, term string) ([]string, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return nil, common.NewAppError(common.CodeJwcParseFailed, "解析HTML失败")
+	}
+
+	table := doc.Find("#dataList")
+	if table.Length() == 0 {
+		return nil, common.NewAppError(common.CodeJwcParseFailed, "未找到评价批次数据")
+	}
+
+	var links []string
+	table.Find("tr").Each(func(i int, tr *goquery.Selection) {
+		if !strings.Contains(tr.Text(), term) {
+			return
+		}
+		tr.Find("a[href]").Each(func(_ int, a *goquery.Selection) {
+			href, _ := a.Attr("href")
+			if strings.Contains(href, "xspj_list") {
+				links = append(links, href)
+			}
+		})
+	})
+
+	return links, nil
+}
+
+// parseCoursesFromHTML 解析待评价课程列表
+func (s *evaluationService) parseCoursesFromHTML(r io.Reader) ([]PendingEvaluation, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return nil, common.NewAppError(common.CodeJwcParseFailed, "解析HTML失败")
+	}
+
+	table := doc.Find("#dataList")
+	if table.Length() == 0 {
+		return nil, common.NewAppError(common.CodeJwcParseFailed, "未找到待评价课程数据")
+	}
+
+	trim := func(s string) string {
+		return strings.TrimSpace(strings.ReplaceAll(s, "\u00A0", ""))
+	}
+
+	var list []PendingEvaluation
+	table.Find("tr").Each(func(i int, tr *goquery.Selection) {
+		tds := tr.Find("td")
+		if tds.Length() < 9 {
+			return
+		}
+
+		// 操作列中的评价链接
+		var editHref string
+		tds.Eq(8).Find("a").Each(func(_ int, a *goquery.Selection) {
+			href := a.AttrOr("href", "")
+			if editHref == "" && strings.Contains(href, "xspj_edit") {
+				editHref = href
+			}
+			// 部分页面使用 onclick="openWindow('...')"
+			if editHref == "" {
+				if m := regexp.MustCompile(`['"]([^'"]*xspj_edit[^'"]*)['"]`).FindStringSubmatch(a.AttrOr("onclick", "")); len(m) == 2 {
+					editHref = m[1]
+				}
+			}
+		})
+		if editHref == "" {
+			return
+		}
+
+		list = append(list, PendingEvaluation{
+			ID:         s.encodeID(editHref),
+			CourseCode: trim(tds.Eq(1).Text()),
+			CourseName: trim(tds.Eq(2).Text()),
+			Teacher:    trim(tds.Eq(3).Text()),
+			Category:   trim(tds.Eq(4).Text()),
+			Score:      trim(tds.Eq(5).Text()),
+			Evaluated:  trim(tds.Eq(6).Text()) == "是",
+			Submitted:  trim(tds.Eq(7).Text()) == "是",
+		})
+	})
+
+	return list, nil
+}
+
+// parseFormFromHTML 解析评价表单页面
+func (s *evaluationService) parseFormFromHTML(r io.Reader, pageURL string) (*evaluationForm, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return nil, common.NewAppError(common.CodeJwcParseFailed, "解析HTML失败")
+	}
+
+	formSel := doc.Find("form").First()
+	if formSel.Length() == 0 {
+		return nil, common.NewAppError(common.CodeJwcParseFailed, "未找到评价表单")
+	}
+
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, common.NewAppError(common.CodeJwcParseFailed, "评价地址无效")
+	}
+	actionURL, err := url.Parse(formSel.AttrOr("action", ""))
+	if err != nil {
+		return nil, common.NewAppError(common.CodeJwcParseFailed, "评价表单地址无效")
+	}
+
+	form := &evaluationForm{
+		action: base.ResolveReference(actionURL).String(),
+		hidden: url.Values{},
+	}
+
+	formSel.Find("input[type='hidden']").Each(func(_ int, in *goquery.Selection) {
+		if name := in.AttrOr("name", ""); name != "" {
+			form.hidden.Add(name, in.AttrOr("value", ""))
+		}
+	})
+
+	// 单选框按 name 分组为评价指标，保持页面顺序
+	var indicators []Indicator
+	index := make(map[string]int)
+	formSel.Find("input[type='radio']").Each(func(_ int, in *goquery.Selection) {
+		name := in.AttrOr("name", "")
+		if name == "" {
+			return
+		}
+
+		i, ok := index[name]
+		if !ok {
+			title := strings.TrimSpace(in.Closest("tr").Find("td").First().Text())
+			indicators = append(indicators, Indicator{ID: name, Title: title})
+			i = len(indicators) - 1
+			index[name] = i
+		}
+
+		indicators[i].Options = append(indicators[i].Options, Option{
+			Value: in.AttrOr("value", ""),
+			Label: radioLabel(in),
+		})
+	})
+
+	if len(indicators) == 0 {
+		return nil, common.NewAppError(common.CodeJwcParseFailed, "未找到评价指标")
+	}
+
+	form.commentField = formSel.Find("textarea").First().AttrOr("name", "")
+
+	form.questionnaire = &Questionnaire{
+		CourseName: matchField(doc, `课程名称[:：]\s*(\S+)`),
+		Teacher:    matchField(doc, `教师[:：]\s*(\S+)`),
+		Indicators: indicators,
+		HasComment: form.commentField != "",
+	}
+
+	return form, nil
+}
+
+// encodeID 将评价链接编码为对外的评价ID
+func (s *evaluationService) encodeID(href string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(href))
+}
+
+// decodeID 解码评价ID，并校验只能访问教务系统自身
+func (s *evaluationService) decodeID(id string) (string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(id)
+	if err != nil || len(raw) == 0 {
+		return "", common.NewAppError(common.CodeInvalidParams, "评价ID无效")
+	}
+
+	target, err := s.resolveURL(string(raw))
+	if err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// resolveURL 将页面中的链接解析为绝对地址（仅允许与教评入口同一主机）
+func (s *evaluationService) resolveURL(href string) (string, error) {
+	base, err := url.Parse(s.evaluationURL)
+	if err != nil {
+		return "", common.NewAppError(common.CodeInternalError, "教评地址配置错误")
+	}
+
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return "", common.NewAppError(common.CodeInvalidParams, "评价ID无效")
+	}
+
+	target := base.ResolveReference(ref)
+	if target.Host != base.Host {
+		return "", common.NewAppError(common.CodeInvalidParams, "评价ID无效")
+	}
+	return target.String(), nil
+}
+
+// radioLabel 获取单选框后面的文字（如 "优"）
+func radioLabel(in *goquery.Selection) string {
+	if len(in.Nodes) == 0 {
+		return ""
+	}
+	for n := in.Nodes[0].NextSibling; n != nil; n = n.NextSibling {
+		if n.Type == html.TextNode {
+			if t := strings.TrimSpace(strings.ReplaceAll(n.Data, "\u00A0", "")); t != "" {
+				return t
+			}
+			continue
+		}
+		if n.Type == html.ElementNode && (n.Data == "label" || n.Data == "span") {
+			return strings.TrimSpace(goquery.NewDocumentFromNode(n).Text())
+		}
+		break
+	}
+	return strings.TrimSpace(in.AttrOr("title", ""))
+}
+
+// matchField 逐个文本节点匹配，提取页面中的字段
+func matchField(doc *goquery.Document, pattern string) string {
+	re := regexp.MustCompile(pattern)
+
+	var result string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if result != "" {
+			return
+		}
+		if n.Type == html.TextNode {
+			if m := re.FindStringSubmatch(strings.ReplaceAll(n.Data, "\u00A0", " ")); len(m) == 2 {
+				result = m[1]
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+
+	for _, n := range doc.Nodes {
+		walk(n)
+	}
+	return result
+}
+
+// hasOption 判断选项值是否属于该指标
+func hasOption(indicator Indicator, value string) bool {
+	for _, opt := range indicator.Options {
+		if opt.Value == value {
+			return true
+		}
+	}
+	return false
+}
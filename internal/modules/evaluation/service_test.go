@@ -0,0 +1,37 @@
+package evaluation
+
+import (
+	"spider-go/internal/common"
+	"strings"
+	"testing"
+)
+
+func TestCheckSubmitResult(t *testing.T) {
+	s := &evaluationService{}
+
+	tests := []struct {
+		name    string
+		page    string
+		wantErr string // 为空表示成功
+	}{
+		{"success", `<script>alert('提交成功！');window.close();</script>`, ""},
+		{"success with spaces", `<script>alert( "评价成功" )</script>`, ""},
+		{"not successful", `<script>alert('评价未成功，请重新提交');</script>`, "评价未成功，请重新提交"},
+		{"success word outside alert", `<html><script>var msg = "成功";</script><span>提交成功后可查看</span></html>`, "提交评价失败"},
+		{"saved but not submitted", `<script>alert('保存成功！');</script>`, "评价已保存但未提交，请重新提交"},
+		{"failure then label", `<script>alert('已过评价时间');</script><label>提交成功</label>`, "已过评价时间"},
+	}
+	for _, tt := range tests {
+		err := s.checkSubmitResult(strings.NewReader(tt.page))
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: got %v, want success", tt.name, err)
+			}
+			continue
+		}
+		appErr, ok := err.(*common.AppError)
+		if !ok || appErr.Message != tt.wantErr {
+			t.Errorf("%s: got %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
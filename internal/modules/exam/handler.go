@@ -22,7 +22,10 @@ func NewHandler(service Service) *Handler {
 func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 	exams := r.Group("/exams")
 	{
-		exams.GET("", h.GetExams) // 获取考试安排
+		exams.GET("", h.GetExams)             // 获取考试安排
+		exams.GET("/ics", h.ExportCalendar)   // 导出考试日历
+		exams.GET("/reminder", h.GetReminder) // 获取考试提醒设置
+		exams.PUT("/reminder", h.SetReminder) // 开启/关闭考试提醒
 	}
 }
 
@@ -58,3 +61,97 @@ func (h *Handler) GetExams(c *gin.Context) {
 
 	common.Success(c, exams)
 }
+
+// ExportCalendar 导出考试安排为 iCalendar 文件
+// @Summary 导出考试日历
+// @Tags Exam
+// @Produce text/calendar
+// @Param term query string false "学期（默认当前学期）" example(2024-2025-1)
+// @Success 200 {file} file
+// @Router /exams/ics [get]
+func (h *Handler) ExportCalendar(c *gin.Context) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		common.Error(c, common.CodeUnauthorized, "未授权")
+		return
+	}
+
+	var req ExportCalendarRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.Error(c, common.CodeInvalidParams, err.Error())
+		return
+	}
+
+	data, err := h.service.ExportCalendar(c.Request.Context(), uid.(int), req.Term)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.ErrorWithAppError(c, appErr)
+		} else {
+			common.Error(c, common.CodeInternalError, "导出日历失败")
+		}
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="exams.ics"`)
+	c.Data(200, "text/calendar; charset=utf-8", data)
+}
+
+// GetReminder 获取考试提醒设置
+// @Summary 获取考试提醒设置
+// @Tags Exam
+// @Produce json
+// @Success 200 {object} ExamReminder
+// @Router /exams/reminder [get]
+func (h *Handler) GetReminder(c *gin.Context) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		common.Error(c, common.CodeUnauthorized, "未授权")
+		return
+	}
+
+	reminder, err := h.service.GetReminder(c.Request.Context(), uid.(int))
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.ErrorWithAppError(c, appErr)
+		} else {
+			common.Error(c, common.CodeInternalError, "获取提醒设置失败")
+		}
+		return
+	}
+
+	common.Success(c, reminder)
+}
+
+// SetReminder 开启/关闭考试提醒
+// @Summary 开启/关闭考试提醒
+// @Tags Exam
+// @Accept json
+// @Produce json
+// @Param request body ReminderRequest true "提醒设置"
+// @Success 200 {object} ExamReminder
+// @Router /exams/reminder [put]
+func (h *Handler) SetReminder(c *gin.Context) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		common.Error(c, common.CodeUnauthorized, "未授权")
+		return
+	}
+
+	var req ReminderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.Error(c, common.CodeInvalidParams, err.Error())
+		return
+	}
+
+	reminder, err := h.service.SetReminder(c.Request.Context(), uid.(int), req.Enabled)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.ErrorWithAppError(c, appErr)
+		} else {
+			common.Error(c, common.CodeInternalError, "保存提醒设置失败")
+		}
+		return
+	}
+
+	common.Success(c, reminder)
+}
@@ -0,0 +1,109 @@
+package exam
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"spider-go/pkg/ical"
+	"strconv"
+	"time"
+)
+
+// examTimePattern 匹配考试时间文本，如 "2025-01-10 09:00~11:00"、"2025年01月10日 09:00-11:00"、
+// "2025-01-10 09:00:00~2025-01-10 11:00:00"
+var examTimePattern = regexp.MustCompile(
+	`(\d{4})[-/年](\d{1,2})[-/月](\d{1,2})日?\s*(\d{1,2}):(\d{2})(?::\d{2})?\s*[~～\-－至到]\s*` +
+		`(?:(\d{4})[-/年](\d{1,2})[-/月](\d{1,2})日?\s*)?(\d{1,2}):(\d{2})`,
+)
+
+// parseExamTime 解析考试时间文本为北京时间的开始、结束时间
+func parseExamTime(raw string) (start, end time.Time, ok bool) {
+	m := examTimePattern.FindStringSubmatch(raw)
+	if m == nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	n := func(s string) int {
+		v, _ := strconv.Atoi(s)
+		return v
+	}
+	at := func(y, mo, d, h, mi string) (time.Time, bool) {
+		t := time.Date(n(y), time.Month(n(mo)), n(d), n(h), n(mi), 0, 0, ical.Location)
+		// time.Date 会自动进位非法日期（如 2 月 30 日），进位说明原文有误
+		if t.Month() != time.Month(n(mo)) || t.Day() != n(d) || n(h) > 23 || n(mi) > 59 {
+			return time.Time{}, false
+		}
+		return t, true
+	}
+
+	start, ok = at(m[1], m[2], m[3], m[4], m[5])
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	if m[6] != "" {
+		end, ok = at(m[6], m[7], m[8], m[9], m[10])
+	} else {
+		end, ok = at(m[1], m[2], m[3], m[9], m[10])
+	}
+	if !ok || !end.After(start) {
+		return time.Time{}, time.Time{}, false
+	}
+	return start, end, true
+}
+
+// fillExamTimes 补全考试的结构化时间
+func fillExamTimes(exams []ExamArrangement) {
+	for i := range exams {
+		start, end, ok := parseExamTime(exams[i].Time)
+		if !ok {
+			exams[i].StartTime, exams[i].EndTime = nil, nil
+			continue
+		}
+		exams[i].StartTime, exams[i].EndTime = &start, &end
+	}
+}
+
+// examKey 考试的稳定标识（用于日历 UID 与提醒去重）
+func examKey(term string, e ExamArrangement) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s|%s|%s|%s", term, e.ClassNo, e.ClassName, e.Time)))
+	return hex.EncodeToString(sum[:])
+}
+
+// buildCalendar 根据考试安排生成 iCalendar 文本（无法解析时间的考试跳过）
+func buildCalendar(term string, exams []ExamArrangement, now time.Time) []byte {
+	cal := ical.NewCalendar("-//spider-go//exam arrangement//CN", "考试安排 "+term)
+	stamp := ical.FormatUTC(now)
+
+	for _, e := range exams {
+		if e.StartTime == nil || e.EndTime == nil {
+			continue
+		}
+
+		cal.Line("BEGIN:VEVENT")
+		cal.Line("UID:" + examKey(term, e) + "@spider-go")
+		cal.Line("DTSTAMP:" + stamp)
+		cal.Line("DTSTART;TZID=" + ical.TZID + ":" + ical.FormatLocal(*e.StartTime))
+		cal.Line("DTEND;TZID=" + ical.TZID + ":" + ical.FormatLocal(*e.EndTime))
+		cal.Line("SUMMARY:" + ical.EscapeText("考试："+e.ClassName))
+		if e.Place != "" {
+			cal.Line("LOCATION:" + ical.EscapeText(e.Place))
+		}
+
+		desc := "课程号：" + e.ClassNo + "\n考试时间：" + e.Time
+		if e.Execution != "" {
+			desc += "\n执行情况：" + e.Execution
+		}
+		cal.Line("DESCRIPTION:" + ical.EscapeText(desc))
+
+		// 提前一天提醒
+		cal.Line("BEGIN:VALARM")
+		cal.Line("ACTION:DISPLAY")
+		cal.Line("DESCRIPTION:" + ical.EscapeText("明天考试："+e.ClassName))
+		cal.Line("TRIGGER:-P1D")
+		cal.Line("END:VALARM")
+		cal.Line("END:VEVENT")
+	}
+
+	return cal.Bytes()
+}
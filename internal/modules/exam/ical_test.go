@@ -0,0 +1,51 @@
+package exam
+
+import (
+	"spider-go/pkg/ical"
+	"testing"
+	"time"
+)
+
+func TestParseExamTime(t *testing.T) {
+	at := func(y int, mo time.Month, d, h, mi int) time.Time {
+		return time.Date(y, mo, d, h, mi, 0, 0, ical.Location)
+	}
+
+	tests := []struct {
+		name  string
+		raw   string
+		start time.Time
+		end   time.Time
+		ok    bool
+	}{
+		{name: "dash date with tilde", raw: "2025-01-10 09:00~11:00", start: at(2025, 1, 10, 9, 0), end: at(2025, 1, 10, 11, 0), ok: true},
+		{name: "chinese date", raw: "2025年01月10日 09:00-11:00", start: at(2025, 1, 10, 9, 0), end: at(2025, 1, 10, 11, 0), ok: true},
+		{name: "full datetime range", raw: "2025-01-10 09:00:00~2025-01-10 11:00:00", start: at(2025, 1, 10, 9, 0), end: at(2025, 1, 10, 11, 0), ok: true},
+		{name: "slash date single digits", raw: "2025/1/9 8:30至10:30", start: at(2025, 1, 9, 8, 30), end: at(2025, 1, 9, 10, 30), ok: true},
+		{name: "full-width tilde and spaces", raw: " 2024-12-30  14:00 ～ 16:00 ", start: at(2024, 12, 30, 14, 0), end: at(2024, 12, 30, 16, 0), ok: true},
+		{name: "surrounding text", raw: "第18周 星期五 2025-01-10 09:00~11:00（闭卷）", start: at(2025, 1, 10, 9, 0), end: at(2025, 1, 10, 11, 0), ok: true},
+		{name: "range across midnight date", raw: "2025-01-10 22:00~2025-01-11 00:30", start: at(2025, 1, 10, 22, 0), end: at(2025, 1, 11, 0, 30), ok: true},
+
+		{name: "empty", raw: ""},
+		{name: "to be announced", raw: "待定"},
+		{name: "date only", raw: "2025-01-10"},
+		{name: "missing end", raw: "2025-01-10 09:00"},
+		{name: "invalid day", raw: "2025-02-30 09:00~11:00"},
+		{name: "invalid month", raw: "2025-13-01 09:00~11:00"},
+		{name: "invalid hour", raw: "2025-01-10 25:00~26:00"},
+		{name: "invalid minute", raw: "2025-01-10 09:60~11:00"},
+		{name: "end before start", raw: "2025-01-10 11:00~09:00"},
+		{name: "end equals start", raw: "2025-01-10 09:00~09:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, ok := parseExamTime(tt.raw)
+			if ok != tt.ok {
+				t.Fatalf("parseExamTime(%q) ok = %v, want %v (start %v, end %v)", tt.raw, ok, tt.ok, start, end)
+			}
+			if !start.Equal(tt.start) || !end.Equal(tt.end) {
+				t.Errorf("parseExamTime(%q) = %v, %v, want %v, %v", tt.raw, start, end, tt.start, tt.end)
+			}
+		})
+	}
+}
@@ -1,16 +1,64 @@
 package exam
 
+import "time"
+
 // ExamArrangement 考试安排
 type ExamArrangement struct {
-	SerialNo  string `json:"serial_no"`  // 序号
-	ClassNo   string `json:"class_no"`   // 课程号
-	ClassName string `json:"class_name"` // 课程名称
-	Time      string `json:"time"`       // 考试时间
-	Place     string `json:"place"`      // 考试地点
-	Execution string `json:"execution"`  // 执行情况
+	SerialNo  string     `json:"serial_no"`            // 序号
+	ClassNo   string     `json:"class_no"`             // 课程号
+	ClassName string     `json:"class_name"`           // 课程名称
+	Time      string     `json:"time"`                 // 考试时间（原始文本）
+	StartTime *time.Time `json:"start_time,omitempty"` // 开始时间（无法解析时为空）
+	EndTime   *time.Time `json:"end_time,omitempty"`   // 结束时间（无法解析时为空）
+	Place     string     `json:"place"`                // 考试地点
+	Execution string     `json:"execution"`            // 执行情况
 }
 
 // GetExamsRequest 获取考试安排请求
 type GetExamsRequest struct {
 	Term string `form:"term" binding:"required"` // 学期：2024-2025-1
 }
+
+// ExportCalendarRequest 导出考试日历请求
+type ExportCalendarRequest struct {
+	Term string `form:"term"` // 学期：2024-2025-1（为空时使用当前学期）
+}
+
+// ExamReminder 考试提醒订阅
+type ExamReminder struct {
+	Uid       int       `gorm:"primaryKey;autoIncrement:false" json:"uid"`
+	Enabled   bool      `gorm:"not null;default:false;index" json:"enabled"` // 是否开启提醒
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (ExamReminder) TableName() string {
+	return "exam_reminders"
+}
+
+// ExamReminderLog 考试提醒发送记录（同一场考试的同一档提醒只发一次）
+type ExamReminderLog struct {
+	ID         uint   `gorm:"primaryKey"`
+	Uid        int    `gorm:"not null;uniqueIndex:idx_uid_exam_stage"`
+	ExamKey    string `gorm:"type:varchar(64);not null;uniqueIndex:idx_uid_exam_stage"` // 考试标识
+	DaysBefore int    `gorm:"not null;uniqueIndex:idx_uid_exam_stage"`                  // 提前天数档位
+	CreatedAt  time.Time
+}
+
+// TableName 指定表名
+func (ExamReminderLog) TableName() string {
+	return "exam_reminder_logs"
+}
+
+// ReminderRequest 考试提醒订阅请求
+type ReminderRequest struct {
+	Enabled bool `json:"enabled"` // 是否开启
+}
+
+// UpcomingExam 待提醒的考试
+type UpcomingExam struct {
+	ExamArrangement
+	DaysBefore int    `json:"days_before"` // 提醒档位（提前天数）
+	Key        string `json:"-"`           // 考试标识
+}
@@ -6,6 +6,7 @@ import (
 	"spider-go/internal/shared"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // Module 考试模块
@@ -16,13 +17,16 @@ type Module struct {
 
 // NewModule 创建考试模块
 func NewModule(
+	db *gorm.DB,
 	userQuery shared.UserQuery,
-	sessionService service.SessionService,
 	crawlerService service.CrawlerService,
+	parseMonitor service.ParseMonitor,
 	userDataCache cache.UserDataCache,
+	configCache cache.ConfigCache,
 	examURL string,
 ) *Module {
-	svc := NewService(userQuery, sessionService, crawlerService, userDataCache, examURL)
+	repo := NewRepository(db)
+	svc := NewService(repo, userQuery, crawlerService, parseMonitor, userDataCache, configCache, examURL)
 	handler := NewHandler(svc)
 
 	return &Module{
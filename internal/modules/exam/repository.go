@@ -0,0 +1,101 @@
+package exam
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository 考试提醒数据访问接口
+type Repository interface {
+	FindReminder(ctx context.Context, uid int) (*ExamReminder, error)
+	SaveReminder(ctx context.Context, reminder *ExamReminder) error
+	FindReminderSubscribers(ctx context.Context, afterUid, limit int) ([]int, error)
+	FindSentKeys(ctx context.Context, uid int, keys []string) (map[string]map[int]bool, error)
+	SaveLogs(ctx context.Context, logs []ExamReminderLog) error
+	DeleteReminder(ctx context.Context, uid int) error
+}
+
+// repository 考试提醒数据访问实现
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository 创建考试提醒数据访问层
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// FindReminder 查找考试提醒订阅（不存在时返回未开启的订阅）
+func (r *repository) FindReminder(ctx context.Context, uid int) (*ExamReminder, error) {
+	var reminder ExamReminder
+	if err := r.db.WithContext(ctx).First(&reminder, uid).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &ExamReminder{Uid: uid}, nil
+		}
+		return nil, err
+	}
+	return &reminder, nil
+}
+
+// SaveReminder 保存考试提醒订阅（存在则更新）
+func (r *repository) SaveReminder(ctx context.Context, reminder *ExamReminder) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "uid"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(reminder).Error
+}
+
+// FindReminderSubscribers 按 uid 分批查询开启提醒的用户
+func (r *repository) FindReminderSubscribers(ctx context.Context, afterUid, limit int) ([]int, error) {
+	var uids []int
+	err := r.db.WithContext(ctx).Model(&ExamReminder{}).
+		Where("enabled = ? AND uid > ?", true, afterUid).
+		Order("uid ASC").
+		Limit(limit).
+		Pluck("uid", &uids).Error
+	return uids, err
+}
+
+// FindSentKeys 查询已发送的提醒，返回 考试标识 -> 已发送的档位
+func (r *repository) FindSentKeys(ctx context.Context, uid int, keys []string) (map[string]map[int]bool, error) {
+	sent := make(map[string]map[int]bool)
+	if len(keys) == 0 {
+		return sent, nil
+	}
+
+	var logs []ExamReminderLog
+	if err := r.db.WithContext(ctx).
+		Where("uid = ? AND exam_key IN ?", uid, keys).
+		Find(&logs).Error; err != nil {
+		return nil, err
+	}
+
+	for _, l := range logs {
+		if sent[l.ExamKey] == nil {
+			sent[l.ExamKey] = make(map[int]bool)
+		}
+		sent[l.ExamKey][l.DaysBefore] = true
+	}
+	return sent, nil
+}
+
+// SaveLogs 记录已发送的提醒（重复记录忽略）
+func (r *repository) SaveLogs(ctx context.Context, logs []ExamReminderLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&logs).Error
+}
+
+// DeleteReminder 在同一事务中删除用户的考试提醒订阅和发送记录
+func (r *repository) DeleteReminder(ctx context.Context, uid int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("uid = ?", uid).Delete(&ExamReminder{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("uid = ?", uid).Delete(&ExamReminderLog{}).Error
+	})
+}
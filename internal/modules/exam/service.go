@@ -1,9 +1,9 @@
 package exam
 
 import (
+	"bytes"
 	"context"
 	"io"
-	"net/http"
 	"net/url"
 	"regexp"
 	"spider-go/internal/cache"
@@ -19,30 +19,55 @@ import (
 // Service 考试服务接口
 type Service interface {
 	GetAllExams(ctx context.Context, uid int, term string) ([]ExamArrangement, error)
+	// ExportCalendar 导出考试安排为 iCalendar（term 为空时使用当前学期）
+	ExportCalendar(ctx context.Context, uid int, term string) ([]byte, error)
+	// GetReminder 获取考试提醒订阅
+	GetReminder(ctx context.Context, uid int) (*ExamReminder, error)
+	// SetReminder 开启/关闭考试提醒
+	SetReminder(ctx context.Context, uid int, enabled bool) (*ExamReminder, error)
+	// ListReminderSubscribers 按 uid 分批获取开启提醒的用户
+	ListReminderSubscribers(ctx context.Context, afterUid, limit int) ([]int, error)
+	// DueReminders 获取当前学期需要提醒且尚未提醒过的考试
+	DueReminders(ctx context.Context, uid int, now time.Time) ([]UpcomingExam, error)
+	// MarkReminded 记录已发送的提醒
+	MarkReminded(ctx context.Context, uid int, exams []UpcomingExam) error
+	// Prewarm 重新抓取当前学期考试安排并写入缓存（供数据预热任务使用）
+	Prewarm(ctx context.Context, uid int) error
+	// OnJwcUnbind 解除教务绑定时取消考试提醒
+	OnJwcUnbind(ctx context.Context, uid int) error
 }
 
+// ReminderDays 考试提醒档位（提前天数，升序）
+var ReminderDays = []int{1, 3}
+
 // examService 考试服务实现
 type examService struct {
+	repo           Repository
 	userQuery      shared.UserQuery
-	sessionService service.SessionService
 	crawlerService service.CrawlerService
+	parseMonitor   service.ParseMonitor
 	userDataCache  cache.UserDataCache
+	configCache    cache.ConfigCache
 	examURL        string
 }
 
 // NewService 创建考试服务
 func NewService(
+	repo Repository,
 	userQuery shared.UserQuery,
-	sessionService service.SessionService,
 	crawlerService service.CrawlerService,
+	parseMonitor service.ParseMonitor,
 	userDataCache cache.UserDataCache,
+	configCache cache.ConfigCache,
 	examURL string,
 ) Service {
 	return &examService{
+		repo:           repo,
 		userQuery:      userQuery,
-		sessionService: sessionService,
 		crawlerService: crawlerService,
+		parseMonitor:   parseMonitor,
 		userDataCache:  userDataCache,
+		configCache:    configCache,
 		examURL:        examURL,
 	}
 }
@@ -68,31 +93,71 @@ func (s *examService) GetAllExams(ctx context.Context, uid int, term string) ([]
 	// 先查询缓存
 	var cachedExams []ExamArrangement
 	if err := s.userDataCache.GetExams(ctx, uid, term, &cachedExams); err == nil {
+		fillExamTimes(cachedExams)
 		return cachedExams, nil
 	}
 
-	// 获取会话
-	cookies, err := s.getCookiesOrLogin(ctx, uid, user.Sid, user.Spwd)
+	exams, err := s.refreshExams(ctx, uid, user.Sid, user.Spwd, term)
 	if err != nil {
+		// 教务系统熔断中，返回历史考试安排
+		if appErr, ok := err.(*common.AppError); ok && appErr.Code == common.CodeJwcUnavailable {
+			if err := s.userDataCache.GetStaleExams(ctx, uid, term, &cachedExams); err == nil {
+				fillExamTimes(cachedExams)
+				return cachedExams, nil
+			}
+		}
 		return nil, err
 	}
 
+	return exams, nil
+}
+
+// Prewarm 重新抓取当前学期的考试安排并写入缓存（忽略已有缓存）
+func (s *examService) Prewarm(ctx context.Context, uid int) error {
+	term, err := s.configCache.GetCurrentTerm(ctx)
+	if err != nil || term == "" {
+		return common.NewAppError(common.CodeInternalError, "未设置当前学期")
+	}
+
+	user, err := s.userQuery.GetUserByUid(ctx, uid)
+	if err != nil {
+		return common.NewAppError(common.CodeUserNotFound, "用户不存在")
+	}
+
+	if user.Sid == "" || user.Spwd == "" {
+		return common.NewAppError(common.CodeJwcNotBound, "")
+	}
+
+	_, err = s.refreshExams(ctx, uid, user.Sid, user.Spwd, term)
+	return err
+}
+
+// refreshExams 从教务系统抓取考试安排并写入缓存
+func (s *examService) refreshExams(ctx context.Context, uid int, sid, spwd, term string) ([]ExamArrangement, error) {
 	// 构造请求
 	form := url.Values{}
 	form.Add("xnxqid", term)
 
-	// 发起请求
-	body, err := s.crawlerService.FetchWithCookies(ctx, "POST", s.examURL, cookies, form)
+	// 发起请求（会话过期时自动重新登录）
+	account := &service.JwcAccount{Uid: uid, Username: sid, Password: spwd}
+	body, err := s.crawlerService.FetchWithSession(ctx, account, "POST", s.examURL, form)
 	if err != nil {
 		return nil, err
 	}
 	defer body.Close()
 
-	// 解析响应
-	exams, err := s.parseExamArrangementFromHTML(body)
+	page, err := io.ReadAll(body)
+	if err != nil {
+		return nil, common.NewAppError(common.CodeJwcRequestFailed, "读取考试安排页面失败")
+	}
+
+	// 解析响应（记录解析结果，页面结构变化时告警）
+	exams, err := s.parseExamArrangementFromHTML(bytes.NewReader(page))
+	s.parseMonitor.Record(ctx, service.PageExams, sid, page, err)
 	if err != nil {
 		return nil, err
 	}
+	fillExamTimes(exams)
 
 	// 写入缓存（1小时过期）
 	_ = s.userDataCache.CacheExams(ctx, uid, term, exams, time.Hour)
@@ -100,29 +165,132 @@ func (s *examService) GetAllExams(ctx context.Context, uid int, term string) ([]
 	return exams, nil
 }
 
-// getCookiesOrLogin 获取缓存的 cookies 或登录
-func (s *examService) getCookiesOrLogin(ctx context.Context, uid int, sid, spwd string) ([]*http.Cookie, error) {
-	cookies, err := s.sessionService.GetCachedCookies(ctx, uid)
+// ExportCalendar 导出考试安排为 iCalendar
+func (s *examService) ExportCalendar(ctx context.Context, uid int, term string) ([]byte, error) {
+	if term == "" {
+		current, err := s.configCache.GetCurrentTerm(ctx)
+		if err != nil {
+			return nil, common.NewAppError(common.CodeInternalError, err.Error())
+		}
+		term = current
+	}
+
+	exams, err := s.GetAllExams(ctx, uid, term)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildCalendar(term, exams, time.Now()), nil
+}
+
+// GetReminder 获取考试提醒订阅
+func (s *examService) GetReminder(ctx context.Context, uid int) (*ExamReminder, error) {
+	reminder, err := s.repo.FindReminder(ctx, uid)
 	if err != nil {
-		return nil, common.NewAppError(common.CodeCacheError, "缓存错误")
+		return nil, common.NewAppError(common.CodeInternalError, "获取提醒设置失败")
 	}
+	return reminder, nil
+}
 
-	if len(cookies) > 0 {
-		return cookies, nil
+// SetReminder 开启/关闭考试提醒
+func (s *examService) SetReminder(ctx context.Context, uid int, enabled bool) (*ExamReminder, error) {
+	if enabled {
+		user, err := s.userQuery.GetUserByUid(ctx, uid)
+		if err != nil {
+			return nil, common.NewAppError(common.CodeUserNotFound, "用户不存在")
+		}
+		if user.Sid == "" || user.Spwd == "" {
+			return nil, common.NewAppError(common.CodeJwcNotBound, "")
+		}
 	}
 
-	if err := s.sessionService.LoginAndCache(ctx, uid, sid, spwd); err != nil {
+	reminder := &ExamReminder{Uid: uid, Enabled: enabled}
+	if err := s.repo.SaveReminder(ctx, reminder); err != nil {
+		return nil, common.NewAppError(common.CodeInternalError, "保存提醒设置失败")
+	}
+	return s.GetReminder(ctx, uid)
+}
+
+// ListReminderSubscribers 按 uid 分批获取开启提醒的用户
+func (s *examService) ListReminderSubscribers(ctx context.Context, afterUid, limit int) ([]int, error) {
+	return s.repo.FindReminderSubscribers(ctx, afterUid, limit)
+}
+
+// OnJwcUnbind 解除教务绑定时取消考试提醒并清除发送记录
+func (s *examService) OnJwcUnbind(ctx context.Context, uid int) error {
+	return s.repo.DeleteReminder(ctx, uid)
+}
+
+// DueReminders 获取需要提醒的考试：距开考不超过某一档位天数，且该档位（或更近的档位）尚未提醒过
+func (s *examService) DueReminders(ctx context.Context, uid int, now time.Time) ([]UpcomingExam, error) {
+	term, err := s.configCache.GetCurrentTerm(ctx)
+	if err != nil {
+		return nil, common.NewAppError(common.CodeInternalError, err.Error())
+	}
+
+	exams, err := s.GetAllExams(ctx, uid, term)
+	if err != nil {
 		return nil, err
 	}
 
-	cookies, err = s.sessionService.GetCachedCookies(ctx, uid)
-	if err != nil || len(cookies) == 0 {
-		return nil, common.NewAppError(common.CodeJwcLoginFailed, "获取会话失败")
+	maxDays := ReminderDays[len(ReminderDays)-1]
+	var due []UpcomingExam
+	for _, e := range exams {
+		if e.StartTime == nil || !e.StartTime.After(now) {
+			continue
+		}
+		left := e.StartTime.Sub(now)
+		if left > time.Duration(maxDays)*24*time.Hour {
+			continue
+		}
+		for _, days := range ReminderDays {
+			if left <= time.Duration(days)*24*time.Hour {
+				due = append(due, UpcomingExam{ExamArrangement: e, DaysBefore: days, Key: examKey(term, e)})
+				break
+			}
+		}
+	}
+	if len(due) == 0 {
+		return nil, nil
 	}
 
-	return cookies, nil
+	keys := make([]string, 0, len(due))
+	for _, d := range due {
+		keys = append(keys, d.Key)
+	}
+	sent, err := s.repo.FindSentKeys(ctx, uid, keys)
+	if err != nil {
+		return nil, common.NewAppError(common.CodeInternalError, "查询提醒记录失败")
+	}
+
+	res := due[:0]
+	for _, d := range due {
+		reminded := false
+		for days := range sent[d.Key] {
+			if days <= d.DaysBefore {
+				reminded = true
+				break
+			}
+		}
+		if !reminded {
+			res = append(res, d)
+		}
+	}
+	return res, nil
 }
 
+// MarkReminded 记录已发送的提醒
+func (s *examService) MarkReminded(ctx context.Context, uid int, exams []UpcomingExam) error {
+	logs := make([]ExamReminderLog, 0, len(exams))
+	for _, e := range exams {
+		logs = append(logs, ExamReminderLog{Uid: uid, ExamKey: e.Key, DaysBefore: e.DaysBefore})
+	}
+	return s.repo.SaveLogs(ctx, logs)
+}
+
+// examColumns 考试安排必须包含的列
+var examColumns = []string{"课程编号", "课程名称", "考试时间", "考场"}
+
 // parseExamArrangementFromHTML 解析考试安排 HTML
 func (s *examService) parseExamArrangementFromHTML(r io.Reader) ([]ExamArrangement, error) {
 	doc, err := goquery.NewDocumentFromReader(r)
@@ -130,16 +298,17 @@ func (s *examService) parseExamArrangementFromHTML(r io.Reader) ([]ExamArrangeme
 		return nil, common.NewAppError(common.CodeJwcParseFailed, "解析HTML失败")
 	}
 
-	title := strings.TrimSpace(doc.Find("title").Text())
-	if title != "我的考试 - 考试安排查询" {
-		return nil, common.NewAppError(common.CodeJwcParseFailed, "页面错误")
-	}
-
 	table := doc.Find("#dataList")
 	if table.Length() == 0 {
 		return nil, common.NewAppError(common.CodeJwcParseFailed, "未找到考试安排数据")
 	}
 
+	// 按表头定位列（备注列缺失时执行情况为空）
+	cols, err := shared.MapTableColumns(table, examColumns...)
+	if err != nil {
+		return nil, common.NewAppError(common.CodeJwcParseFailed, err.Error())
+	}
+
 	rows := table.Find("tr")
 	if rows.Length() <= 1 {
 		return nil, nil // 只有表头，无数据
@@ -158,7 +327,7 @@ func (s *examService) parseExamArrangementFromHTML(r io.Reader) ([]ExamArrangeme
 		}
 
 		tds := tr.Find("td")
-		if tds.Length() < 9 {
+		if tds.Length() < cols.Width() {
 			return
 		}
 
@@ -169,12 +338,12 @@ func (s *examService) parseExamArrangementFromHTML(r io.Reader) ([]ExamArrangeme
 		}
 
 		exams = append(exams, ExamArrangement{
-			SerialNo:  trim(tds.Eq(0).Text()),
-			ClassNo:   trim(tds.Eq(2).Text()),
-			ClassName: trim(tds.Eq(3).Text()),
-			Time:      trim(tds.Eq(4).Text()),
-			Place:     trim(tds.Eq(5).Text()),
-			Execution: trim(tds.Eq(8).Text()),
+			SerialNo:  trim(cols.Text(tds, "序号")),
+			ClassNo:   trim(cols.Text(tds, "课程编号")),
+			ClassName: trim(cols.Text(tds, "课程名称")),
+			Time:      trim(cols.Text(tds, "考试时间")),
+			Place:     trim(cols.Text(tds, "考场")),
+			Execution: trim(cols.Text(tds, "备注")),
 		})
 	})
 
@@ -0,0 +1,34 @@
+package exam
+
+import (
+	"bytes"
+	"spider-go/internal/common"
+	"spider-go/internal/service/jwctest"
+	"strings"
+	"testing"
+)
+
+func TestParseExamArrangementFromHTML(t *testing.T) {
+	s := &examService{}
+
+	for _, name := range []string{"xsksap_list", "xsksap_list_empty"} {
+		t.Run(name, func(t *testing.T) {
+			exams, err := s.parseExamArrangementFromHTML(bytes.NewReader(jwctest.ReadFixture(t, "testdata/"+name+".html")))
+			if err != nil {
+				t.Fatalf("parseExamArrangementFromHTML: %v", err)
+			}
+			fillExamTimes(exams)
+			jwctest.AssertGolden(t, "testdata/"+name+".golden.json", exams)
+		})
+	}
+}
+
+func TestParseExamArrangementFromHTMLRejectsOtherPages(t *testing.T) {
+	s := &examService{}
+
+	page := `<html><head><title>统一身份认证</title></head><body><input name="execution" value="e1s1"/></body></html>`
+	_, err := s.parseExamArrangementFromHTML(strings.NewReader(page))
+	if appErr, ok := err.(*common.AppError); !ok || appErr.Code != common.CodeJwcParseFailed {
+		t.Errorf("got %v, want CodeJwcParseFailed", err)
+	}
+}
@@ -0,0 +1,204 @@
+package grade
+
+import (
+	"sort"
+	"strconv"
+)
+
+// courseRankSize 最好/最差课程各返回的数量
+const courseRankSize = 5
+
+// analyzeGrades 按学期和课程性质统计成绩
+func analyzeGrades(grades []Grade, policy GPAPolicy) *GradeAnalytics {
+	// distinctGrades 基于 map，结果无序，先按学期和序号排好保证输出稳定
+	distinct := distinctGrades(grades)
+	sort.SliceStable(distinct, func(i, j int) bool {
+		if distinct[i].Term != distinct[j].Term {
+			return distinct[i].Term < distinct[j].Term
+		}
+		a, _ := strconv.Atoi(distinct[i].SerialNo)
+		b, _ := strconv.Atoi(distinct[j].SerialNo)
+		return a < b
+	})
+
+	analytics := &GradeAnalytics{
+		Policy:     policy.Name(),
+		Overall:    policy.Calculate(distinct),
+		Terms:      []TermAnalytics{},
+		Properties: []PropertyAnalytics{},
+	}
+
+	// 按学期分组
+	byTerm := make(map[string][]Grade)
+	for _, g := range distinct {
+		byTerm[g.Term] = append(byTerm[g.Term], g)
+	}
+	terms := make([]string, 0, len(byTerm))
+	for term := range byTerm {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms) // 学期格式 2024-2025-1 可直接按字符串排序
+
+	var cumulative []Grade
+	for _, term := range terms {
+		termGrades := byTerm[term]
+		cumulative = append(cumulative, termGrades...)
+
+		stat := TermAnalytics{
+			Term:          term,
+			GPA:           policy.Calculate(termGrades),
+			CumulativeGPA: policy.Calculate(cumulative),
+			CourseCount:   len(termGrades),
+		}
+		for _, g := range termGrades {
+			if g.Status == 1 {
+				stat.RetakeCount++
+			}
+			switch passed, known := isPassed(g.Score); {
+			case !known:
+			case passed:
+				stat.CreditsEarned += g.Credit
+			default:
+				stat.CreditsFailed += g.Credit
+			}
+		}
+		analytics.RetakeCount += stat.RetakeCount
+		analytics.Terms = append(analytics.Terms, stat)
+	}
+
+	// 按课程统计学分：同一课程多次修读只要通过一次即计为已获得
+	type courseState struct {
+		grade  Grade
+		passed bool
+		known  bool
+	}
+	courses := make(map[string]*courseState)
+	var courseOrder []string
+	for _, g := range distinct {
+		key := g.Code
+		if key == "" {
+			key = g.Subject
+		}
+		passed, known := isPassed(g.Score)
+		st, ok := courses[key]
+		if !ok {
+			courses[key] = &courseState{grade: g, passed: passed, known: known}
+			courseOrder = append(courseOrder, key)
+			continue
+		}
+		st.known = st.known || known
+		if passed && !st.passed {
+			st.grade, st.passed = g, true
+		}
+	}
+
+	byProperty := make(map[string]*PropertyAnalytics)
+	propertyScores := make(map[string][]float64)
+	var propertyOrder []string
+	for _, key := range courseOrder {
+		st := courses[key]
+		if st.known {
+			if st.passed {
+				analytics.CreditsEarned += st.grade.Credit
+			} else {
+				analytics.CreditsFailed += st.grade.Credit
+			}
+		}
+
+		property := st.grade.Property
+		if property == "" {
+			property = "其他"
+		}
+		pa, ok := byProperty[property]
+		if !ok {
+			pa = &PropertyAnalytics{Property: property}
+			byProperty[property] = pa
+			propertyOrder = append(propertyOrder, property)
+		}
+		pa.CourseCount++
+		if st.known {
+			if st.passed {
+				pa.CreditsEarned += st.grade.Credit
+			} else {
+				pa.CreditsFailed += st.grade.Credit
+			}
+		}
+		if score, ok := scoreValue(st.grade.Score); ok {
+			propertyScores[property] = append(propertyScores[property], score)
+		}
+	}
+	for _, property := range propertyOrder {
+		pa := byProperty[property]
+		if scores := propertyScores[property]; len(scores) > 0 {
+			var sum float64
+			for _, v := range scores {
+				sum += v
+			}
+			pa.AverageScore = round3(sum / float64(len(scores)))
+		}
+		analytics.Properties = append(analytics.Properties, *pa)
+	}
+
+	analytics.CreditsEarned = round3(analytics.CreditsEarned)
+	analytics.CreditsFailed = round3(analytics.CreditsFailed)
+	analytics.BestCourses, analytics.WorstCourses = rankCourses(distinct)
+
+	return analytics
+}
+
+// rankCourses 按成绩排序，返回最好和最差的课程（两级制成绩不参与排名）
+func rankCourses(grades []Grade) ([]CourseRank, []CourseRank) {
+	type scored struct {
+		grade Grade
+		score float64
+	}
+	var list []scored
+	for _, g := range grades {
+		if score, ok := scoreValue(g.Score); ok {
+			list = append(list, scored{grade: g, score: score})
+		}
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].score != list[j].score {
+			return list[i].score > list[j].score
+		}
+		return list[i].grade.Credit > list[j].grade.Credit
+	})
+
+	toRank := func(s scored) CourseRank {
+		return CourseRank{
+			Term:    s.grade.Term,
+			Code:    s.grade.Code,
+			Subject: s.grade.Subject,
+			Score:   s.grade.Score,
+			Credit:  s.grade.Credit,
+		}
+	}
+
+	n := courseRankSize
+	if len(list) < n {
+		n = len(list)
+	}
+	best := make([]CourseRank, 0, n)
+	worst := make([]CourseRank, 0, n)
+	for i := 0; i < n; i++ {
+		best = append(best, toRank(list[i]))
+		worst = append(worst, toRank(list[len(list)-1-i]))
+	}
+	return best, worst
+}
+
+// isPassed 判断成绩是否通过，无法识别的成绩（如缓考）返回 known=false
+func isPassed(scoreText string) (passed bool, known bool) {
+	if v, ok := parseNumeric(scoreText); ok {
+		return v >= 60, true
+	}
+	switch scoreText {
+	case "优", "良", "中", "及格", "合格":
+		return true, true
+	case "不及格", "不合格":
+		return false, true
+	}
+	return false, false
+}
@@ -0,0 +1,90 @@
+package grade
+
+import "testing"
+
+// analyticsGrades 两个学期的成绩：高等数学第一学期不及格、第二学期补考通过，另有选修课和缓考
+func analyticsGrades() []Grade {
+	return []Grade{
+		{SerialNo: "1", Term: "2023-2024-1", Code: "MA101", Subject: "高等数学", Score: "50", Credit: 4, Property: "必修"},
+		{SerialNo: "2", Term: "2023-2024-1", Code: "EN101", Subject: "大学英语", Score: "85", Credit: 2, Property: "必修"},
+		{SerialNo: "3", Term: "2023-2024-1", Code: "PE101", Subject: "体育", Score: "良", Credit: 1, Property: "选修"},
+		{SerialNo: "1", Term: "2023-2024-2", Code: "MA101", Subject: "高等数学", Score: "75", Credit: 4, Gpa: 2.5, Status: 1, Property: "必修"},
+		{SerialNo: "2", Term: "2023-2024-2", Code: "CS101", Subject: "程序设计", Score: "90", Credit: 3, Property: "必修"},
+		{SerialNo: "3", Term: "2023-2024-2", Code: "AR201", Subject: "艺术鉴赏", Score: "缓考", Credit: 2, Property: "选修"},
+	}
+}
+
+func TestAnalyzeGradesTerms(t *testing.T) {
+	analytics := analyzeGrades(analyticsGrades(), defaultGPAPolicy)
+
+	// 第一学期：高等数学 0×4，大学英语 3.5×2，体育为选修不计入：7 / 6
+	// 第二学期：补考绩点 2.5 按 1 学分计（学分计 4），程序设计 4.0×3：14.5 / 7
+	// 累计：(7 + 14.5) / 13
+	want := []TermAnalytics{
+		{
+			Term:          "2023-2024-1",
+			GPA:           &GPA{AverageGPA: round3(7.0 / 6), AverageScore: 67.5},
+			CumulativeGPA: &GPA{AverageGPA: round3(7.0 / 6), AverageScore: 67.5},
+			CourseCount:   3,
+			CreditsEarned: 3,
+			CreditsFailed: 4,
+		},
+		{
+			Term:          "2023-2024-2",
+			GPA:           &GPA{AverageGPA: round3(14.5 / 7), AverageScore: 75},
+			CumulativeGPA: &GPA{AverageGPA: round3(21.5 / 13), AverageScore: 71.25},
+			CourseCount:   3,
+			CreditsEarned: 7,
+			RetakeCount:   1,
+		},
+	}
+	if len(analytics.Terms) != len(want) {
+		t.Fatalf("got %d terms, want %d", len(analytics.Terms), len(want))
+	}
+	for i, w := range want {
+		got := analytics.Terms[i]
+		if got.Term != w.Term || got.CourseCount != w.CourseCount || got.CreditsEarned != w.CreditsEarned ||
+			got.CreditsFailed != w.CreditsFailed || got.RetakeCount != w.RetakeCount {
+			t.Errorf("term %d = %+v, want %+v", i, got, w)
+		}
+		if got.GPA.AverageGPA != w.GPA.AverageGPA || got.GPA.AverageScore != w.GPA.AverageScore {
+			t.Errorf("%s GPA = %+v, want %+v", w.Term, got.GPA, w.GPA)
+		}
+		if got.CumulativeGPA.AverageGPA != w.CumulativeGPA.AverageGPA || got.CumulativeGPA.AverageScore != w.CumulativeGPA.AverageScore {
+			t.Errorf("%s cumulative GPA = %+v, want %+v", w.Term, got.CumulativeGPA, w.CumulativeGPA)
+		}
+	}
+	if *analytics.Overall != *analytics.Terms[1].CumulativeGPA {
+		t.Errorf("overall = %+v, want the last cumulative GPA %+v", analytics.Overall, analytics.Terms[1].CumulativeGPA)
+	}
+}
+
+func TestAnalyzeGradesCredits(t *testing.T) {
+	analytics := analyzeGrades(analyticsGrades(), defaultGPAPolicy)
+
+	// 高等数学补考通过后只按 4 学分计一次已获得；缓考既不算获得也不算未通过
+	if analytics.CreditsEarned != 10 || analytics.CreditsFailed != 0 || analytics.RetakeCount != 1 {
+		t.Errorf("credits = earned %v, failed %v, retakes %d; want 10, 0, 1",
+			analytics.CreditsEarned, analytics.CreditsFailed, analytics.RetakeCount)
+	}
+
+	want := []PropertyAnalytics{
+		{Property: "必修", CourseCount: 3, CreditsEarned: 9, AverageScore: round3((75.0 + 85 + 90) / 3)},
+		{Property: "选修", CourseCount: 2, CreditsEarned: 1, AverageScore: 85},
+	}
+	if len(analytics.Properties) != len(want) {
+		t.Fatalf("properties = %+v, want %+v", analytics.Properties, want)
+	}
+	for i, w := range want {
+		if analytics.Properties[i] != w {
+			t.Errorf("property %d = %+v, want %+v", i, analytics.Properties[i], w)
+		}
+	}
+
+	if best := analytics.BestCourses; len(best) != 5 || best[0].Code != "CS101" {
+		t.Errorf("best courses = %+v", best)
+	}
+	if worst := analytics.WorstCourses; len(worst) != 5 || worst[0].Code != "MA101" || worst[0].Score != "50" {
+		t.Errorf("worst courses = %+v", worst)
+	}
+}
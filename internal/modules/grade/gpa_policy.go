@@ -0,0 +1,406 @@
+package grade
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// GPAPolicy 绩点计算策略
+type GPAPolicy interface {
+	// Name 策略标识（用于 policy 查询参数）
+	Name() string
+	// Description 策略说明
+	Description() string
+	// Calculate 计算 GPA
+	Calculate(grades []Grade) *GPA
+}
+
+// AttemptMode 同一课程多次修读（补考/重修）时的取舍方式
+type AttemptMode int
+
+const (
+	AttemptBest  AttemptMode = iota // 取最好成绩
+	AttemptFirst                    // 取首次修读成绩
+	AttemptAll                      // 所有修读均计入
+)
+
+// DefaultGPAPolicyName 默认策略标识
+const DefaultGPAPolicyName = "default"
+
+var (
+	gpaPoliciesMu sync.RWMutex
+	gpaPolicies   = map[string]GPAPolicy{}
+
+	// defaultGPAPolicy 学校默认策略
+	defaultGPAPolicy GPAPolicy = &schoolPolicy{}
+)
+
+func init() {
+	RegisterGPAPolicy(defaultGPAPolicy)
+	RegisterGPAPolicy(NewScalePolicy("standard4", "标准4.0（仅必修，取最好成绩）", standard4Scale, false, AttemptBest))
+	RegisterGPAPolicy(NewScalePolicy("standard4_all", "标准4.0（含选修，取最好成绩）", standard4Scale, true, AttemptBest))
+	RegisterGPAPolicy(NewScalePolicy("standard4_first", "标准4.0（仅必修，取首次修读成绩）", standard4Scale, false, AttemptFirst))
+	RegisterGPAPolicy(NewScalePolicy("wes", "WES 4.0（含选修，所有修读均计入）", wesScale, true, AttemptAll))
+	RegisterGPAPolicy(NewScalePolicy("pku4", "北大4.0（仅必修，取最好成绩）", pku4Scale, false, AttemptBest))
+	RegisterGPAPolicy(NewScalePolicy("pku4_all", "北大4.0（含选修，取最好成绩）", pku4Scale, true, AttemptBest))
+}
+
+// RegisterGPAPolicy 注册绩点计算策略（同名覆盖）
+func RegisterGPAPolicy(policy GPAPolicy) {
+	gpaPoliciesMu.Lock()
+	defer gpaPoliciesMu.Unlock()
+	gpaPolicies[policy.Name()] = policy
+}
+
+// GetGPAPolicy 根据标识获取绩点计算策略
+func GetGPAPolicy(name string) (GPAPolicy, bool) {
+	gpaPoliciesMu.RLock()
+	defer gpaPoliciesMu.RUnlock()
+	policy, ok := gpaPolicies[name]
+	return policy, ok
+}
+
+// ListGPAPolicies 获取所有已注册的策略（按标识排序，默认策略在最前）
+func ListGPAPolicies() []GPAPolicy {
+	gpaPoliciesMu.RLock()
+	defer gpaPoliciesMu.RUnlock()
+
+	policies := make([]GPAPolicy, 0, len(gpaPolicies))
+	for _, p := range gpaPolicies {
+		policies = append(policies, p)
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		if policies[i].Name() == DefaultGPAPolicyName {
+			return true
+		}
+		if policies[j].Name() == DefaultGPAPolicyName {
+			return false
+		}
+		return policies[i].Name() < policies[j].Name()
+	})
+	return policies
+}
+
+// resolveGPAPolicies 解析 policy 参数（逗号分隔，可重复传参），去重并校验
+func resolveGPAPolicies(names []string) ([]GPAPolicy, error) {
+	var policies []GPAPolicy
+	seen := map[string]struct{}{}
+	for _, raw := range names {
+		for _, name := range strings.Split(raw, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+
+			policy, ok := GetGPAPolicy(name)
+			if !ok {
+				return nil, fmt.Errorf("未知的绩点计算策略: %s", name)
+			}
+			policies = append(policies, policy)
+		}
+	}
+	return policies, nil
+}
+
+// schoolPolicy 学校默认策略：仅必修；(分数-50)/10 折算绩点；
+// 正常考试 59.9 分及以上计入，补考/重修及格按 60 分、1 学分绩点计
+type schoolPolicy struct{}
+
+// Name 策略标识
+func (p *schoolPolicy) Name() string {
+	return DefaultGPAPolicyName
+}
+
+// Description 策略说明
+func (p *schoolPolicy) Description() string {
+	return "学校默认算法（仅必修，补考/重修及格按 60 分计）"
+}
+
+// Calculate 计算 GPA
+func (p *schoolPolicy) Calculate(gradeArray []Grade) *GPA {
+	distinct := distinctGrades(gradeArray)
+
+	var (
+		sumScore   float64
+		sumGp      float64
+		sumCredit  float64
+		num2       int
+		sumScore2  float64
+		sumCredit2 float64
+	)
+
+	for _, g := range distinct {
+		if g.Property != "必修" {
+			continue
+		}
+
+		scoreText := g.Score
+
+		// BasicPoint
+		if g.Status == 0 {
+			gradeD := mapGradeToScoreForBasic(scoreText)
+			sumScore2 += gradeD * g.Credit
+			sumCredit2 += g.Credit
+		}
+
+		// GPA & APF
+		numericScore, isNum := parseNumeric(scoreText)
+
+		if isNum && g.Status == 0 && numericScore >= 59.9 {
+			sumScore += numericScore
+			gp := getCourseGp(g, scoreText)
+			sumGp += gp * g.Credit
+			sumCredit += g.Credit
+			num2++
+		} else {
+			if g.Status == 0 && !isNum {
+				gp := getCourseGp(g, scoreText)
+				score := gp*10.0 + 50.0
+				sumScore += score
+				sumGp += gp * g.Credit
+				sumCredit += g.Credit
+				num2++
+			} else {
+				if g.Status == 1 && isNum && numericScore >= 59.9 {
+					sumScore += 60.0
+					gp := getCourseGp(g, scoreText)
+					sumGp += gp * 1.0
+					sumCredit += g.Credit
+					num2++
+				} else if g.Status == 1 && !isNum && (scoreText == "及格" || scoreText == "合格") {
+					gp := getCourseGp(g, scoreText)
+					sumScore += 60.0
+					sumGp += gp * 1.0
+					sumCredit += g.Credit
+					num2++
+				} else if g.Status == 1 && !isNum && (scoreText == "不及格" || scoreText == "不合格") {
+					sumCredit += g.Credit
+					num2++
+				} else if g.Status == 1 && isNum && numericScore <= 59.9 {
+					sumCredit += g.Credit
+					num2++
+				} else {
+					sumCredit += g.Credit
+					num2++
+					if isNum {
+						sumScore += numericScore
+					} else {
+						log.Println("特殊成绩样式:", scoreText)
+					}
+				}
+			}
+		}
+	}
+
+	var gpa, apf, basic float64
+	if sumCredit != 0 {
+		gpa = sumGp / sumCredit
+	}
+	if num2 != 0 {
+		apf = sumScore / float64(num2)
+	}
+	if sumCredit2 != 0 {
+		basic = sumScore2 / sumCredit2
+	}
+
+	if math.IsNaN(gpa) {
+		gpa = 0
+	}
+	if math.IsNaN(apf) {
+		apf = 0
+	}
+	if math.IsNaN(basic) {
+		basic = 0
+	}
+
+	return &GPA{
+		AverageGPA:   round3(gpa),
+		AverageScore: round3(apf),
+		BasicScore:   round3(basic),
+	}
+}
+
+// ScaleFunc 百分制分数到绩点的映射
+type ScaleFunc func(score float64) float64
+
+// scalePolicy 基于分数区间映射的通用策略
+type scalePolicy struct {
+	name             string
+	description      string
+	scale            ScaleFunc
+	includeElectives bool
+	attempt          AttemptMode
+}
+
+// NewScalePolicy 创建基于分数映射的策略
+func NewScalePolicy(name, description string, scale ScaleFunc, includeElectives bool, attempt AttemptMode) GPAPolicy {
+	return &scalePolicy{
+		name:             name,
+		description:      description,
+		scale:            scale,
+		includeElectives: includeElectives,
+		attempt:          attempt,
+	}
+}
+
+// Name 策略标识
+func (p *scalePolicy) Name() string {
+	return p.name
+}
+
+// Description 策略说明
+func (p *scalePolicy) Description() string {
+	return p.description
+}
+
+// Calculate 计算 GPA：绩点和基本分按学分加权，平均分为算术平均；合格/不合格等两级制课程不计入
+func (p *scalePolicy) Calculate(grades []Grade) *GPA {
+	var candidates []Grade
+	for _, g := range distinctGrades(grades) {
+		if !p.includeElectives && g.Property != "必修" {
+			continue
+		}
+		if _, ok := scoreValue(g.Score); !ok {
+			continue
+		}
+		candidates = append(candidates, g)
+	}
+
+	var sumGp, sumCredit, sumScore, sumWeighted float64
+	var num int
+	for _, g := range selectAttempts(candidates, p.attempt) {
+		score, _ := scoreValue(g.Score)
+		sumScore += score
+		num++
+
+		if g.Credit <= 0 {
+			continue
+		}
+		sumGp += p.scale(score) * g.Credit
+		sumWeighted += score * g.Credit
+		sumCredit += g.Credit
+	}
+
+	gpa := &GPA{}
+	if sumCredit != 0 {
+		gpa.AverageGPA = round3(sumGp / sumCredit)
+		gpa.BasicScore = round3(sumWeighted / sumCredit)
+	}
+	if num != 0 {
+		gpa.AverageScore = round3(sumScore / float64(num))
+	}
+	return gpa
+}
+
+// selectAttempts 按课程代码分组，根据取舍方式选出计入的修读记录
+func selectAttempts(grades []Grade, mode AttemptMode) []Grade {
+	if mode == AttemptAll {
+		return grades
+	}
+
+	courseKey := func(g Grade) string {
+		if g.Code != "" {
+			return g.Code
+		}
+		return g.Subject
+	}
+
+	chosen := make(map[string]Grade)
+	var order []string
+	for _, g := range grades {
+		key := courseKey(g)
+		cur, ok := chosen[key]
+		if !ok {
+			chosen[key] = g
+			order = append(order, key)
+			continue
+		}
+
+		switch mode {
+		case AttemptBest:
+			gs, _ := scoreValue(g.Score)
+			cs, _ := scoreValue(cur.Score)
+			if gs > cs {
+				chosen[key] = g
+			}
+		case AttemptFirst:
+			// 正常考试优先，其次学期更早者
+			if (g.Status == 0 && cur.Status != 0) || (g.Status == cur.Status && g.Term < cur.Term) {
+				chosen[key] = g
+			}
+		}
+	}
+
+	res := make([]Grade, 0, len(order))
+	for _, key := range order {
+		res = append(res, chosen[key])
+	}
+	return res
+}
+
+// scoreValue 将成绩转换为百分制分数（五级制按区间中值折算，两级制及其他返回 false）
+func scoreValue(scoreText string) (float64, bool) {
+	if v, ok := parseNumeric(scoreText); ok {
+		return v, true
+	}
+	switch scoreText {
+	case "优":
+		return 95, true
+	case "良":
+		return 85, true
+	case "中":
+		return 75, true
+	case "及格":
+		return 65, true
+	case "不及格":
+		return 50, true
+	}
+	return 0, false
+}
+
+// standard4Scale 标准4.0：90+ 为 4，80+ 为 3，70+ 为 2，60+ 为 1
+func standard4Scale(score float64) float64 {
+	switch {
+	case score >= 90:
+		return 4
+	case score >= 80:
+		return 3
+	case score >= 70:
+		return 2
+	case score >= 60:
+		return 1
+	}
+	return 0
+}
+
+// wesScale WES 中国成绩换算：85+ 为 A(4)，75+ 为 B(3)，60+ 为 C(2)
+func wesScale(score float64) float64 {
+	switch {
+	case score >= 85:
+		return 4
+	case score >= 75:
+		return 3
+	case score >= 60:
+		return 2
+	}
+	return 0
+}
+
+// pku4Scale 北大4.0：GP = 4 - 3(100-x)²/1600，60 分以下为 0
+func pku4Scale(score float64) float64 {
+	if score < 60 {
+		return 0
+	}
+	if score > 100 {
+		score = 100
+	}
+	return 4 - 3*math.Pow(100-score, 2)/1600
+}
@@ -22,8 +22,13 @@ func NewHandler(service Service) *Handler {
 func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 	grades := r.Group("/grades")
 	{
-		grades.GET("", h.GetGrades)            // 获取成绩（可选term参数）
-		grades.GET("/level", h.GetLevelGrades) // 获取等级考试成绩
+		grades.GET("", h.GetGrades)                    // 获取成绩（可选term参数）
+		grades.GET("/level", h.GetLevelGrades)         // 获取等级考试成绩
+		grades.GET("/policies", h.GetPolicies)         // 获取绩点计算策略列表
+		grades.GET("/analytics", h.GetAnalytics)       // 成绩分析
+		grades.POST("/simulate", h.Simulate)           // 绩点模拟
+		grades.GET("/subscription", h.GetSubscription) // 获取新成绩通知订阅
+		grades.PUT("/subscription", h.SetSubscription) // 开启/关闭新成绩通知
 	}
 }
 
@@ -32,6 +37,7 @@ func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 // @Tags Grade
 // @Produce json
 // @Param term query string false "学期" example(2024-2025-1)
+// @Param policy query string false "绩点计算策略，逗号分隔" example(standard4,wes)
 // @Success 200 {object} GradesResponse
 // @Router /grades [get]
 func (h *Handler) GetGrades(c *gin.Context) {
@@ -41,19 +47,19 @@ func (h *Handler) GetGrades(c *gin.Context) {
 		return
 	}
 
-	// 从 query params 获取学期参数
+	// 从 query params 获取学期和绩点策略参数
 	term := c.Query("term")
+	policies := c.QueryArray("policy")
 
-	var grades []Grade
-	var gpa *GPA
+	var resp *GradesResponse
 	var err error
 
 	if term != "" {
 		// 查询指定学期的成绩
-		grades, gpa, err = h.service.GetGradesByTerm(c.Request.Context(), uid.(int), term)
+		resp, err = h.service.GetGradesByTerm(c.Request.Context(), uid.(int), term, policies)
 	} else {
 		// 查询所有成绩
-		grades, gpa, err = h.service.GetAllGrades(c.Request.Context(), uid.(int))
+		resp, err = h.service.GetAllGrades(c.Request.Context(), uid.(int), policies)
 	}
 
 	if err != nil {
@@ -65,10 +71,7 @@ func (h *Handler) GetGrades(c *gin.Context) {
 		return
 	}
 
-	common.Success(c, gin.H{
-		"grades": grades,
-		"gpa":    gpa,
-	})
+	common.Success(c, resp)
 }
 
 // GetLevelGrades 获取等级考试成绩
@@ -96,3 +99,143 @@ func (h *Handler) GetLevelGrades(c *gin.Context) {
 
 	common.Success(c, grades)
 }
+
+// GetPolicies 获取绩点计算策略列表
+// @Summary 获取绩点计算策略列表
+// @Tags Grade
+// @Produce json
+// @Success 200 {array} GPAPolicyInfo
+// @Router /grades/policies [get]
+func (h *Handler) GetPolicies(c *gin.Context) {
+	policies := ListGPAPolicies()
+	infos := make([]GPAPolicyInfo, 0, len(policies))
+	for _, p := range policies {
+		infos = append(infos, GPAPolicyInfo{
+			Name:        p.Name(),
+			Description: p.Description(),
+		})
+	}
+
+	common.Success(c, infos)
+}
+
+// GetAnalytics 成绩分析
+// @Summary 成绩分析
+// @Tags Grade
+// @Produce json
+// @Param policy query string false "绩点计算策略" example(default)
+// @Success 200 {object} GradeAnalytics
+// @Router /grades/analytics [get]
+func (h *Handler) GetAnalytics(c *gin.Context) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		common.Error(c, common.CodeUnauthorized, "未授权")
+		return
+	}
+
+	analytics, err := h.service.GetAnalytics(c.Request.Context(), uid.(int), c.Query("policy"))
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.ErrorWithAppError(c, appErr)
+		} else {
+			common.Error(c, common.CodeInternalError, "获取成绩分析失败")
+		}
+		return
+	}
+
+	common.Success(c, analytics)
+}
+
+// Simulate 绩点模拟
+// @Summary 绩点模拟
+// @Tags Grade
+// @Accept json
+// @Produce json
+// @Param request body SimulateRequest true "模拟请求"
+// @Success 200 {object} SimulateResponse
+// @Router /grades/simulate [post]
+func (h *Handler) Simulate(c *gin.Context) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		common.Error(c, common.CodeUnauthorized, "未授权")
+		return
+	}
+
+	var req SimulateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.Error(c, common.CodeInvalidParams, err.Error())
+		return
+	}
+
+	resp, err := h.service.Simulate(c.Request.Context(), uid.(int), &req)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.ErrorWithAppError(c, appErr)
+		} else {
+			common.Error(c, common.CodeInternalError, "绩点模拟失败")
+		}
+		return
+	}
+
+	common.Success(c, resp)
+}
+
+// GetSubscription 获取新成绩通知订阅
+// @Summary 获取新成绩通知订阅
+// @Tags Grade
+// @Produce json
+// @Success 200 {object} GradeSubscription
+// @Router /grades/subscription [get]
+func (h *Handler) GetSubscription(c *gin.Context) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		common.Error(c, common.CodeUnauthorized, "未授权")
+		return
+	}
+
+	sub, err := h.service.GetSubscription(c.Request.Context(), uid.(int))
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.ErrorWithAppError(c, appErr)
+		} else {
+			common.Error(c, common.CodeInternalError, "获取订阅状态失败")
+		}
+		return
+	}
+
+	common.Success(c, sub)
+}
+
+// SetSubscription 开启/关闭新成绩通知
+// @Summary 开启/关闭新成绩通知
+// @Tags Grade
+// @Accept json
+// @Produce json
+// @Param request body SubscriptionRequest true "订阅设置"
+// @Success 200 {object} GradeSubscription
+// @Router /grades/subscription [put]
+func (h *Handler) SetSubscription(c *gin.Context) {
+	uid, ok := c.Get("uid")
+	if !ok {
+		common.Error(c, common.CodeUnauthorized, "未授权")
+		return
+	}
+
+	var req SubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.Error(c, common.CodeInvalidParams, err.Error())
+		return
+	}
+
+	sub, err := h.service.SetSubscription(c.Request.Context(), uid.(int), req.Enabled)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.ErrorWithAppError(c, appErr)
+		} else {
+			common.Error(c, common.CodeInternalError, "保存订阅状态失败")
+		}
+		return
+	}
+
+	common.Success(c, sub)
+}
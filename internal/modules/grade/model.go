@@ -1,5 +1,7 @@
 package grade
 
+import "time"
+
 // Grade 成绩信息
 type Grade struct {
 	SerialNo string  `json:"serialNo"` // 序号
@@ -35,6 +37,159 @@ type GetGradesRequest struct {
 
 // GradesResponse 成绩响应
 type GradesResponse struct {
-	Grades []Grade `json:"grades"`
-	GPA    *GPA    `json:"gpa"`
+	Grades    []Grade         `json:"grades"`
+	GPA       *GPA            `json:"gpa"`                  // 学校默认策略计算的 GPA
+	GPAs      map[string]*GPA `json:"gpas,omitempty"`       // policy 参数指定的各策略 GPA
+	Stale     bool            `json:"stale"`                // 是否为教务系统不可用时返回的历史数据
+	FetchedAt *time.Time      `json:"fetched_at,omitempty"` // 数据抓取时间
+}
+
+// GPAPolicyInfo 绩点计算策略信息
+type GPAPolicyInfo struct {
+	Name        string `json:"name"`        // 策略标识
+	Description string `json:"description"` // 策略说明
+}
+
+// GradeRecord 成绩历史记录（每次成功抓取后更新）
+type GradeRecord struct {
+	ID        uint      `gorm:"primaryKey"`
+	Uid       int       `gorm:"not null;uniqueIndex:idx_grade_record_course,priority:1"`
+	SerialNo  string    `gorm:"type:varchar(16);not null"` // 页面行号，仅用于排序
+	Code      string    `gorm:"type:varchar(32);not null;uniqueIndex:idx_grade_record_course,priority:2"`
+	Term      string    `gorm:"type:varchar(16);not null;uniqueIndex:idx_grade_record_course,priority:3"`
+	Subject   string    `gorm:"type:varchar(128);not null"`
+	Score     string    `gorm:"type:varchar(32);not null"`
+	Credit    float64   `gorm:"not null;default:0"`
+	Gpa       float64   `gorm:"not null;default:0"`
+	Status    int       `gorm:"not null;default:0;uniqueIndex:idx_grade_record_course,priority:4"` // 0 正常考试/重修，1 补考等，同一学期的补考单独保留
+	Property  string    `gorm:"type:varchar(32);not null"`
+	FetchedAt time.Time `gorm:"not null"` // 最后一次抓取时间
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName 指定表名
+func (GradeRecord) TableName() string {
+	return "grade_records"
+}
+
+// ToGrade 转换为成绩信息
+func (r *GradeRecord) ToGrade() Grade {
+	return Grade{
+		SerialNo: r.SerialNo,
+		Term:     r.Term,
+		Code:     r.Code,
+		Subject:  r.Subject,
+		Score:    r.Score,
+		Credit:   r.Credit,
+		Gpa:      r.Gpa,
+		Status:   r.Status,
+		Property: r.Property,
+	}
+}
+
+// GradeSubscription 新成绩通知订阅
+type GradeSubscription struct {
+	Uid       int       `gorm:"primaryKey;autoIncrement:false" json:"uid"`
+	Enabled   bool      `gorm:"not null;default:false;index" json:"enabled"` // 是否开启通知
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (GradeSubscription) TableName() string {
+	return "grade_subscriptions"
+}
+
+// GradeSnapshot 成绩快照（用于比对新出成绩）
+type GradeSnapshot struct {
+	Uid       int    `gorm:"primaryKey;autoIncrement:false"`
+	Data      string `gorm:"type:mediumtext;not null"` // []Grade 的 JSON
+	UpdatedAt time.Time
+}
+
+// TableName 指定表名
+func (GradeSnapshot) TableName() string {
+	return "grade_snapshots"
+}
+
+// SubscriptionRequest 新成绩通知订阅请求
+type SubscriptionRequest struct {
+	Enabled bool `json:"enabled"` // 是否开启
+}
+
+// SimulatedCourse 模拟课程（code 与已有成绩匹配时覆盖，否则新增）
+type SimulatedCourse struct {
+	Code     string  `json:"code"`                   // 课程代码（匹配已有成绩）
+	Term     string  `json:"term"`                   // 学期（可选，指定时只覆盖该学期的修读记录）
+	Subject  string  `json:"subject"`                // 课程名称（新增课程时使用）
+	Credit   float64 `json:"credit" binding:"gte=0"` // 学分（新增课程必填；覆盖时为 0 表示沿用原学分）
+	Property string  `json:"property"`               // 课程性质（新增课程默认必修）
+	Score    string  `json:"score"`                  // 假设分数；为空表示待求解的剩余课程
+}
+
+// SimulateRequest 绩点模拟请求
+type SimulateRequest struct {
+	Policy    string            `json:"policy"`                              // 绩点计算策略（默认学校算法）
+	Courses   []SimulatedCourse `json:"courses" binding:"dive"`              // 假设的课程成绩
+	TargetGPA *float64          `json:"target_gpa" binding:"omitempty,gt=0"` // 目标绩点（可选）
+}
+
+// SimulateTarget 目标绩点求解结果
+type SimulateTarget struct {
+	TargetGPA     float64 `json:"target_gpa"`     // 目标绩点
+	Reachable     bool    `json:"reachable"`      // 剩余课程满分时能否达到
+	RequiredScore float64 `json:"required_score"` // 剩余课程需要的最低统一分数
+	ResultGPA     *GPA    `json:"result_gpa"`     // 剩余课程取该分数时的 GPA
+}
+
+// SimulateResponse 绩点模拟响应
+type SimulateResponse struct {
+	Policy    string          `json:"policy"`           // 使用的策略
+	Current   *GPA            `json:"current"`          // 当前 GPA
+	Simulated *GPA            `json:"simulated"`        // 按假设成绩计算的 GPA（不含未填分数的课程）
+	Target    *SimulateTarget `json:"target,omitempty"` // 目标求解结果
+}
+
+// GradeAnalytics 成绩分析
+type GradeAnalytics struct {
+	Policy        string              `json:"policy"`         // 使用的绩点策略
+	Overall       *GPA                `json:"overall"`        // 总体 GPA
+	CreditsEarned float64             `json:"credits_earned"` // 已获得学分（同一课程只计一次）
+	CreditsFailed float64             `json:"credits_failed"` // 尚未通过课程的学分
+	RetakeCount   int                 `json:"retake_count"`   // 补考/重修次数
+	Terms         []TermAnalytics     `json:"terms"`          // 按学期统计（按学期升序）
+	Properties    []PropertyAnalytics `json:"properties"`     // 按课程性质统计
+	BestCourses   []CourseRank        `json:"best_courses"`   // 成绩最好的课程
+	WorstCourses  []CourseRank        `json:"worst_courses"`  // 成绩最差的课程
+	Stale         bool                `json:"stale"`          // 是否基于历史数据
+}
+
+// TermAnalytics 学期统计
+type TermAnalytics struct {
+	Term          string  `json:"term"`           // 学期
+	GPA           *GPA    `json:"gpa"`            // 本学期 GPA
+	CumulativeGPA *GPA    `json:"cumulative_gpa"` // 截至本学期的累计 GPA
+	CourseCount   int     `json:"course_count"`   // 课程数
+	CreditsEarned float64 `json:"credits_earned"` // 通过的学分
+	CreditsFailed float64 `json:"credits_failed"` // 未通过的学分
+	RetakeCount   int     `json:"retake_count"`   // 补考/重修次数
+}
+
+// PropertyAnalytics 课程性质统计
+type PropertyAnalytics struct {
+	Property      string  `json:"property"`       // 课程性质：必修/选修/...
+	CourseCount   int     `json:"course_count"`   // 课程数
+	CreditsEarned float64 `json:"credits_earned"` // 通过的学分
+	CreditsFailed float64 `json:"credits_failed"` // 未通过的学分
+	AverageScore  float64 `json:"average_score"`  // 平均分（五级制按中值折算，两级制不计入）
+}
+
+// CourseRank 课程排名项
+type CourseRank struct {
+	Term    string  `json:"term"`    // 学期
+	Code    string  `json:"code"`    // 课程代码
+	Subject string  `json:"subject"` // 课程名称
+	Score   string  `json:"score"`   // 成绩
+	Credit  float64 `json:"credit"`  // 学分
 }
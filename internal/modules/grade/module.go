@@ -6,6 +6,7 @@ import (
 	"spider-go/internal/shared"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // Module 成绩模块
@@ -16,15 +17,17 @@ type Module struct {
 
 // NewModule 创建成绩模块
 func NewModule(
+	db *gorm.DB,
 	userQuery shared.UserQuery,
-	sessionService service.SessionService,
 	crawlerService service.CrawlerService,
+	parseMonitor service.ParseMonitor,
 	userDataCache cache.UserDataCache,
 	gradeURL string,
 	gradeLevelURL string,
 ) *Module {
-	// 初始化各层：service -> handler
-	svc := NewService(userQuery, sessionService, crawlerService, userDataCache, gradeURL, gradeLevelURL)
+	// 初始化各层：repository -> service -> handler
+	repo := NewRepository(db)
+	svc := NewService(repo, userQuery, crawlerService, parseMonitor, userDataCache, gradeURL, gradeLevelURL)
 	handler := NewHandler(svc)
 
 	return &Module{
@@ -0,0 +1,168 @@
+package grade
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrSnapshotNotFound = errors.New("grade snapshot not found")
+)
+
+// Repository 成绩数据访问接口
+type Repository interface {
+	FindSubscription(ctx context.Context, uid int) (*GradeSubscription, error)
+	SaveSubscription(ctx context.Context, sub *GradeSubscription) error
+	FindSubscribers(ctx context.Context, afterUid, limit int) ([]int, error)
+	FindSnapshot(ctx context.Context, uid int) (*GradeSnapshot, error)
+	SaveSnapshot(ctx context.Context, snapshot *GradeSnapshot) error
+	UpsertGrades(ctx context.Context, uid int, grades []Grade, fetchedAt time.Time) error
+	FindGrades(ctx context.Context, uid int, term string) ([]GradeRecord, error)
+	DeleteUserData(ctx context.Context, uid int) error
+}
+
+// repository 成绩数据访问实现
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository 创建成绩数据访问层
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// FindSubscription 查找新成绩通知订阅（不存在时返回未开启的订阅）
+func (r *repository) FindSubscription(ctx context.Context, uid int) (*GradeSubscription, error) {
+	var sub GradeSubscription
+	if err := r.db.WithContext(ctx).First(&sub, uid).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &GradeSubscription{Uid: uid}, nil
+		}
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// SaveSubscription 保存新成绩通知订阅（存在则更新）
+func (r *repository) SaveSubscription(ctx context.Context, sub *GradeSubscription) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "uid"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(sub).Error
+}
+
+// FindSubscribers 按 uid 分批查询开启通知的用户
+func (r *repository) FindSubscribers(ctx context.Context, afterUid, limit int) ([]int, error) {
+	var uids []int
+	err := r.db.WithContext(ctx).Model(&GradeSubscription{}).
+		Where("enabled = ? AND uid > ?", true, afterUid).
+		Order("uid ASC").
+		Limit(limit).
+		Pluck("uid", &uids).Error
+	return uids, err
+}
+
+// FindSnapshot 查找成绩快照
+func (r *repository) FindSnapshot(ctx context.Context, uid int) (*GradeSnapshot, error) {
+	var snapshot GradeSnapshot
+	if err := r.db.WithContext(ctx).First(&snapshot, uid).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSnapshotNotFound
+		}
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// SaveSnapshot 保存成绩快照（存在则覆盖）
+func (r *repository) SaveSnapshot(ctx context.Context, snapshot *GradeSnapshot) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "uid"}},
+		DoUpdates: clause.AssignmentColumns([]string{"data", "updated_at"}),
+	}).Create(snapshot).Error
+}
+
+// UpsertGrades 批量保存成绩（按 uid + recordKey 去重更新）
+func (r *repository) UpsertGrades(ctx context.Context, uid int, grades []Grade, fetchedAt time.Time) error {
+	if len(grades) == 0 {
+		return nil
+	}
+
+	records := make([]GradeRecord, 0, len(grades))
+	seen := make(map[string]struct{}, len(grades))
+	for _, g := range grades {
+		// 同一批次内重复的键会导致 upsert 冲突，保留第一条
+		key := recordKey(g)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		records = append(records, GradeRecord{
+			Uid:       uid,
+			SerialNo:  g.SerialNo,
+			Code:      g.Code,
+			Term:      g.Term,
+			Subject:   g.Subject,
+			Score:     g.Score,
+			Credit:    g.Credit,
+			Gpa:       g.Gpa,
+			Status:    g.Status,
+			Property:  g.Property,
+			FetchedAt: fetchedAt,
+		})
+	}
+
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "uid"}, {Name: "code"}, {Name: "term"}, {Name: "status"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"serial_no", "subject", "score", "credit", "gpa", "property", "fetched_at", "updated_at",
+		}),
+	}).CreateInBatches(records, 100).Error
+}
+
+// FindGrades 查询历史成绩（term 为空表示全部学期）
+func (r *repository) FindGrades(ctx context.Context, uid int, term string) ([]GradeRecord, error) {
+	var records []GradeRecord
+	query := r.db.WithContext(ctx).Where("uid = ?", uid)
+	if term != "" {
+		query = query.Where("term = ?", term)
+	}
+	err := query.Order("term ASC, id ASC").Find(&records).Error
+	return records, err
+}
+
+// DeleteUserData 在同一事务中删除用户的成绩历史、成绩快照和新成绩通知订阅
+func (r *repository) DeleteUserData(ctx context.Context, uid int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&GradeRecord{}, &GradeSnapshot{}, &GradeSubscription{}} {
+			if err := tx.Where("uid = ?", uid).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// legacyRecordIndex 旧版成绩历史唯一索引（包含序号，同一课程在不同查询中会重复写入）
+const legacyRecordIndex = "idx_grade_record_key"
+
+// MigrateGradeRecords 迁移成绩历史表的唯一键（在 AutoMigrate 之前调用）
+// 删除包含序号的旧索引，并按 uid + 课程编号 + 学期 + 考试性质 去重，保留最近写入的一条
+func MigrateGradeRecords(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&GradeRecord{}) || !migrator.HasIndex(&GradeRecord{}, legacyRecordIndex) {
+		return nil
+	}
+
+	if err := migrator.DropIndex(&GradeRecord{}, legacyRecordIndex); err != nil {
+		return err
+	}
+	return db.Exec(`DELETE FROM grade_records WHERE id NOT IN (
+		SELECT id FROM (SELECT MAX(id) AS id FROM grade_records GROUP BY uid, code, term, status) AS latest
+	)`).Error
+}
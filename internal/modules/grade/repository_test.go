@@ -0,0 +1,147 @@
+package grade
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestRepository 基于内存 SQLite 的成绩数据访问层（测试用）
+func newTestRepository(t *testing.T) (Repository, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&GradeRecord{}, &GradeSnapshot{}, &GradeSubscription{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return NewRepository(db), db
+}
+
+func TestUpsertGradesKeysByCourseNotSerialNo(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	ctx := context.Background()
+	fetchedAt := time.Now()
+
+	// 按学期查询时序号为 1
+	first := []Grade{{SerialNo: "1", Term: "2024-2025-1", Code: "B0001", Subject: "高等数学", Score: "85", Credit: 4, Gpa: 3.5}}
+	if err := repo.UpsertGrades(ctx, 1, first, fetchedAt); err != nil {
+		t.Fatalf("UpsertGrades: %v", err)
+	}
+
+	// 查询全部学期时同一课程序号变为 5，同一学期的补考单独保留
+	second := []Grade{
+		{SerialNo: "5", Term: "2024-2025-1", Code: "B0001", Subject: "高等数学", Score: "88", Credit: 4, Gpa: 3.8},
+		{SerialNo: "6", Term: "2024-2025-1", Code: "B0001", Subject: "高等数学", Score: "60", Credit: 4, Gpa: 1, Status: 1},
+	}
+	if err := repo.UpsertGrades(ctx, 1, second, fetchedAt.Add(time.Hour)); err != nil {
+		t.Fatalf("UpsertGrades: %v", err)
+	}
+
+	records, err := repo.FindGrades(ctx, 1, "")
+	if err != nil {
+		t.Fatalf("FindGrades: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2: %+v", len(records), records)
+	}
+	if r := records[0]; r.SerialNo != "5" || r.Score != "88" || r.Status != 0 {
+		t.Errorf("normal exam record = %+v, want updated to serial 5, score 88", r)
+	}
+	if r := records[1]; r.SerialNo != "6" || r.Status != 1 {
+		t.Errorf("makeup exam record = %+v", r)
+	}
+}
+
+func TestMigrateGradeRecordsDropsLegacyKey(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	// 旧版表结构：唯一键包含序号，同一课程存在多行
+	if err := db.Exec(`CREATE TABLE grade_records (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, uid INTEGER NOT NULL, serial_no TEXT NOT NULL, code TEXT NOT NULL,
+		term TEXT NOT NULL, subject TEXT NOT NULL, score TEXT NOT NULL, credit REAL NOT NULL DEFAULT 0,
+		gpa REAL NOT NULL DEFAULT 0, status INTEGER NOT NULL DEFAULT 0, property TEXT NOT NULL DEFAULT '',
+		fetched_at DATETIME, created_at DATETIME, updated_at DATETIME)`).Error; err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	if err := db.Exec(`CREATE UNIQUE INDEX idx_grade_record_key ON grade_records (uid, serial_no, code, term)`).Error; err != nil {
+		t.Fatalf("create index: %v", err)
+	}
+	if err := db.Exec(`INSERT INTO grade_records (uid, serial_no, code, term, subject, score) VALUES
+		(1, '1', 'B0001', '2024-2025-1', '高等数学', '85'),
+		(1, '5', 'B0001', '2024-2025-1', '高等数学', '88')`).Error; err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	if err := MigrateGradeRecords(db); err != nil {
+		t.Fatalf("MigrateGradeRecords: %v", err)
+	}
+	if err := db.AutoMigrate(&GradeRecord{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	if db.Migrator().HasIndex(&GradeRecord{}, legacyRecordIndex) {
+		t.Error("legacy index not dropped")
+	}
+	records, err := NewRepository(db).FindGrades(context.Background(), 1, "")
+	if err != nil {
+		t.Fatalf("FindGrades: %v", err)
+	}
+	if len(records) != 1 || records[0].Score != "88" {
+		t.Fatalf("records = %+v, want the latest row only", records)
+	}
+}
+
+func TestDeleteUserData(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	ctx := context.Background()
+
+	for _, uid := range []int{1, 2} {
+		grades := []Grade{{SerialNo: "1", Term: "2024-2025-1", Code: "B0001", Subject: "高等数学", Score: "85", Credit: 4}}
+		if err := repo.UpsertGrades(ctx, uid, grades, time.Now()); err != nil {
+			t.Fatalf("UpsertGrades: %v", err)
+		}
+		if err := repo.SaveSnapshot(ctx, &GradeSnapshot{Uid: uid, Data: "[]"}); err != nil {
+			t.Fatalf("SaveSnapshot: %v", err)
+		}
+		if err := repo.SaveSubscription(ctx, &GradeSubscription{Uid: uid, Enabled: true}); err != nil {
+			t.Fatalf("SaveSubscription: %v", err)
+		}
+	}
+
+	if err := repo.DeleteUserData(ctx, 1); err != nil {
+		t.Fatalf("DeleteUserData: %v", err)
+	}
+	// 重复调用不报错
+	if err := repo.DeleteUserData(ctx, 1); err != nil {
+		t.Fatalf("DeleteUserData again: %v", err)
+	}
+
+	if records, _ := repo.FindGrades(ctx, 1, ""); len(records) != 0 {
+		t.Errorf("uid 1 still has %d grade records", len(records))
+	}
+	if _, err := repo.FindSnapshot(ctx, 1); err != ErrSnapshotNotFound {
+		t.Errorf("uid 1 snapshot: %v, want ErrSnapshotNotFound", err)
+	}
+	if sub, err := repo.FindSubscription(ctx, 1); err != nil || sub.Enabled {
+		t.Errorf("uid 1 subscription = %+v, %v, want disabled", sub, err)
+	}
+	if uids, _ := repo.FindSubscribers(ctx, 0, 10); len(uids) != 1 || uids[0] != 2 {
+		t.Errorf("subscribers = %v, want [2]", uids)
+	}
+
+	// 其他用户的数据不受影响
+	if records, _ := repo.FindGrades(ctx, 2, ""); len(records) != 1 {
+		t.Errorf("uid 2 has %d grade records, want 1", len(records))
+	}
+	if _, err := repo.FindSnapshot(ctx, 2); err != nil {
+		t.Errorf("uid 2 snapshot: %v", err)
+	}
+}
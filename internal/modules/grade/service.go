@@ -1,11 +1,13 @@
 package grade
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"io"
 	"log"
 	"math"
-	"net/http"
 	"net/url"
 	"regexp"
 	"spider-go/internal/cache"
@@ -21,16 +23,36 @@ import (
 
 // Service 成绩服务接口
 type Service interface {
-	GetAllGrades(ctx context.Context, uid int) ([]Grade, *GPA, error)
-	GetGradesByTerm(ctx context.Context, uid int, term string) ([]Grade, *GPA, error)
+	// GetAllGrades 获取所有成绩，policies 为额外计算的绩点策略标识
+	GetAllGrades(ctx context.Context, uid int, policies []string) (*GradesResponse, error)
+	// GetGradesByTerm 根据学期获取成绩，policies 为额外计算的绩点策略标识
+	GetGradesByTerm(ctx context.Context, uid int, term string, policies []string) (*GradesResponse, error)
 	GetLevelGrades(ctx context.Context, uid int) ([]LevelGrade, error)
+	// GetAnalytics 成绩分析：按学期/课程性质统计 GPA、学分和补考重修情况
+	GetAnalytics(ctx context.Context, uid int, policy string) (*GradeAnalytics, error)
+	// Simulate 绩点模拟：按假设成绩重新计算 GPA，并可求解达到目标绩点所需的分数
+	Simulate(ctx context.Context, uid int, req *SimulateRequest) (*SimulateResponse, error)
+
+	// GetSubscription 获取新成绩通知订阅
+	GetSubscription(ctx context.Context, uid int) (*GradeSubscription, error)
+	// SetSubscription 开启/关闭新成绩通知
+	SetSubscription(ctx context.Context, uid int, enabled bool) (*GradeSubscription, error)
+	// ListSubscribers 按 uid 分批获取开启通知的用户（供定时任务使用）
+	ListSubscribers(ctx context.Context, afterUid, limit int) ([]int, error)
+	// CheckNewGrades 重新抓取成绩并与上次快照比对，返回新出的成绩（首次抓取只建立快照）
+	CheckNewGrades(ctx context.Context, uid int) ([]Grade, error)
+	// Prewarm 重新抓取全部成绩并写入缓存（供数据预热任务使用）
+	Prewarm(ctx context.Context, uid int) error
+	// OnJwcUnbind 解除教务绑定时删除成绩历史、成绩快照并取消新成绩通知
+	OnJwcUnbind(ctx context.Context, uid int) error
 }
 
 // gradeService 成绩服务实现
 type gradeService struct {
+	repo           Repository
 	userQuery      shared.UserQuery
-	sessionService service.SessionService
 	crawlerService service.CrawlerService
+	parseMonitor   service.ParseMonitor
 	userDataCache  cache.UserDataCache
 	gradeURL       string
 	gradeLevelURL  string
@@ -38,17 +60,19 @@ type gradeService struct {
 
 // NewService 创建成绩服务
 func NewService(
+	repo Repository,
 	userQuery shared.UserQuery,
-	sessionService service.SessionService,
 	crawlerService service.CrawlerService,
+	parseMonitor service.ParseMonitor,
 	userDataCache cache.UserDataCache,
 	gradeURL string,
 	gradeLevelURL string,
 ) Service {
 	return &gradeService{
+		repo:           repo,
 		userQuery:      userQuery,
-		sessionService: sessionService,
 		crawlerService: crawlerService,
+		parseMonitor:   parseMonitor,
 		userDataCache:  userDataCache,
 		gradeURL:       gradeURL,
 		gradeLevelURL:  gradeLevelURL,
@@ -56,136 +80,246 @@ func NewService(
 }
 
 // GetAllGrades 获取所有成绩
-func (s *gradeService) GetAllGrades(ctx context.Context, uid int) ([]Grade, *GPA, error) {
+func (s *gradeService) GetAllGrades(ctx context.Context, uid int, policies []string) (*GradesResponse, error) {
+	return s.getGrades(ctx, uid, "", policies)
+}
+
+// GetGradesByTerm 根据学期获取成绩
+func (s *gradeService) GetGradesByTerm(ctx context.Context, uid int, term string, policies []string) (*GradesResponse, error) {
+	// 校验参数
+	re := regexp.MustCompile(`^\d{4}-\d{4}-[12]$`)
+	if !re.MatchString(term) {
+		return nil, common.NewAppError(common.CodeJwcInvalidParams, "学期格式错误")
+	}
+
+	return s.getGrades(ctx, uid, term, policies)
+}
+
+// getGrades 获取成绩（term 为空表示全部学期）
+// 优先读缓存，其次抓取教务系统；抓取失败时返回数据库中最后一次成功抓取的成绩并标记 stale
+func (s *gradeService) getGrades(ctx context.Context, uid int, term string, policyNames []string) (*GradesResponse, error) {
+	// 校验策略
+	policies, err := resolveGPAPolicies(policyNames)
+	if err != nil {
+		return nil, common.NewAppError(common.CodeInvalidParams, err.Error())
+	}
+
 	// 获取用户信息
 	user, err := s.userQuery.GetUserByUid(ctx, uid)
 	if err != nil {
-		return nil, nil, common.NewAppError(common.CodeUserNotFound, "用户不存在")
+		return nil, common.NewAppError(common.CodeUserNotFound, "用户不存在")
 	}
 
 	if user.Sid == "" || user.Spwd == "" {
-		return nil, nil, common.NewAppError(common.CodeJwcNotBound, "")
+		return nil, common.NewAppError(common.CodeJwcNotBound, "")
 	}
 
 	// 先查询缓存
-	type GradeData struct {
-		Grades []Grade `json:"grades"`
-		GPA    *GPA    `json:"gpa"`
-	}
-	var cachedData GradeData
-	if err := s.userDataCache.GetGrades(ctx, uid, "", &cachedData); err == nil {
-		return cachedData.Grades, cachedData.GPA, nil
+	var cachedData GradesResponse
+	if err := s.userDataCache.GetGrades(ctx, uid, term, &cachedData); err == nil {
+		return withPolicies(&cachedData, policies), nil
 	}
 
-	// 获取会话
-	cookies, err := s.getCookiesOrLogin(ctx, uid, user.Sid, user.Spwd)
+	// 从教务系统抓取
+	data, err := s.refreshGrades(ctx, uid, user.Sid, user.Spwd, term)
 	if err != nil {
-		return nil, nil, err
+		if stale := s.loadPersistedGrades(ctx, uid, term); stale != nil {
+			log.Printf("抓取成绩失败，返回历史数据 (uid=%d): %v", uid, err)
+			return withPolicies(stale, policies), nil
+		}
+		return nil, err
 	}
 
-	// 构造请求
-	form := url.Values{}
-	form.Set("kksj", "")
-	form.Set("kcxz", "")
-	form.Set("kcmc", "")
-	form.Set("xsfs", "all")
+	return withPolicies(data, policies), nil
+}
 
-	// 发起请求
-	body, err := s.crawlerService.FetchWithCookies(ctx, "POST", s.gradeURL, cookies, form)
+// refreshGrades 从教务系统抓取成绩并写入缓存（1小时过期）
+func (s *gradeService) refreshGrades(ctx context.Context, uid int, sid, spwd, term string) (*GradesResponse, error) {
+	gradeList, err := s.fetchGrades(ctx, uid, sid, spwd, term)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	defer body.Close()
 
-	// 解析成绩
-	gradeList, err := s.parseGradesFromHTML(body)
+	now := time.Now()
+	data := &GradesResponse{
+		Grades:    gradeList,
+		GPA:       s.calculateGPA(gradeList),
+		FetchedAt: &now,
+	}
+	_ = s.userDataCache.CacheGrades(ctx, uid, term, data, time.Hour)
+
+	return data, nil
+}
+
+// Prewarm 重新抓取全部学期的成绩并写入缓存（忽略已有缓存）
+func (s *gradeService) Prewarm(ctx context.Context, uid int) error {
+	user, err := s.userQuery.GetUserByUid(ctx, uid)
 	if err != nil {
-		return nil, nil, err
+		return common.NewAppError(common.CodeUserNotFound, "用户不存在")
 	}
 
-	// 计算 GPA
-	gpa := s.calculateGPA(gradeList)
+	if user.Sid == "" || user.Spwd == "" {
+		return common.NewAppError(common.CodeJwcNotBound, "")
+	}
 
-	// 写入缓存（1小时过期）
-	data := GradeData{
-		Grades: gradeList,
-		GPA:    gpa,
+	_, err = s.refreshGrades(ctx, uid, user.Sid, user.Spwd, "")
+	return err
+}
+
+// withPolicies 返回附带各策略 GPA 的响应副本（不修改缓存中的数据）
+func withPolicies(resp *GradesResponse, policies []GPAPolicy) *GradesResponse {
+	if len(policies) == 0 {
+		return resp
 	}
-	_ = s.userDataCache.CacheGrades(ctx, uid, "", data, time.Hour)
 
-	return gradeList, gpa, nil
+	res := *resp
+	res.GPAs = make(map[string]*GPA, len(policies))
+	for _, p := range policies {
+		res.GPAs[p.Name()] = p.Calculate(resp.Grades)
+	}
+	return &res
 }
 
-// GetGradesByTerm 根据学期获取成绩
-func (s *gradeService) GetGradesByTerm(ctx context.Context, uid int, term string) ([]Grade, *GPA, error) {
-	// 校验参数
-	re := regexp.MustCompile(`^\d{4}-\d{4}-[12]$`)
-	if !re.MatchString(term) {
-		return nil, nil, common.NewAppError(common.CodeJwcInvalidParams, "学期格式错误")
+// loadPersistedGrades 读取数据库中的历史成绩（没有数据时返回 nil）
+func (s *gradeService) loadPersistedGrades(ctx context.Context, uid int, term string) *GradesResponse {
+	records, err := s.repo.FindGrades(ctx, uid, term)
+	if err != nil || len(records) == 0 {
+		return nil
+	}
+
+	gradeList := make([]Grade, 0, len(records))
+	fetchedAt := records[0].FetchedAt
+	for _, r := range records {
+		gradeList = append(gradeList, r.ToGrade())
+		// 取最早的抓取时间，保证返回的数据至少和该时间一样新
+		if r.FetchedAt.Before(fetchedAt) {
+			fetchedAt = r.FetchedAt
+		}
 	}
 
+	return &GradesResponse{
+		Grades:    gradeList,
+		GPA:       s.calculateGPA(gradeList),
+		Stale:     true,
+		FetchedAt: &fetchedAt,
+	}
+}
+
+// GetLevelGrades 获取等级考试成绩
+func (s *gradeService) GetLevelGrades(ctx context.Context, uid int) ([]LevelGrade, error) {
 	// 获取用户信息
 	user, err := s.userQuery.GetUserByUid(ctx, uid)
 	if err != nil {
-		return nil, nil, common.NewAppError(common.CodeUserNotFound, "用户不存在")
+		return nil, common.NewAppError(common.CodeUserNotFound, "用户不存在")
 	}
 
 	if user.Sid == "" || user.Spwd == "" {
-		return nil, nil, common.NewAppError(common.CodeJwcNotBound, "")
+		return nil, common.NewAppError(common.CodeJwcNotBound, "")
 	}
 
-	// 先查询缓存
-	type GradeData struct {
-		Grades []Grade `json:"grades"`
-		GPA    *GPA    `json:"gpa"`
+	// 发起请求（会话过期时自动重新登录）
+	account := &service.JwcAccount{Uid: uid, Username: user.Sid, Password: user.Spwd}
+	body, err := s.crawlerService.FetchWithSession(ctx, account, "GET", s.gradeLevelURL, nil)
+	if err != nil {
+		return nil, err
 	}
-	var cachedData GradeData
-	if err := s.userDataCache.GetGrades(ctx, uid, term, &cachedData); err == nil {
-		return cachedData.Grades, cachedData.GPA, nil
+	defer body.Close()
+
+	page, err := io.ReadAll(body)
+	if err != nil {
+		return nil, common.NewAppError(common.CodeJwcRequestFailed, "读取等级考试页面失败")
 	}
 
-	// 获取会话
-	cookies, err := s.getCookiesOrLogin(ctx, uid, user.Sid, user.Spwd)
+	// 解析成绩（记录解析结果，页面结构变化时告警）
+	levelGrades, err := s.parseLevelGradesFromHTML(bytes.NewReader(page))
+	s.parseMonitor.Record(ctx, service.PageLevelGrades, user.Sid, page, err)
+	return levelGrades, err
+}
+
+// GetAnalytics 成绩分析
+func (s *gradeService) GetAnalytics(ctx context.Context, uid int, policyName string) (*GradeAnalytics, error) {
+	if policyName == "" {
+		policyName = DefaultGPAPolicyName
+	}
+	policy, ok := GetGPAPolicy(policyName)
+	if !ok {
+		return nil, common.NewAppError(common.CodeInvalidParams, "未知的绩点计算策略: "+policyName)
+	}
+
+	current, err := s.getGrades(ctx, uid, "", nil)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	// 构造请求
-	form := url.Values{}
-	form.Set("kksj", term)
-	form.Set("kcxz", "")
-	form.Set("kcmc", "")
-	form.Set("xsfs", "all")
+	analytics := analyzeGrades(current.Grades, policy)
+	analytics.Stale = current.Stale
+	return analytics, nil
+}
+
+// Simulate 绩点模拟
+func (s *gradeService) Simulate(ctx context.Context, uid int, req *SimulateRequest) (*SimulateResponse, error) {
+	policyName := req.Policy
+	if policyName == "" {
+		policyName = DefaultGPAPolicyName
+	}
+	policy, ok := GetGPAPolicy(policyName)
+	if !ok {
+		return nil, common.NewAppError(common.CodeInvalidParams, "未知的绩点计算策略: "+policyName)
+	}
+
+	current, err := s.getGrades(ctx, uid, "", nil)
+	if err != nil {
+		return nil, err
+	}
 
-	// 发起请求
-	body, err := s.crawlerService.FetchWithCookies(ctx, "POST", s.gradeURL, cookies, form)
+	resp, err := simulateGPA(current.Grades, req, policy)
 	if err != nil {
-		return nil, nil, err
+		return nil, common.NewAppError(common.CodeInvalidParams, err.Error())
 	}
-	defer body.Close()
+	return resp, nil
+}
 
-	// 解析成绩
-	gradeList, err := s.parseGradesFromHTML(body)
+// GetSubscription 获取新成绩通知订阅
+func (s *gradeService) GetSubscription(ctx context.Context, uid int) (*GradeSubscription, error) {
+	sub, err := s.repo.FindSubscription(ctx, uid)
 	if err != nil {
-		return nil, nil, err
+		return nil, common.NewAppError(common.CodeInternalError, "获取订阅状态失败")
 	}
+	return sub, nil
+}
 
-	// 计算 GPA
-	gpa := s.calculateGPA(gradeList)
+// SetSubscription 开启/关闭新成绩通知
+func (s *gradeService) SetSubscription(ctx context.Context, uid int, enabled bool) (*GradeSubscription, error) {
+	if enabled {
+		user, err := s.userQuery.GetUserByUid(ctx, uid)
+		if err != nil {
+			return nil, common.NewAppError(common.CodeUserNotFound, "用户不存在")
+		}
+		if user.Sid == "" || user.Spwd == "" {
+			return nil, common.NewAppError(common.CodeJwcNotBound, "")
+		}
+	}
 
-	// 写入缓存（1小时过期）
-	data := GradeData{
-		Grades: gradeList,
-		GPA:    gpa,
+	sub := &GradeSubscription{Uid: uid, Enabled: enabled}
+	if err := s.repo.SaveSubscription(ctx, sub); err != nil {
+		return nil, common.NewAppError(common.CodeInternalError, "保存订阅状态失败")
 	}
-	_ = s.userDataCache.CacheGrades(ctx, uid, term, data, time.Hour)
+	return s.GetSubscription(ctx, uid)
+}
 
-	return gradeList, gpa, nil
+// ListSubscribers 按 uid 分批获取开启通知的用户
+func (s *gradeService) ListSubscribers(ctx context.Context, afterUid, limit int) ([]int, error) {
+	return s.repo.FindSubscribers(ctx, afterUid, limit)
 }
 
-// GetLevelGrades 获取等级考试成绩
-func (s *gradeService) GetLevelGrades(ctx context.Context, uid int) ([]LevelGrade, error) {
-	// 获取用户信息
+// OnJwcUnbind 解除教务绑定时删除成绩历史和快照，并取消新成绩通知
+// 快照必须删除：重新绑定其他学号后首次抓取应重新建立快照，而不是把新账号的成绩当作新出成绩
+func (s *gradeService) OnJwcUnbind(ctx context.Context, uid int) error {
+	return s.repo.DeleteUserData(ctx, uid)
+}
+
+// CheckNewGrades 重新抓取成绩并与上次快照比对
+func (s *gradeService) CheckNewGrades(ctx context.Context, uid int) ([]Grade, error) {
 	user, err := s.userQuery.GetUserByUid(ctx, uid)
 	if err != nil {
 		return nil, common.NewAppError(common.CodeUserNotFound, "用户不存在")
@@ -195,46 +329,94 @@ func (s *gradeService) GetLevelGrades(ctx context.Context, uid int) ([]LevelGrad
 		return nil, common.NewAppError(common.CodeJwcNotBound, "")
 	}
 
-	// 获取会话
-	cookies, err := s.getCookiesOrLogin(ctx, uid, user.Sid, user.Spwd)
+	// 不走缓存，直接抓取最新成绩
+	gradeList, err := s.fetchGrades(ctx, uid, user.Sid, user.Spwd, "")
 	if err != nil {
 		return nil, err
 	}
 
-	// 发起请求
-	body, err := s.crawlerService.FetchWithCookies(ctx, "GET", s.gradeLevelURL, cookies, nil)
+	// 顺便刷新缓存
+	now := time.Now()
+	_ = s.userDataCache.CacheGrades(ctx, uid, "", &GradesResponse{
+		Grades:    gradeList,
+		GPA:       s.calculateGPA(gradeList),
+		FetchedAt: &now,
+	}, time.Hour)
+
+	// 与上次快照比对
+	var newGrades []Grade
+	snapshot, err := s.repo.FindSnapshot(ctx, uid)
+	switch {
+	case err == nil:
+		var previous []Grade
+		if err := json.Unmarshal([]byte(snapshot.Data), &previous); err != nil {
+			return nil, common.NewAppError(common.CodeInternalError, "成绩快照损坏")
+		}
+		// 已有成绩的用户抓到空表（教务系统偶发返回空列表）时保留快照，避免下次把全部成绩当作新成绩通知
+		if len(gradeList) == 0 && len(previous) > 0 {
+			return nil, nil
+		}
+		newGrades = diffGrades(previous, gradeList)
+	case errors.Is(err, ErrSnapshotNotFound):
+		// 首次抓取，只建立快照
+	default:
+		return nil, common.NewAppError(common.CodeInternalError, "获取成绩快照失败")
+	}
+
+	data, err := json.Marshal(gradeList)
 	if err != nil {
-		return nil, err
+		return nil, common.NewAppError(common.CodeInternalError, "序列化成绩失败")
+	}
+	if err := s.repo.SaveSnapshot(ctx, &GradeSnapshot{Uid: uid, Data: string(data)}); err != nil {
+		return nil, common.NewAppError(common.CodeInternalError, "保存成绩快照失败")
 	}
-	defer body.Close()
 
-	// 解析成绩
-	return s.parseLevelGradesFromHTML(body)
+	return newGrades, nil
 }
 
-// getCookiesOrLogin 获取缓存的 cookies 或登录
-func (s *gradeService) getCookiesOrLogin(ctx context.Context, uid int, sid, spwd string) ([]*http.Cookie, error) {
-	cookies, err := s.sessionService.GetCachedCookies(ctx, uid)
+// fetchGrades 从教务系统抓取成绩（term 为空表示全部学期）
+func (s *gradeService) fetchGrades(ctx context.Context, uid int, sid, spwd, term string) ([]Grade, error) {
+	// 构造请求
+	form := url.Values{}
+	form.Set("kksj", term)
+	form.Set("kcxz", "")
+	form.Set("kcmc", "")
+	form.Set("xsfs", "all")
+
+	// 发起请求（会话过期时自动重新登录）
+	account := &service.JwcAccount{Uid: uid, Username: sid, Password: spwd}
+	body, err := s.crawlerService.FetchWithSession(ctx, account, "POST", s.gradeURL, form)
 	if err != nil {
-		return nil, common.NewAppError(common.CodeCacheError, "缓存错误")
+		return nil, err
 	}
+	defer body.Close()
 
-	if len(cookies) > 0 {
-		return cookies, nil
+	page, err := io.ReadAll(body)
+	if err != nil {
+		return nil, common.NewAppError(common.CodeJwcRequestFailed, "读取成绩页面失败")
 	}
 
-	if err := s.sessionService.LoginAndCache(ctx, uid, sid, spwd); err != nil {
+	// 解析成绩（记录解析结果，页面结构变化时告警）
+	gradeList, err := s.parseGradesFromHTML(bytes.NewReader(page))
+	s.parseMonitor.Record(ctx, service.PageGrades, sid, page, err)
+	if err != nil {
 		return nil, err
 	}
 
-	cookies, err = s.sessionService.GetCachedCookies(ctx, uid)
-	if err != nil || len(cookies) == 0 {
-		return nil, common.NewAppError(common.CodeJwcLoginFailed, "获取会话失败")
+	// 持久化，供教务系统不可用时兜底
+	if err := s.repo.UpsertGrades(ctx, uid, gradeList, time.Now()); err != nil {
+		log.Printf("保存成绩历史失败 (uid=%d): %v", uid, err)
 	}
 
-	return cookies, nil
+	return gradeList, nil
 }
 
+// gradeColumns 成绩列表必须包含的列（序号缺失时为空，不影响解析）
+var gradeColumns = []string{"开课学期", "课程编号", "课程名称", "成绩", "学分", "绩点", "考试性质", "课程属性"}
+
+// levelGradeColumns 等级考试成绩必须包含的列
+var levelGradeColumns = []string{"考级课程", "分数类成绩/总成绩", "等级类成绩/总成绩", "考级日期"}
+
 // parseGradesFromHTML 解析成绩 HTML
 func (s *gradeService) parseGradesFromHTML(r io.Reader) ([]Grade, error) {
 	doc, err := goquery.NewDocumentFromReader(r)
@@ -247,10 +429,17 @@ func (s *gradeService) parseGradesFromHTML(r io.Reader) ([]Grade, error) {
 		return nil, common.NewAppError(common.CodeJwcParseFailed, "未找到成绩数据")
 	}
 
-	var grades []Grade
+	// 按表头定位列，教务系统调整列顺序时仍能正确解析，缺少列时报错而不是静默返回错位的数据
+	cols, err := shared.MapTableColumns(table, gradeColumns...)
+	if err != nil {
+		return nil, common.NewAppError(common.CodeJwcParseFailed, err.Error())
+	}
+
+	// 表头完整但没有数据行（新生或所选学期尚无成绩）是正常结果，返回空列表
+	grades := []Grade{}
 	table.Find("tr").Each(func(i int, tr *goquery.Selection) {
 		tds := tr.Find("td")
-		if tds.Length() < 13 {
+		if tds.Length() < cols.Width() {
 			return
 		}
 
@@ -258,24 +447,24 @@ func (s *gradeService) parseGradesFromHTML(r io.Reader) ([]Grade, error) {
 			return strings.TrimSpace(strings.ReplaceAll(s, "\u00A0", ""))
 		}
 
-		serialNo := trim(tds.Eq(0).Text())
-		term := trim(tds.Eq(1).Text())
-		code := trim(tds.Eq(2).Text())
-		subject := trim(tds.Eq(3).Text())
-		score := trim(tds.Eq(4).Text())
-		credit := parseFloatSafe(trim(tds.Eq(5).Text()))
-		gpa := parseFloatSafe(trim(tds.Eq(7).Text()))
+		serialNo := trim(cols.Text(tds, "序号"))
+		term := trim(cols.Text(tds, "开课学期"))
+		code := trim(cols.Text(tds, "课程编号"))
+		subject := trim(cols.Text(tds, "课程名称"))
+		score := trim(cols.Text(tds, "成绩"))
+		credit := parseFloatSafe(trim(cols.Text(tds, "学分")))
+		gpa := parseFloatSafe(trim(cols.Text(tds, "绩点")))
 
 		// 处理 status
 		statusNormalRegexp := regexp.MustCompile(`^正常考试$|.*重.*`)
 		var status int
-		if statusNormalRegexp.MatchString(trim(tds.Eq(10).Text())) {
+		if statusNormalRegexp.MatchString(trim(cols.Text(tds, "考试性质"))) {
 			status = 0
 		} else {
 			status = 1
 		}
 
-		property := trim(tds.Eq(11).Text())
+		property := trim(cols.Text(tds, "课程属性"))
 
 		if subject == "" && score == "" {
 			return
@@ -294,10 +483,6 @@ func (s *gradeService) parseGradesFromHTML(r io.Reader) ([]Grade, error) {
 		})
 	})
 
-	if len(grades) == 0 {
-		return nil, common.NewAppError(common.CodeJwcParseFailed, "未解析到成绩")
-	}
-
 	return grades, nil
 }
 
@@ -313,10 +498,15 @@ func (s *gradeService) parseLevelGradesFromHTML(r io.Reader) ([]LevelGrade, erro
 		return nil, common.NewAppError(common.CodeJwcParseFailed, "未找到等级考试数据")
 	}
 
+	cols, err := shared.MapTableColumns(table, levelGradeColumns...)
+	if err != nil {
+		return nil, common.NewAppError(common.CodeJwcParseFailed, err.Error())
+	}
+
 	var levelGrades []LevelGrade
 	table.Find("tr").Each(func(i int, s *goquery.Selection) {
 		tds := s.Find("td")
-		if tds.Length() < 9 {
+		if tds.Length() < cols.Width() {
 			return
 		}
 
@@ -324,18 +514,16 @@ func (s *gradeService) parseLevelGradesFromHTML(r io.Reader) ([]LevelGrade, erro
 			return strings.ReplaceAll(s, "\u00A0", "")
 		}
 
-		no := trim(tds.Eq(0).Text())
-		courseName := trim(tds.Eq(1).Text())
+		no := trim(cols.Text(tds, "序号"))
+		courseName := trim(cols.Text(tds, "考级课程"))
 
 		// 处理分数类和等级类成绩
-		var levGrade string
-		if trim(tds.Eq(4).Text()) == "" {
-			levGrade = trim(tds.Eq(7).Text())
-		} else {
-			levGrade = trim(tds.Eq(4).Text())
+		levGrade := trim(cols.Text(tds, "分数类成绩/总成绩"))
+		if levGrade == "" {
+			levGrade = trim(cols.Text(tds, "等级类成绩/总成绩"))
 		}
 
-		time := trim(tds.Eq(8).Text())
+		time := trim(cols.Text(tds, "考级日期"))
 
 		levelGrades = append(levelGrades, LevelGrade{
 			No:         no,
@@ -348,116 +536,16 @@ func (s *gradeService) parseLevelGradesFromHTML(r io.Reader) ([]LevelGrade, erro
 	return levelGrades, nil
 }
 
-// calculateGPA 计算 GPA
+// calculateGPA 使用学校默认策略计算 GPA
 func (s *gradeService) calculateGPA(gradeArray []Grade) *GPA {
-	distinct := s.distinctGrades(gradeArray)
-
-	var (
-		sumScore   float64
-		sumGp      float64
-		sumCredit  float64
-		num2       int
-		sumScore2  float64
-		sumCredit2 float64
-	)
-
-	for _, g := range distinct {
-		if g.Property != "必修" {
-			continue
-		}
-
-		scoreText := g.Score
-
-		// BasicPoint
-		if g.Status == 0 {
-			gradeD := mapGradeToScoreForBasic(scoreText)
-			sumScore2 += gradeD * g.Credit
-			sumCredit2 += g.Credit
-		}
-
-		// GPA & APF
-		numericScore, isNum := parseNumeric(scoreText)
-
-		if isNum && g.Status == 0 && numericScore >= 59.9 {
-			sumScore += numericScore
-			gp := s.getCourseGp(g, scoreText)
-			sumGp += gp * g.Credit
-			sumCredit += g.Credit
-			num2++
-		} else {
-			if g.Status == 0 && !isNum {
-				gp := s.getCourseGp(g, scoreText)
-				score := gp*10.0 + 50.0
-				sumScore += score
-				sumGp += gp * g.Credit
-				sumCredit += g.Credit
-				num2++
-			} else {
-				if g.Status == 1 && isNum && numericScore >= 59.9 {
-					sumScore += 60.0
-					gp := s.getCourseGp(g, scoreText)
-					sumGp += gp * 1.0
-					sumCredit += g.Credit
-					num2++
-				} else if g.Status == 1 && !isNum && (scoreText == "及格" || scoreText == "合格") {
-					gp := s.getCourseGp(g, scoreText)
-					sumScore += 60.0
-					sumGp += gp * 1.0
-					sumCredit += g.Credit
-					num2++
-				} else if g.Status == 1 && !isNum && (scoreText == "不及格" || scoreText == "不合格") {
-					sumCredit += g.Credit
-					num2++
-				} else if g.Status == 1 && isNum && numericScore <= 59.9 {
-					sumCredit += g.Credit
-					num2++
-				} else {
-					sumCredit += g.Credit
-					num2++
-					if isNum {
-						sumScore += numericScore
-					} else {
-						log.Println("特殊成绩样式:", scoreText)
-					}
-				}
-			}
-		}
-	}
-
-	var gpa, apf, basic float64
-	if sumCredit != 0 {
-		gpa = sumGp / sumCredit
-	}
-	if num2 != 0 {
-		apf = sumScore / float64(num2)
-	}
-	if sumCredit2 != 0 {
-		basic = sumScore2 / sumCredit2
-	}
-
-	if math.IsNaN(gpa) {
-		gpa = 0
-	}
-	if math.IsNaN(apf) {
-		apf = 0
-	}
-	if math.IsNaN(basic) {
-		basic = 0
-	}
-
-	return &GPA{
-		AverageGPA:   round3(gpa),
-		AverageScore: round3(apf),
-		BasicScore:   round3(basic),
-	}
+	return defaultGPAPolicy.Calculate(gradeArray)
 }
 
 // distinctGrades 去重成绩
-func (s *gradeService) distinctGrades(grades []Grade) []Grade {
+func distinctGrades(grades []Grade) []Grade {
 	m := make(map[string]Grade)
 	for _, g := range grades {
-		key := g.SerialNo + "|" + g.Code + "|" + g.Term
-		m[key] = g
+		m[gradeKey(g)] = g
 	}
 	res := make([]Grade, 0, len(m))
 	for _, g := range m {
@@ -466,8 +554,42 @@ func (s *gradeService) distinctGrades(grades []Grade) []Grade {
 	return res
 }
 
+// gradeKey 成绩唯一键（与 distinctGrades 保持一致，用于同一页面内去重计算 GPA）
+func gradeKey(g Grade) string {
+	return g.SerialNo + "|" + g.Code + "|" + g.Term
+}
+
+// recordKey 跨次抓取比对成绩的键：课程编号 + 学期 + 考试性质（与成绩历史表的唯一索引一致）
+// 序号只是页面行号，按学期查询和查询全部时不同，新成绩出现后也会整体后移，不能用于跨次比对；
+// 没有课程编号的记录才使用序号区分
+func recordKey(g Grade) string {
+	if g.Code == "" {
+		return "#" + g.SerialNo + "|" + g.Term
+	}
+	return g.Code + "|" + g.Term + "|" + strconv.Itoa(g.Status)
+}
+
+// diffGrades 找出 current 中相对 previous 新增的成绩（按 recordKey 比对）
+func diffGrades(previous, current []Grade) []Grade {
+	known := make(map[string]struct{}, len(previous))
+	for _, g := range previous {
+		known[recordKey(g)] = struct{}{}
+	}
+
+	var added []Grade
+	for _, g := range current {
+		key := recordKey(g)
+		if _, ok := known[key]; ok {
+			continue
+		}
+		known[key] = struct{}{}
+		added = append(added, g)
+	}
+	return added
+}
+
 // getCourseGp 获取课程绩点
-func (s *gradeService) getCourseGp(g Grade, scoreText string) float64 {
+func getCourseGp(g Grade, scoreText string) float64 {
 	if !math.IsNaN(g.Gpa) && g.Gpa > 0 {
 		return g.Gpa
 	}
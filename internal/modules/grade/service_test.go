@@ -0,0 +1,127 @@
+package grade
+
+import (
+	"bytes"
+	"spider-go/internal/common"
+	"spider-go/internal/service/jwctest"
+	"strings"
+	"testing"
+)
+
+func TestParseGradesFromHTML(t *testing.T) {
+	s := &gradeService{}
+
+	grades, err := s.parseGradesFromHTML(bytes.NewReader(jwctest.ReadFixture(t, "testdata/cjcx_list.html")))
+	if err != nil {
+		t.Fatalf("parseGradesFromHTML: %v", err)
+	}
+	jwctest.AssertGolden(t, "testdata/cjcx_list.golden.json", grades)
+}
+
+func TestParseGradesFromHTMLRejectsOtherPages(t *testing.T) {
+	s := &gradeService{}
+
+	pages := map[string]string{
+		"login page": `<html><head><title>统一身份认证</title></head><body><input name="execution" value="e1s1"/></body></html>`,
+		"empty list": `<html><body><table id="dataList"><tr><th>序号</th></tr></table></body></html>`,
+	}
+	for name, page := range pages {
+		_, err := s.parseGradesFromHTML(strings.NewReader(page))
+		if appErr, ok := err.(*common.AppError); !ok || appErr.Code != common.CodeJwcParseFailed {
+			t.Errorf("%s: got %v, want CodeJwcParseFailed", name, err)
+		}
+	}
+}
+
+func TestParseGradesFromHTMLEmptyTerm(t *testing.T) {
+	s := &gradeService{}
+
+	// 表头完整，没有数据行（教务系统在空表中放一行"未查询到数据"）
+	page := `<table id="dataList">
+<tr><th>序号</th><th>开课学期</th><th>课程编号</th><th>课程名称</th><th>成绩</th><th>学分</th><th>绩点</th><th>考试性质</th><th>课程属性</th></tr>
+<tr><td colspan="9">未查询到数据</td></tr>
+</table>`
+	grades, err := s.parseGradesFromHTML(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("parseGradesFromHTML: %v", err)
+	}
+	if grades == nil || len(grades) != 0 {
+		t.Fatalf("got %#v, want an empty non-nil list", grades)
+	}
+}
+
+func TestParseGradesFromHTMLMapsColumnsByHeader(t *testing.T) {
+	s := &gradeService{}
+
+	// 列顺序与教务系统默认页面不同，且多出一列
+	page := `<table id="dataList">
+<tr><th>课程名称</th><th>序号</th><th>学分</th><th>成绩</th><th>新增列</th><th>绩点</th><th>课程编号</th><th>开课学期</th><th>课程属性</th><th>考试性质</th></tr>
+<tr><td>高等数学</td><td>1</td><td>5</td><td>91</td><td>x</td><td>4.1</td><td>MA101</td><td>2023-2024-1</td><td>必修</td><td>正常考试</td></tr>
+</table>`
+	grades, err := s.parseGradesFromHTML(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("parseGradesFromHTML: %v", err)
+	}
+	want := Grade{SerialNo: "1", Term: "2023-2024-1", Code: "MA101", Subject: "高等数学", Score: "91", Credit: 5, Gpa: 4.1, Status: 0, Property: "必修"}
+	if len(grades) != 1 || grades[0] != want {
+		t.Fatalf("got %+v, want %+v", grades, want)
+	}
+
+	// 缺少列时报错并指出缺少的列
+	page = strings.Replace(page, "<th>绩点</th>", "<th>绩点(新)</th>", 1)
+	_, err = s.parseGradesFromHTML(strings.NewReader(page))
+	if appErr, ok := err.(*common.AppError); !ok || appErr.Code != common.CodeJwcParseFailed || !strings.Contains(appErr.Message, "绩点") {
+		t.Fatalf("got %v, want CodeJwcParseFailed mentioning 绩点", err)
+	}
+}
+
+func TestParseLevelGradesFromHTML(t *testing.T) {
+	s := &gradeService{}
+
+	levelGrades, err := s.parseLevelGradesFromHTML(bytes.NewReader(jwctest.ReadFixture(t, "testdata/djkscj_list.html")))
+	if err != nil {
+		t.Fatalf("parseLevelGradesFromHTML: %v", err)
+	}
+	jwctest.AssertGolden(t, "testdata/djkscj_list.golden.json", levelGrades)
+}
+
+func TestCalculateGPA(t *testing.T) {
+	s := &gradeService{}
+
+	grades, err := s.parseGradesFromHTML(bytes.NewReader(jwctest.ReadFixture(t, "testdata/cjcx_list.html")))
+	if err != nil {
+		t.Fatalf("parseGradesFromHTML: %v", err)
+	}
+
+	gpas := map[string]*GPA{}
+	for _, policy := range ListGPAPolicies() {
+		gpas[policy.Name()] = policy.Calculate(grades)
+	}
+	jwctest.AssertGolden(t, "testdata/gpa.golden.json", gpas)
+
+	if got, want := s.calculateGPA(grades), gpas[DefaultGPAPolicyName]; *got != *want {
+		t.Errorf("calculateGPA = %+v, want default policy %+v", got, want)
+	}
+}
+
+func TestGradeKeys(t *testing.T) {
+	// 同一学期同一课程的两行（序号不同）在 GPA 去重时都保留
+	rows := []Grade{
+		{SerialNo: "1", Term: "2023-2024-1", Code: "PE101", Subject: "体育", Score: "80", Credit: 1, Gpa: 3},
+		{SerialNo: "2", Term: "2023-2024-1", Code: "PE101", Subject: "体育", Score: "90", Credit: 1, Gpa: 4},
+	}
+	if got := len(distinctGrades(rows)); got != 2 {
+		t.Errorf("distinctGrades kept %d rows, want 2", got)
+	}
+
+	// 跨次抓取比对时序号变化不算新成绩，补考算新成绩
+	previous := []Grade{{SerialNo: "1", Term: "2023-2024-1", Code: "MA101", Subject: "高等数学", Score: "55"}}
+	current := []Grade{
+		{SerialNo: "7", Term: "2023-2024-1", Code: "MA101", Subject: "高等数学", Score: "55"},
+		{SerialNo: "8", Term: "2023-2024-1", Code: "MA101", Subject: "高等数学", Score: "70", Status: 1},
+	}
+	added := diffGrades(previous, current)
+	if len(added) != 1 || added[0].Status != 1 {
+		t.Errorf("diffGrades = %+v, want only the makeup exam", added)
+	}
+}
@@ -0,0 +1,189 @@
+package grade
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// simulateGPA 在已有成绩上应用假设成绩并重新计算 GPA，指定目标时求解剩余课程的最低统一分数
+func simulateGPA(grades []Grade, req *SimulateRequest, policy GPAPolicy) (*SimulateResponse, error) {
+	simulated, pending, err := applySimulatedCourses(grades, req.Courses)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &SimulateResponse{
+		Policy:    policy.Name(),
+		Current:   policy.Calculate(grades),
+		Simulated: policy.Calculate(withoutPending(simulated, pending)),
+	}
+
+	if req.TargetGPA == nil {
+		return resp, nil
+	}
+	if len(pending) == 0 {
+		return nil, errors.New("求解目标绩点需要至少一门未填写分数的课程")
+	}
+
+	resp.Target = solveTarget(simulated, pending, *req.TargetGPA, policy)
+	return resp, nil
+}
+
+// applySimulatedCourses 应用假设成绩，返回新的成绩列表和待求解课程的下标
+func applySimulatedCourses(grades []Grade, courses []SimulatedCourse) ([]Grade, []int, error) {
+	result := make([]Grade, len(grades))
+	copy(result, grades)
+
+	for i, c := range courses {
+		score := strings.TrimSpace(c.Score)
+		if score != "" && !isValidScore(score) {
+			return nil, nil, errors.New("第 " + strconv.Itoa(i+1) + " 门课程分数无效: " + score)
+		}
+
+		// 覆盖已有课程：同一课程的多次修读合并为一条正常考试记录
+		if c.Code != "" && overrideCourse(&result, c, score, simulatedSerialNo(i)) {
+			continue
+		}
+
+		// 新增课程
+		if c.Credit <= 0 {
+			return nil, nil, errors.New("第 " + strconv.Itoa(i+1) + " 门新增课程需要填写学分")
+		}
+		property := c.Property
+		if property == "" {
+			property = "必修"
+		}
+		subject := c.Subject
+		if subject == "" {
+			subject = c.Code
+		}
+
+		result = append(result, Grade{
+			SerialNo: simulatedSerialNo(i),
+			Term:     c.Term,
+			Code:     c.Code,
+			Subject:  subject,
+			Score:    score,
+			Credit:   c.Credit,
+			Property: property,
+		})
+	}
+
+	// 覆盖会调整记录顺序，最后统一找出待求解的课程
+	var pending []int
+	for i, g := range result {
+		if strings.HasPrefix(g.SerialNo, simulatedSerialPrefix) && g.Score == "" {
+			pending = append(pending, i)
+		}
+	}
+
+	return result, pending, nil
+}
+
+// simulatedSerialPrefix 模拟课程的序号前缀
+const simulatedSerialPrefix = "sim-"
+
+// simulatedSerialNo 模拟课程序号：distinctGrades 按 序号|课程编号|学期 去重，
+// 新增课程通常没有课程编号，用独立的序号保证多门模拟课程之间、以及与真实成绩之间不会被合并
+func simulatedSerialNo(i int) string {
+	return simulatedSerialPrefix + strconv.Itoa(i+1)
+}
+
+// overrideCourse 用假设成绩覆盖已有课程，未找到课程时返回 false
+func overrideCourse(grades *[]Grade, c SimulatedCourse, score, serialNo string) bool {
+	var matched *Grade
+	kept := (*grades)[:0:0]
+	for _, g := range *grades {
+		if g.Code == c.Code && (c.Term == "" || g.Term == c.Term) {
+			if matched == nil {
+				first := g
+				matched = &first
+			}
+			continue
+		}
+		kept = append(kept, g)
+	}
+	if matched == nil {
+		return false
+	}
+
+	matched.SerialNo = serialNo
+	matched.Score = score
+	matched.Status = 0
+	matched.Gpa = 0 // 教务系统给出的绩点不再适用
+	if c.Credit > 0 {
+		matched.Credit = c.Credit
+	}
+	if c.Property != "" {
+		matched.Property = c.Property
+	}
+
+	*grades = append(kept, *matched)
+	return true
+}
+
+// solveTarget 求剩余课程达到目标绩点所需的最低统一分数（按整数分逐一尝试）
+func solveTarget(grades []Grade, pending []int, target float64, policy GPAPolicy) *SimulateTarget {
+	trial := make([]Grade, len(grades))
+	copy(trial, grades)
+
+	calc := func(score int) *GPA {
+		text := strconv.Itoa(score)
+		for _, idx := range pending {
+			trial[idx].Score = text
+		}
+		return policy.Calculate(trial)
+	}
+
+	for score := 0; score <= 100; score++ {
+		if gpa := calc(score); gpa.AverageGPA >= target {
+			return &SimulateTarget{
+				TargetGPA:     target,
+				Reachable:     true,
+				RequiredScore: float64(score),
+				ResultGPA:     gpa,
+			}
+		}
+	}
+
+	return &SimulateTarget{
+		TargetGPA:     target,
+		Reachable:     false,
+		RequiredScore: 100,
+		ResultGPA:     calc(100),
+	}
+}
+
+// withoutPending 去掉未填写分数的课程
+func withoutPending(grades []Grade, pending []int) []Grade {
+	if len(pending) == 0 {
+		return grades
+	}
+
+	skip := make(map[int]struct{}, len(pending))
+	for _, idx := range pending {
+		skip[idx] = struct{}{}
+	}
+
+	res := make([]Grade, 0, len(grades)-len(pending))
+	for i, g := range grades {
+		if _, ok := skip[i]; ok {
+			continue
+		}
+		res = append(res, g)
+	}
+	return res
+}
+
+// isValidScore 校验假设分数（0-100 的数字或五级制/两级制成绩）
+func isValidScore(score string) bool {
+	if v, ok := parseNumeric(score); ok {
+		return v >= 0 && v <= 100
+	}
+	switch score {
+	case "优", "良", "中", "及格", "不及格", "合格", "不合格":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package grade
+
+import (
+	"strings"
+	"testing"
+)
+
+// simulatorGrades 模拟测试用的已有成绩：高等数学正常考试不及格后补考通过，大学物理 90 分
+func simulatorGrades() []Grade {
+	return []Grade{
+		{SerialNo: "1", Term: "2023-2024-1", Code: "MA101", Subject: "高等数学", Score: "50", Credit: 2, Property: "必修"},
+		{SerialNo: "2", Term: "2023-2024-1", Code: "MA101", Subject: "高等数学", Score: "70", Credit: 2, Gpa: 1, Status: 1, Property: "必修"},
+		{SerialNo: "3", Term: "2023-2024-1", Code: "PH101", Subject: "大学物理", Score: "90", Credit: 2, Gpa: 4, Property: "必修"},
+	}
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func TestSimulateGPAOverridesExistingCourse(t *testing.T) {
+	grades := simulatorGrades()
+
+	resp, err := simulateGPA(grades, &SimulateRequest{
+		Courses: []SimulatedCourse{{Code: "MA101", Score: "90"}},
+	}, defaultGPAPolicy)
+	if err != nil {
+		t.Fatalf("simulateGPA: %v", err)
+	}
+
+	// 两次修读合并为一条 90 分的正常考试记录，与大学物理同为 4.0
+	if resp.Simulated.AverageGPA != 4 {
+		t.Errorf("simulated GPA = %v, want 4", resp.Simulated.AverageGPA)
+	}
+	if *resp.Current != *defaultGPAPolicy.Calculate(grades) {
+		t.Errorf("current GPA = %+v, want unchanged", resp.Current)
+	}
+	if grades[0].Score != "50" || len(grades) != 3 {
+		t.Error("simulateGPA modified the input grades")
+	}
+
+	simulated, pending, err := applySimulatedCourses(grades, []SimulatedCourse{{Code: "MA101", Score: "90", Credit: 3}})
+	if err != nil || len(pending) != 0 {
+		t.Fatalf("applySimulatedCourses = %v, %v", pending, err)
+	}
+	var math []Grade
+	for _, g := range simulated {
+		if g.Code == "MA101" {
+			math = append(math, g)
+		}
+	}
+	if len(math) != 1 || math[0].Score != "90" || math[0].Status != 0 || math[0].Gpa != 0 || math[0].Credit != 3 {
+		t.Errorf("overridden course = %+v", math)
+	}
+}
+
+func TestSimulateGPAAddsNewCourse(t *testing.T) {
+	resp, err := simulateGPA(simulatorGrades(), &SimulateRequest{
+		Courses: []SimulatedCourse{
+			{Subject: "毕业设计", Credit: 4, Score: "80"},
+			{Subject: "专业实习", Credit: 4, Score: "80"},
+		},
+	}, defaultGPAPolicy)
+	if err != nil {
+		t.Fatalf("simulateGPA: %v", err)
+	}
+
+	// 高等数学正常考试不及格计 2 学分 0 绩点，补考绩点 1 按 1 学分计（学分再计 2），大学物理 4.0×2，
+	// 两门新课各 3.0×4：(0 + 1 + 8 + 24) / (2 + 2 + 2 + 8)
+	if got, want := resp.Simulated.AverageGPA, round3(33.0/14); got != want {
+		t.Errorf("simulated GPA = %v, want %v (both new courses counted)", got, want)
+	}
+
+	tests := []struct {
+		name    string
+		course  SimulatedCourse
+		wantErr string
+	}{
+		{name: "missing credit", course: SimulatedCourse{Subject: "新课", Score: "80"}, wantErr: "需要填写学分"},
+		{name: "unknown code without credit", course: SimulatedCourse{Code: "XX999", Score: "80"}, wantErr: "需要填写学分"},
+		{name: "invalid score", course: SimulatedCourse{Subject: "新课", Credit: 2, Score: "120"}, wantErr: "分数无效"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := simulateGPA(simulatorGrades(), &SimulateRequest{Courses: []SimulatedCourse{tt.course}}, defaultGPAPolicy)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSimulateGPATarget(t *testing.T) {
+	pending := []SimulatedCourse{{Subject: "毕业设计", Credit: 9}}
+
+	// 已有绩点和 9、学分 6；剩余 9 学分需要绩点 x 使 (9 + 9x) / 15 >= 3，x >= 4，即 90 分
+	resp, err := simulateGPA(simulatorGrades(), &SimulateRequest{Courses: pending, TargetGPA: floatPtr(3)}, defaultGPAPolicy)
+	if err != nil {
+		t.Fatalf("simulateGPA: %v", err)
+	}
+	if target := resp.Target; !target.Reachable || target.RequiredScore != 90 || target.ResultGPA.AverageGPA < 3 {
+		t.Errorf("reachable target = %+v", target)
+	}
+
+	// 剩余课程满分也达不到
+	resp, err = simulateGPA(simulatorGrades(), &SimulateRequest{Courses: pending, TargetGPA: floatPtr(4.5)}, defaultGPAPolicy)
+	if err != nil {
+		t.Fatalf("simulateGPA: %v", err)
+	}
+	if target := resp.Target; target.Reachable || target.RequiredScore != 100 || target.ResultGPA.AverageGPA >= 4.5 {
+		t.Errorf("unreachable target = %+v", target)
+	}
+
+	// 没有待求解的课程
+	_, err = simulateGPA(simulatorGrades(), &SimulateRequest{
+		Courses:   []SimulatedCourse{{Code: "MA101", Score: "90"}},
+		TargetGPA: floatPtr(3),
+	}, defaultGPAPolicy)
+	if err == nil {
+		t.Error("target without pending courses should fail")
+	}
+}
@@ -91,3 +91,29 @@ func (h *Handler) GetDAURange(c *gin.Context) {
 		Data:      dayData,
 	})
 }
+
+// GetParseHealth 获取教务页面解析健康度
+// @Summary 获取各教务页面的解析成功率（教务系统改版时失败率升高）
+// @Tags 统计
+// @Produce json
+// @Success 200 {object} common.Response{data=[]service.ParseStats}
+// @Router /api/admin/statistics/parse-health [get]
+func (h *Handler) GetParseHealth(c *gin.Context) {
+	common.Success(c, h.service.GetParseHealth(c.Request.Context()))
+}
+
+// GetParseSamples 获取解析失败的页面样本
+// @Summary 获取页面最近解析失败的原始 HTML（已匿名化）
+// @Tags 统计
+// @Produce json
+// @Param page path string true "页面类型：grades、level_grades、course_table、exams"
+// @Success 200 {object} common.Response{data=[]service.ParseSample}
+// @Router /api/admin/statistics/parse-health/{page}/samples [get]
+func (h *Handler) GetParseSamples(c *gin.Context) {
+	samples, err := h.service.GetParseSamples(c.Request.Context(), c.Param("page"))
+	if err != nil {
+		common.ErrorWithAppError(c, err.(*common.AppError))
+		return
+	}
+	common.Success(c, samples)
+}
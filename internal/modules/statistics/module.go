@@ -13,8 +13,8 @@ type Module struct {
 }
 
 // NewModule 创建统计模块
-func NewModule(dauService service.DAUService) *Module {
-	svc := NewService(dauService)
+func NewModule(dauService service.DAUService, parseMonitor service.ParseMonitor) *Module {
+	svc := NewService(dauService, parseMonitor)
 	handler := NewHandler(svc)
 
 	return &Module{
@@ -29,6 +29,8 @@ func (m *Module) RegisterRoutes(adminGroup *gin.RouterGroup) {
 	// 管理员统计接口
 	adminGroup.GET("/dau", m.handler.GetTodayDAU)
 	adminGroup.GET("/dau/range", m.handler.GetDAURange)
+	adminGroup.GET("/parse-health", m.handler.GetParseHealth)
+	adminGroup.GET("/parse-health/:page/samples", m.handler.GetParseSamples)
 }
 
 // GetService 获取服务（供其他模块使用）
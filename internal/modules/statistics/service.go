@@ -15,16 +15,22 @@ type Service interface {
 	GetDAUByDate(ctx context.Context, date time.Time) (int64, error)
 	// GetDAURange 获取指定日期范围的DAU
 	GetDAURange(ctx context.Context, startDate, endDate time.Time) (map[string]int64, error)
+	// GetParseHealth 获取各教务页面的解析健康度
+	GetParseHealth(ctx context.Context) []service.ParseStats
+	// GetParseSamples 获取页面最近解析失败的样本
+	GetParseSamples(ctx context.Context, page string) ([]service.ParseSample, error)
 }
 
 type statisticsService struct {
-	dauService service.DAUService
+	dauService   service.DAUService
+	parseMonitor service.ParseMonitor
 }
 
 // NewService 创建统计服务
-func NewService(dauService service.DAUService) Service {
+func NewService(dauService service.DAUService, parseMonitor service.ParseMonitor) Service {
 	return &statisticsService{
-		dauService: dauService,
+		dauService:   dauService,
+		parseMonitor: parseMonitor,
 	}
 }
 
@@ -64,3 +70,29 @@ func (s *statisticsService) GetDAURange(ctx context.Context, startDate, endDate
 	}
 	return data, nil
 }
+
+// parsePages 监控解析健康度的页面类型
+var parsePages = map[string]bool{
+	service.PageGrades:      true,
+	service.PageLevelGrades: true,
+	service.PageCourseTable: true,
+	service.PageExams:       true,
+}
+
+// GetParseHealth 获取各教务页面的解析健康度
+func (s *statisticsService) GetParseHealth(ctx context.Context) []service.ParseStats {
+	return s.parseMonitor.Stats()
+}
+
+// GetParseSamples 获取页面最近解析失败的样本
+func (s *statisticsService) GetParseSamples(ctx context.Context, page string) ([]service.ParseSample, error) {
+	if !parsePages[page] {
+		return nil, common.NewAppError(common.CodeInvalidParams, "未知的页面类型")
+	}
+
+	samples, err := s.parseMonitor.Samples(ctx, page)
+	if err != nil {
+		return nil, common.NewAppError(common.CodeInternalError, "获取解析失败样本失败")
+	}
+	return samples, nil
+}
@@ -39,7 +39,10 @@ func (h *Handler) RegisterRoutes(public *gin.RouterGroup, authenticated *gin.Rou
 
 	authenticated.GET("/info", h.GetUserInfo)    // 获取用户信息
 	authenticated.POST("/bind", h.BindJwc)       // 绑定教务系统
+	authenticated.DELETE("/bind", h.UnbindJwc)   // 解除教务系统绑定
 	authenticated.GET("/is-bind", h.CheckIsBind) // 检查绑定状态
+
+	authenticated.POST("/jwc/captcha", h.SolveJwcCaptcha) // 提交教务系统登录验证码
 }
 
 // Register 用户注册
@@ -178,9 +181,11 @@ func (h *Handler) BindJwc(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.BindJwc(c.Request.Context(), uid.(int), req.Sid, req.Spwd); err != nil {
+	if err := h.service.BindJwc(c.Request.Context(), uid.(int), req.Sid, req.Spwd, req.ChallengeID, req.Captcha); err != nil {
 		if err == ErrEmptyParams {
 			common.Error(c, common.CodeInvalidParams, err.Error())
+		} else if appErr, ok := err.(*common.AppError); ok {
+			common.ErrorWithAppError(c, appErr)
 		} else {
 			common.Error(c, common.CodeJwcInvalidParams, err.Error())
 		}
@@ -190,6 +195,60 @@ func (h *Handler) BindJwc(c *gin.Context) {
 	common.Success(c, gin.H{"message": "绑定成功"})
 }
 
+// UnbindJwc 解除教务系统绑定
+// @Summary 解除教务系统绑定
+// @Tags User
+// @Produce json
+// @Success 200 {object} gin.H
+// @Router /user/bind [delete]
+func (h *Handler) UnbindJwc(c *gin.Context) {
+	uid, exists := c.Get("uid")
+	if !exists {
+		common.Error(c, common.CodeUnauthorized, "未授权")
+		return
+	}
+
+	if err := h.service.UnbindJwc(c.Request.Context(), uid.(int)); err != nil {
+		common.Error(c, common.CodeInternalError, "解除绑定失败")
+		return
+	}
+
+	common.Success(c, gin.H{"message": "已解除绑定"})
+}
+
+// SolveJwcCaptcha 提交教务系统登录验证码
+// @Summary 提交教务系统登录验证码
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param request body SolveCaptchaRequest true "验证码"
+// @Success 200 {object} gin.H
+// @Router /user/jwc/captcha [post]
+func (h *Handler) SolveJwcCaptcha(c *gin.Context) {
+	uid, exists := c.Get("uid")
+	if !exists {
+		common.Error(c, common.CodeUnauthorized, "未授权")
+		return
+	}
+
+	var req SolveCaptchaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.Error(c, common.CodeInvalidParams, err.Error())
+		return
+	}
+
+	if err := h.service.SolveJwcCaptcha(c.Request.Context(), uid.(int), req.ChallengeID, req.Captcha); err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.ErrorWithAppError(c, appErr)
+		} else {
+			common.Error(c, common.CodeJwcLoginFailed, "登录失败")
+		}
+		return
+	}
+
+	common.Success(c, gin.H{"message": "登录成功"})
+}
+
 // CheckIsBind 检查绑定状态
 // @Summary 检查绑定状态
 // @Tags User
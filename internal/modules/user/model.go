@@ -4,14 +4,15 @@ import "time"
 
 // User 用户模型
 type User struct {
-	Uid       int       `gorm:"primary_key;AUTO_INCREMENT" json:"uid"`
-	Email     string    `gorm:"unique" json:"email"`
-	Name      string    `json:"name"`
-	Password  string    `json:"-"`   // 不序列化
-	Sid       string    `json:"sid"` // 学号
-	Spwd      string    `json:"-"`   // 教务系统密码（不序列化）
-	CreatedAt time.Time `json:"created_at"`
-	Avatar    string    `json:"avatar"`
+	Uid        int       `gorm:"primary_key;AUTO_INCREMENT" json:"uid"`
+	Email      string    `gorm:"unique" json:"email"`
+	Name       string    `json:"name"`
+	Password   string    `json:"-"`   // 不序列化
+	Sid        string    `json:"sid"` // 学号
+	Spwd       string    `json:"-"`   // 教务系统密码（不序列化）
+	CreatedAt  time.Time `json:"created_at"`
+	Avatar     string    `json:"avatar"`
+	JwcInvalid bool      `gorm:"-" json:"-"` // 教务密码是否已失效（由会话服务判定，不持久化）
 }
 
 // TableName 指定表名
@@ -35,8 +36,16 @@ type LoginRequest struct {
 
 // BindJwcRequest 绑定教务系统请求
 type BindJwcRequest struct {
-	Sid  string `json:"sid" binding:"required"`  // 学号
-	Spwd string `json:"spwd" binding:"required"` // 教务系统密码
+	Sid         string `json:"sid" binding:"required"`  // 学号
+	Spwd        string `json:"spwd" binding:"required"` // 教务系统密码
+	ChallengeID string `json:"challenge_id"`            // 验证码挑战 ID（上次绑定返回 40011 时携带）
+	Captcha     string `json:"captcha"`                 // 验证码
+}
+
+// SolveCaptchaRequest 提交教务系统登录验证码请求
+type SolveCaptchaRequest struct {
+	ChallengeID string `json:"challenge_id" binding:"required"` // 验证码挑战 ID
+	Captcha     string `json:"captcha" binding:"required"`      // 验证码
 }
 
 // ResetPasswordRequest 重置密码请求
@@ -53,25 +62,27 @@ type SendEmailCaptchaRequest struct {
 
 // UserResponse 用户响应（不包含敏感信息）
 type UserResponse struct {
-	Uid       int       `json:"uid"`
-	Email     string    `json:"email"`
-	Name      string    `json:"name"`
-	Sid       string    `json:"sid"`
-	Avatar    string    `json:"avatar"`
-	CreatedAt time.Time `json:"created_at"`
-	IsBind    bool      `json:"is_bind"` // 是否绑定教务系统
+	Uid        int       `json:"uid"`
+	Email      string    `json:"email"`
+	Name       string    `json:"name"`
+	Sid        string    `json:"sid"`
+	Avatar     string    `json:"avatar"`
+	CreatedAt  time.Time `json:"created_at"`
+	IsBind     bool      `json:"is_bind"`     // 是否绑定教务系统
+	JwcInvalid bool      `json:"jwc_invalid"` // 绑定已失效（密码已修改），需提示重新绑定
 }
 
 // ToResponse 转换为响应格式
 func (u *User) ToResponse() *UserResponse {
 	return &UserResponse{
-		Uid:       u.Uid,
-		Email:     u.Email,
-		Name:      u.Name,
-		Sid:       u.Sid,
-		Avatar:    u.Avatar,
-		CreatedAt: u.CreatedAt,
-		IsBind:    u.Sid != "" && u.Spwd != "",
+		Uid:        u.Uid,
+		Email:      u.Email,
+		Name:       u.Name,
+		Sid:        u.Sid,
+		Avatar:     u.Avatar,
+		CreatedAt:  u.CreatedAt,
+		IsBind:     u.Sid != "" && u.Spwd != "",
+		JwcInvalid: u.JwcInvalid,
 	}
 }
 
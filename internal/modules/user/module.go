@@ -3,6 +3,7 @@ package user
 import (
 	"spider-go/internal/cache"
 	"spider-go/internal/service"
+	"spider-go/internal/shared"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -19,15 +20,18 @@ type Module struct {
 func NewModule(
 	db *gorm.DB,
 	sessionService service.SessionService,
+	userDataCache cache.UserDataCache,
 	captchaCache cache.CaptchaCache,
 	emailService service.EmailService,
 	dauService service.DAUService,
+	credentialService service.CredentialService,
+	unbindHooks []shared.JwcUnbindHook,
 	jwtSecret string,
 	jwtIssuer string,
 ) *Module {
 	repo := NewRepository(db)
 	captchaService := NewCaptchaService(captchaCache, emailService)
-	svc := NewService(repo, sessionService, captchaService, dauService, jwtSecret, jwtIssuer)
+	svc := NewService(repo, sessionService, userDataCache, captchaService, dauService, credentialService, unbindHooks, jwtSecret, jwtIssuer)
 	handler := NewHandler(svc, captchaService)
 
 	return &Module{
@@ -19,6 +19,8 @@ type Repository interface {
 	Update(ctx context.Context, user *User) error
 	UpdatePassword(ctx context.Context, uid int, password string) error
 	UpdateJwc(ctx context.Context, uid int, sid, spwd string) error
+	UpdateSpwd(ctx context.Context, uid int, spwd string) error
+	FindBoundUsers(ctx context.Context, afterUid int, limit int) ([]*User, error)
 	Delete(ctx context.Context, uid int) error
 }
 
@@ -79,6 +81,22 @@ func (r *repository) UpdateJwc(ctx context.Context, uid int, sid, spwd string) e
 	}).Error
 }
 
+// UpdateSpwd 更新教务系统密码（用于凭据重新加密）
+func (r *repository) UpdateSpwd(ctx context.Context, uid int, spwd string) error {
+	return r.db.WithContext(ctx).Model(&User{}).Where("uid = ?", uid).Update("spwd", spwd).Error
+}
+
+// FindBoundUsers 分批查询已绑定教务系统的用户（按 uid 递增）
+func (r *repository) FindBoundUsers(ctx context.Context, afterUid int, limit int) ([]*User, error) {
+	var users []*User
+	err := r.db.WithContext(ctx).
+		Where("uid > ? AND spwd <> ''", afterUid).
+		Order("uid ASC").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
 // Delete 删除用户
 func (r *repository) Delete(ctx context.Context, uid int) error {
 	return r.db.WithContext(ctx).Delete(&User{}, uid).Error
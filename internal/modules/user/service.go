@@ -3,7 +3,10 @@ package user
 import (
 	"context"
 	"errors"
+	"log"
 	"regexp"
+	"spider-go/internal/cache"
+	"spider-go/internal/common"
 	"spider-go/internal/service"
 	"spider-go/internal/shared"
 	"time"
@@ -30,38 +33,52 @@ type Service interface {
 	GetUserInfo(ctx context.Context, uid int) (*User, error)
 
 	// 教务系统绑定
-	BindJwc(ctx context.Context, uid int, sid, spwd string) error
+	BindJwc(ctx context.Context, uid int, sid, spwd, challengeID, captcha string) error
+	UnbindJwc(ctx context.Context, uid int) error
+	SolveJwcCaptcha(ctx context.Context, uid int, challengeID, captcha string) error
 	CheckIsBind(ctx context.Context, uid int) (bool, error)
+
+	// 凭据维护
+	ReencryptCredentials(ctx context.Context) (int, error)
 }
 
 // userService 用户服务实现
 type userService struct {
-	repo           Repository
-	sessionService service.SessionService
-	captchaService CaptchaService
-	dauService     service.DAUService
-	jwtSecret      []byte
-	jwtIssuer      string
-	jwtExpire      time.Duration
+	repo              Repository
+	sessionService    service.SessionService
+	userDataCache     cache.UserDataCache
+	captchaService    CaptchaService
+	dauService        service.DAUService
+	credentialService service.CredentialService
+	unbindHooks       []shared.JwcUnbindHook // 解除教务绑定时各模块清理自己的数据
+	jwtSecret         []byte
+	jwtIssuer         string
+	jwtExpire         time.Duration
 }
 
 // NewService 创建用户服务
 func NewService(
 	repo Repository,
 	sessionService service.SessionService,
+	userDataCache cache.UserDataCache,
 	captchaService CaptchaService,
 	dauService service.DAUService,
+	credentialService service.CredentialService,
+	unbindHooks []shared.JwcUnbindHook,
 	jwtSecret string,
 	jwtIssuer string,
 ) Service {
 	return &userService{
-		repo:           repo,
-		sessionService: sessionService,
-		captchaService: captchaService,
-		dauService:     dauService,
-		jwtSecret:      []byte(jwtSecret),
-		jwtIssuer:      jwtIssuer,
-		jwtExpire:      168 * time.Hour, // 7天
+		repo:              repo,
+		sessionService:    sessionService,
+		userDataCache:     userDataCache,
+		captchaService:    captchaService,
+		dauService:        dauService,
+		credentialService: credentialService,
+		unbindHooks:       unbindHooks,
+		jwtSecret:         []byte(jwtSecret),
+		jwtIssuer:         jwtIssuer,
+		jwtExpire:         168 * time.Hour, // 7天
 	}
 }
 
@@ -185,11 +202,15 @@ func (s *userService) GetUserInfo(ctx context.Context, uid int) (*User, error) {
 		return nil, err
 	}
 
+	if user.Sid != "" && user.Spwd != "" {
+		user.JwcInvalid, _ = s.sessionService.IsCredentialInvalid(ctx, uid)
+	}
+
 	return user, nil
 }
 
-// BindJwc 绑定教务系统
-func (s *userService) BindJwc(ctx context.Context, uid int, sid, spwd string) error {
+// BindJwc 绑定教务系统（challengeID 不为空时使用验证码完成上一次被挑战的登录）
+func (s *userService) BindJwc(ctx context.Context, uid int, sid, spwd, challengeID, captcha string) error {
 	if sid == "" || spwd == "" {
 		return ErrEmptyParams
 	}
@@ -202,13 +223,21 @@ func (s *userService) BindJwc(ctx context.Context, uid int, sid, spwd string) er
 		return errors.New("请绑定i中南林APP账号")
 	}
 
-	// 尝试登录教务系统验证账号
-	if err := s.sessionService.LoginAndCache(ctx, uid, sid, spwd); err != nil {
+	// 尝试登录教务系统验证账号（成功后清除绑定失效标记）
+	if err := s.verifyJwc(ctx, uid, sid, spwd, challengeID, captcha); err != nil {
+		// 已识别的失败原因（密码错误、账号锁定、需要验证码、系统维护等）直接返回，便于前端给出提示
+		if appErr, ok := err.(*common.AppError); ok && appErr.Code != common.CodeJwcLoginFailed {
+			return appErr
+		}
 		return errors.New("请绑定i中南林APP账号")
 	}
 
-	// 更新数据库
-	if err := s.repo.UpdateJwc(ctx, uid, sid, spwd); err != nil {
+	// 加密后更新数据库
+	encryptedSpwd, err := s.credentialService.Encrypt(ctx, spwd)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.UpdateJwc(ctx, uid, sid, encryptedSpwd); err != nil {
 		return err
 	}
 
@@ -218,6 +247,50 @@ func (s *userService) BindJwc(ctx context.Context, uid int, sid, spwd string) er
 	return nil
 }
 
+// verifyJwc 验证教务系统账号：携带验证码时完成暂存的登录，否则直接登录
+func (s *userService) verifyJwc(ctx context.Context, uid int, sid, spwd, challengeID, captcha string) error {
+	if challengeID == "" {
+		return s.sessionService.VerifyCredentials(ctx, uid, sid, spwd)
+	}
+
+	// 验证码只能证明发起挑战时提交的学号和密码，再次提交的学号或密码不一致时挑战作废
+	return s.sessionService.CompleteCaptchaVerify(ctx, uid, challengeID, captcha, sid, spwd)
+}
+
+// SolveJwcCaptcha 提交验证码完成自动登录教务系统时被挑战的登录
+func (s *userService) SolveJwcCaptcha(ctx context.Context, uid int, challengeID, captcha string) error {
+	_, err := s.sessionService.CompleteCaptchaLogin(ctx, uid, challengeID, captcha)
+	return err
+}
+
+// UnbindJwc 解除教务系统绑定：清除账号密码，由各模块清理成绩历史和订阅，再清除会话缓存、绑定失效标记及全部数据缓存
+// 先清除账号密码，定时任务不会再为该用户抓取；清理失败时返回错误，重新解除绑定即可重试
+func (s *userService) UnbindJwc(ctx context.Context, uid int) error {
+	if err := s.repo.UpdateJwc(ctx, uid, "", ""); err != nil {
+		return err
+	}
+
+	var hookErr error
+	for _, hook := range s.unbindHooks {
+		if err := hook.OnJwcUnbind(ctx, uid); err != nil {
+			log.Printf("解除绑定清理数据失败 (uid=%d): %v", uid, err)
+			hookErr = err
+		}
+	}
+
+	if err := s.sessionService.InvalidateSession(ctx, uid); err != nil {
+		log.Printf("清除会话缓存失败 (uid=%d): %v", uid, err)
+	}
+	if err := s.sessionService.ClearCredentialInvalid(ctx, uid); err != nil {
+		log.Printf("清除绑定失效标记失败 (uid=%d): %v", uid, err)
+	}
+	if err := s.userDataCache.DeleteUserData(ctx, uid); err != nil {
+		log.Printf("清除数据缓存失败 (uid=%d): %v", uid, err)
+	}
+
+	return hookErr
+}
+
 // CheckIsBind 检查是否绑定教务系统
 func (s *userService) CheckIsBind(ctx context.Context, uid int) (bool, error) {
 	user, err := s.repo.FindByID(ctx, uid)
@@ -227,3 +300,44 @@ func (s *userService) CheckIsBind(ctx context.Context, uid int) (bool, error) {
 
 	return user.Sid != "" && user.Spwd != "", nil
 }
+
+// ReencryptCredentials 重新加密教务系统密码（迁移存量明文，以及密钥轮换后的旧版本密文）
+// 返回重新加密的记录数
+func (s *userService) ReencryptCredentials(ctx context.Context) (int, error) {
+	const batchSize = 200
+
+	count := 0
+	afterUid := 0
+	for {
+		users, err := s.repo.FindBoundUsers(ctx, afterUid, batchSize)
+		if err != nil {
+			return count, err
+		}
+		if len(users) == 0 {
+			return count, nil
+		}
+
+		for _, u := range users {
+			afterUid = u.Uid
+			if !s.credentialService.NeedsReEncrypt(u.Spwd) {
+				continue
+			}
+
+			plaintext, err := s.credentialService.Decrypt(ctx, u.Spwd)
+			if err != nil {
+				log.Printf("解密用户 %d 的教务密码失败: %v", u.Uid, err)
+				continue
+			}
+
+			encrypted, err := s.credentialService.Encrypt(ctx, plaintext)
+			if err != nil {
+				return count, err
+			}
+
+			if err := s.repo.UpdateSpwd(ctx, u.Uid, encrypted); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+}
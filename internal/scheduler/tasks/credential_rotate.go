@@ -0,0 +1,85 @@
+package tasks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"spider-go/internal/cache"
+	"spider-go/internal/modules/user"
+	"spider-go/internal/service"
+	"time"
+)
+
+// credentialRotateLockTTL 轮换任务锁的持有时间
+// 成功后不释放：各实例的触发时间可能相差几秒，任务结束就释放会让稍晚触发的实例再轮换一次
+const credentialRotateLockTTL = 12 * time.Hour
+
+// CredentialRotateTask 凭据密钥轮换任务
+type CredentialRotateTask struct {
+	credentialService service.CredentialService
+	userService       user.Service
+	taskLock          cache.TaskLockCache
+}
+
+// NewCredentialRotateTask 创建凭据密钥轮换任务
+func NewCredentialRotateTask(credentialService service.CredentialService, userService user.Service, taskLock cache.TaskLockCache) *CredentialRotateTask {
+	return &CredentialRotateTask{
+		credentialService: credentialService,
+		userService:       userService,
+		taskLock:          taskLock,
+	}
+}
+
+// Name 任务名称
+func (t *CredentialRotateTask) Name() string {
+	return "凭据密钥轮换"
+}
+
+// Cron Cron 表达式（每月1日凌晨3点执行）
+func (t *CredentialRotateTask) Cron() string {
+	return "0 3 1 * *"
+}
+
+// Run 执行任务：生成新版本数据密钥，并用新密钥重新加密所有教务密码
+// 多实例部署时每个实例都会触发，通过 Redis 锁保证只有一个实例轮换（否则会各生成一个版本或版本号冲突）
+func (t *CredentialRotateTask) Run(ctx context.Context) error {
+	token, err := lockToken()
+	if err != nil {
+		return err
+	}
+	ok, err := t.taskLock.AcquireTaskLock(ctx, "credential_rotate", token, credentialRotateLockTTL)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		log.Printf("凭据密钥轮换已由其他实例执行，跳过")
+		return nil
+	}
+
+	version, err := t.credentialService.RotateKey(ctx)
+	if err != nil {
+		// 未生成新密钥，释放锁以便手动重试
+		if releaseErr := t.taskLock.ReleaseTaskLock(context.WithoutCancel(ctx), "credential_rotate", token); releaseErr != nil {
+			log.Printf("释放凭据密钥轮换锁失败: %v", releaseErr)
+		}
+		return err
+	}
+
+	count, err := t.userService.ReencryptCredentials(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("凭据密钥已轮换至 v%d，重新加密 %d 条记录", version, count)
+	return nil
+}
+
+// lockToken 生成任务锁持有者标识
+func lockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,84 @@
+package tasks
+
+import (
+	"context"
+	"spider-go/internal/modules/user"
+	"spider-go/internal/service"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryTaskLock 内存实现的定时任务锁（测试用，不处理过期）
+type memoryTaskLock struct {
+	mu    sync.Mutex
+	locks map[string]string
+}
+
+func (l *memoryTaskLock) AcquireTaskLock(ctx context.Context, task, token string, expiration time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.locks[task]; ok {
+		return false, nil
+	}
+	l.locks[task] = token
+	return true, nil
+}
+
+func (l *memoryTaskLock) ReleaseTaskLock(ctx context.Context, task, token string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.locks[task] == token {
+		delete(l.locks, task)
+	}
+	return nil
+}
+
+// countingCredentialService 只统计轮换次数（测试用）
+type countingCredentialService struct {
+	service.CredentialService
+	mu        sync.Mutex
+	rotations int
+}
+
+func (s *countingCredentialService) RotateKey(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rotations++
+	return s.rotations + 1, nil
+}
+
+// noopUserService 重新加密不做任何事（测试用）
+type noopUserService struct {
+	user.Service
+}
+
+func (noopUserService) ReencryptCredentials(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func TestCredentialRotateRunsOnceAcrossInstances(t *testing.T) {
+	lock := &memoryTaskLock{locks: make(map[string]string)}
+	credentials := &countingCredentialService{}
+
+	// 多个实例在同一时刻（或相差几秒）触发
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			task := NewCredentialRotateTask(credentials, noopUserService{}, lock)
+			if err := task.Run(context.Background()); err != nil {
+				t.Errorf("Run: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := NewCredentialRotateTask(credentials, noopUserService{}, lock).Run(context.Background()); err != nil {
+		t.Fatalf("late Run: %v", err)
+	}
+	if credentials.rotations != 1 {
+		t.Fatalf("rotations = %d, want 1", credentials.rotations)
+	}
+}
@@ -3,16 +3,38 @@ package tasks
 import (
 	"context"
 	"log"
+	"math/rand"
+	"spider-go/internal/cache"
+	"spider-go/internal/common"
+	"spider-go/internal/shared"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
-// DataPrewarmTask 数据预热任务
+const (
+	// prewarmActiveDays 预热最近几天（含今天）活跃过的用户
+	prewarmActiveDays = 2
+	// prewarmConcurrency 同时预热的最大用户数（避免给教务系统造成压力）
+	prewarmConcurrency = 4
+	// prewarmJitter 每个用户开始预热前的最大随机等待（错开登录请求）
+	prewarmJitter = 2 * time.Second
+)
+
+// DataPrewarmTask 数据预热任务：上课高峰前刷新近期活跃用户的成绩、课表和考试安排缓存
+// 同时会建立教务系统会话，高峰期请求无需同步登录 CAS
 type DataPrewarmTask struct {
-	// TODO: 需要在模块层提供数据预热接口后再实现
+	dauCache   cache.DAUCache
+	prewarmers []shared.Prewarmer
 }
 
-// NewDataPrewarmTask 创建数据预热任务
-func NewDataPrewarmTask() *DataPrewarmTask {
-	return &DataPrewarmTask{}
+// NewDataPrewarmTask 创建数据预热任务（同一用户按顺序执行各预热器，共用一次登录）
+func NewDataPrewarmTask(dauCache cache.DAUCache, prewarmers ...shared.Prewarmer) *DataPrewarmTask {
+	return &DataPrewarmTask{
+		dauCache:   dauCache,
+		prewarmers: prewarmers,
+	}
 }
 
 // Name 任务名称
@@ -20,15 +42,124 @@ func (t *DataPrewarmTask) Name() string {
 	return "数据预热"
 }
 
-// Cron Cron 表达式（每天凌晨2点执行）
+// Cron Cron 表达式（每天7:30执行，8点第一节课前完成；课表缓存6小时，会话缓存1小时）
 func (t *DataPrewarmTask) Cron() string {
-	return "0 2 * * *"
+	return "30 7 * * *"
 }
 
 // Run 执行任务
 func (t *DataPrewarmTask) Run(ctx context.Context) error {
-	// TODO: 实现数据预热逻辑
-	// 需要各模块提供预热接口后才能实现
-	log.Println("数据预热任务暂时禁用，等待模块层提供预热接口")
+	uids, err := t.activeUsers(ctx)
+	if err != nil {
+		return err
+	}
+
+	var succeeded, failed, skipped int64
+	sem := make(chan struct{}, prewarmConcurrency)
+	var wg sync.WaitGroup
+
+	for _, uid := range uids {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		}
+
+		wg.Add(1)
+		go func(uid int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if !sleepJitter(ctx, prewarmJitter) {
+				return
+			}
+
+			switch t.prewarm(ctx, uid) {
+			case prewarmSucceeded:
+				atomic.AddInt64(&succeeded, 1)
+			case prewarmSkipped:
+				atomic.AddInt64(&skipped, 1)
+			default:
+				atomic.AddInt64(&failed, 1)
+			}
+		}(uid)
+	}
+
+	wg.Wait()
+	log.Printf("数据预热完成：活跃用户 %d 人，成功 %d 人，失败 %d 人，跳过 %d 人", len(uids), succeeded, failed, skipped)
 	return nil
 }
+
+// prewarmResult 单个用户的预热结果
+type prewarmResult int
+
+const (
+	prewarmSucceeded prewarmResult = iota
+	prewarmFailed
+	prewarmSkipped // 用户不存在、未绑定、绑定已失效、账号被锁定或需要验证码
+)
+
+// prewarm 依次执行各预热器（数据抓取失败时继续执行其余预热器，登录失败时停止）
+// 登录失败后其余预热器会用同一账号密码再次登录，累计失败次数可能导致账号被锁定
+func (t *DataPrewarmTask) prewarm(ctx context.Context, uid int) prewarmResult {
+	result := prewarmSucceeded
+	for _, p := range t.prewarmers {
+		err := p.Prewarm(ctx, uid)
+		if err == nil {
+			continue
+		}
+
+		if appErr, ok := err.(*common.AppError); ok {
+			switch appErr.Code {
+			case common.CodeUserNotFound, common.CodeJwcNotBound, common.CodeJwcBindInvalid,
+				common.CodeJwcAccountLocked, common.CodeJwcNeedCaptcha:
+				// 需要用户自己处理（重新绑定、解锁、输入验证码），不算预热失败
+				return prewarmSkipped
+			case common.CodeJwcLoginFailed, common.CodeJwcWrongPassword, common.CodeJwcPwdExpired,
+				common.CodeJwcMaintenance, common.CodeJwcUnavailable:
+				log.Printf("数据预热登录失败，跳过其余数据 (uid=%d): %v", uid, err)
+				return prewarmFailed
+			}
+		}
+		log.Printf("数据预热失败 (uid=%d): %v", uid, err)
+		result = prewarmFailed
+	}
+	return result
+}
+
+// activeUsers 最近 prewarmActiveDays 天活跃过的用户（去重）
+func (t *DataPrewarmTask) activeUsers(ctx context.Context) ([]int, error) {
+	seen := make(map[int]bool)
+	var uids []int
+
+	now := time.Now()
+	for i := 0; i < prewarmActiveDays; i++ {
+		members, err := t.dauCache.GetActiveUsers(ctx, now.AddDate(0, 0, -i))
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			uid, err := strconv.Atoi(member)
+			if err != nil || seen[uid] {
+				continue
+			}
+			seen[uid] = true
+			uids = append(uids, uid)
+		}
+	}
+	return uids, nil
+}
+
+// sleepJitter 随机等待 [0, limit)，ctx 取消时返回 false
+func sleepJitter(ctx context.Context, limit time.Duration) bool {
+	timer := time.NewTimer(time.Duration(rand.Int63n(int64(limit))))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
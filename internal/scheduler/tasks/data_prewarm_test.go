@@ -0,0 +1,57 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"spider-go/internal/common"
+	"testing"
+)
+
+// prewarmerFunc 用函数实现预热器（测试用）
+type prewarmerFunc func(ctx context.Context, uid int) error
+
+func (f prewarmerFunc) Prewarm(ctx context.Context, uid int) error {
+	return f(ctx, uid)
+}
+
+func TestDataPrewarmStopsOnLoginFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		firstErr  error
+		want      prewarmResult
+		wantCalls int
+	}{
+		{name: "success", firstErr: nil, want: prewarmSucceeded, wantCalls: 3},
+		{name: "fetch error continues", firstErr: errors.New("read timeout"), want: prewarmFailed, wantCalls: 3},
+		{name: "parse error continues", firstErr: common.NewAppError(common.CodeJwcParseFailed, ""), want: prewarmFailed, wantCalls: 3},
+		{name: "not bound", firstErr: common.NewAppError(common.CodeJwcNotBound, ""), want: prewarmSkipped, wantCalls: 1},
+		{name: "bind invalid", firstErr: common.NewAppError(common.CodeJwcBindInvalid, ""), want: prewarmSkipped, wantCalls: 1},
+		{name: "account locked", firstErr: common.NewAppError(common.CodeJwcAccountLocked, ""), want: prewarmSkipped, wantCalls: 1},
+		{name: "needs captcha", firstErr: common.NewAppError(common.CodeJwcNeedCaptcha, ""), want: prewarmSkipped, wantCalls: 1},
+		{name: "login failed", firstErr: common.NewAppError(common.CodeJwcLoginFailed, ""), want: prewarmFailed, wantCalls: 1},
+		{name: "password expired", firstErr: common.NewAppError(common.CodeJwcPwdExpired, ""), want: prewarmFailed, wantCalls: 1},
+		{name: "circuit open", firstErr: common.NewAppError(common.CodeJwcUnavailable, ""), want: prewarmFailed, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			first := prewarmerFunc(func(ctx context.Context, uid int) error {
+				calls++
+				return tt.firstErr
+			})
+			rest := prewarmerFunc(func(ctx context.Context, uid int) error {
+				calls++
+				return nil
+			})
+
+			task := NewDataPrewarmTask(nil, first, rest, rest)
+			if got := task.prewarm(context.Background(), 1); got != tt.want {
+				t.Errorf("prewarm = %v, want %v", got, tt.want)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("prewarmers called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"spider-go/internal/modules/electricity"
+	"spider-go/internal/service"
+	"spider-go/internal/shared"
+)
+
+// electricityAlertBatchSize 每批处理的订阅数
+const electricityAlertBatchSize = 100
+
+// ElectricityAlertTask 低电费提醒任务
+type ElectricityAlertTask struct {
+	electricityService electricity.Service
+	userQuery          shared.UserQuery
+	emailService       service.EmailService
+}
+
+// NewElectricityAlertTask 创建低电费提醒任务
+func NewElectricityAlertTask(
+	electricityService electricity.Service,
+	userQuery shared.UserQuery,
+	emailService service.EmailService,
+) *ElectricityAlertTask {
+	return &ElectricityAlertTask{
+		electricityService: electricityService,
+		userQuery:          userQuery,
+		emailService:       emailService,
+	}
+}
+
+// Name 任务名称
+func (t *ElectricityAlertTask) Name() string {
+	return "低电费提醒"
+}
+
+// Cron Cron 表达式（8点到22点每两小时执行一次，避免夜间打扰）
+func (t *ElectricityAlertTask) Cron() string {
+	return "0 8-22/2 * * *"
+}
+
+// Run 执行任务：余额低于阈值且本轮未提醒过的用户发送邮件，余额恢复后重置提醒状态
+func (t *ElectricityAlertTask) Run(ctx context.Context) error {
+	sent, failed := 0, 0
+	afterUid := 0
+
+	for {
+		bindings, err := t.electricityService.ListAlertSubscribers(ctx, afterUid, electricityAlertBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(bindings) == 0 {
+			break
+		}
+
+		for _, binding := range bindings {
+			afterUid = binding.Uid
+
+			balance, err := t.electricityService.QueryBalance(ctx, binding.Building, binding.Room)
+			if err != nil {
+				log.Printf("查询电费失败 (uid=%d): %v", binding.Uid, err)
+				failed++
+				continue
+			}
+
+			// 余额已恢复（已充值），清除提醒状态以便下次低于阈值时再提醒
+			if balance.Balance >= binding.AlertThreshold {
+				if binding.AlertedAt != nil {
+					_ = t.electricityService.MarkAlerted(ctx, binding.Uid, false)
+				}
+				continue
+			}
+
+			// 本轮已提醒过
+			if binding.AlertedAt != nil {
+				continue
+			}
+
+			if err := t.notify(ctx, &binding, balance); err != nil {
+				log.Printf("发送低电费提醒失败 (uid=%d): %v", binding.Uid, err)
+				failed++
+				continue
+			}
+
+			if err := t.electricityService.MarkAlerted(ctx, binding.Uid, true); err != nil {
+				log.Printf("更新提醒状态失败 (uid=%d): %v", binding.Uid, err)
+			}
+			sent++
+		}
+
+		if len(bindings) < electricityAlertBatchSize {
+			break
+		}
+	}
+
+	log.Printf("低电费提醒完成：发送 %d 封，失败 %d 个", sent, failed)
+	return nil
+}
+
+// notify 发送提醒邮件
+func (t *ElectricityAlertTask) notify(ctx context.Context, binding *electricity.DormBinding, balance *electricity.Balance) error {
+	user, err := t.userQuery.GetUserByUid(ctx, binding.Uid)
+	if err != nil {
+		return err
+	}
+
+	subject := "宿舍电费余额不足提醒"
+	body := fmt.Sprintf(
+		"<p>您绑定的宿舍 <b>%s %s</b> 当前剩余电费 <b>%.2f 元</b>（剩余电量 %.2f 度），已低于您设置的提醒阈值 %.2f 元。</p>"+
+			"<p>请及时充值，以免停电。</p>"+
+			"<p>余额恢复后，下次低于阈值时会再次提醒。如需关闭提醒，请在应用内修改设置。</p>",
+		binding.Building, binding.Room, balance.Balance, balance.RemainingKwh, binding.AlertThreshold,
+	)
+
+	return t.emailService.SendEmail(ctx, user.Email, subject, body)
+}
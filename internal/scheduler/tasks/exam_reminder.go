@@ -0,0 +1,160 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"sort"
+	"spider-go/internal/modules/exam"
+	"spider-go/internal/service"
+	"spider-go/internal/shared"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// examReminderBatchSize 每批处理的订阅用户数
+	examReminderBatchSize = 100
+	// examReminderConcurrency 同时查询考试安排的最大用户数
+	examReminderConcurrency = 4
+)
+
+// ExamReminderTask 考试提醒任务
+type ExamReminderTask struct {
+	examService  exam.Service
+	userQuery    shared.UserQuery
+	emailService service.EmailService
+}
+
+// NewExamReminderTask 创建考试提醒任务
+func NewExamReminderTask(
+	examService exam.Service,
+	userQuery shared.UserQuery,
+	emailService service.EmailService,
+) *ExamReminderTask {
+	return &ExamReminderTask{
+		examService:  examService,
+		userQuery:    userQuery,
+		emailService: emailService,
+	}
+}
+
+// Name 任务名称
+func (t *ExamReminderTask) Name() string {
+	return "考试提醒"
+}
+
+// Cron Cron 表达式（8点到22点每小时执行一次，提醒档位按距开考时间计算，每档只发一次）
+func (t *ExamReminderTask) Cron() string {
+	return "0 8-22 * * *"
+}
+
+// Run 执行任务：为开启提醒的用户汇总即将到来的考试并发送邮件
+func (t *ExamReminderTask) Run(ctx context.Context) error {
+	var notified, failed int64
+	afterUid := 0
+	now := time.Now()
+
+	sem := make(chan struct{}, examReminderConcurrency)
+	var wg sync.WaitGroup
+
+	for {
+		uids, err := t.examService.ListReminderSubscribers(ctx, afterUid, examReminderBatchSize)
+		if err != nil {
+			wg.Wait()
+			return err
+		}
+		if len(uids) == 0 {
+			break
+		}
+
+		for _, uid := range uids {
+			afterUid = uid
+
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(uid int) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				due, err := t.examService.DueReminders(ctx, uid, now)
+				if err != nil {
+					log.Printf("查询待提醒考试失败 (uid=%d): %v", uid, err)
+					atomic.AddInt64(&failed, 1)
+					return
+				}
+				if len(due) == 0 {
+					return
+				}
+
+				if err := t.notify(ctx, uid, due, now); err != nil {
+					log.Printf("发送考试提醒失败 (uid=%d): %v", uid, err)
+					atomic.AddInt64(&failed, 1)
+					return
+				}
+				if err := t.examService.MarkReminded(ctx, uid, due); err != nil {
+					log.Printf("记录考试提醒失败 (uid=%d): %v", uid, err)
+				}
+				atomic.AddInt64(&notified, 1)
+			}(uid)
+		}
+
+		if len(uids) < examReminderBatchSize {
+			break
+		}
+	}
+
+	wg.Wait()
+	log.Printf("考试提醒完成：通知 %d 人，失败 %d 人", notified, failed)
+	return nil
+}
+
+// notify 发送考试提醒邮件（按开考时间排序）
+func (t *ExamReminderTask) notify(ctx context.Context, uid int, due []exam.UpcomingExam, now time.Time) error {
+	user, err := t.userQuery.GetUserByUid(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].StartTime.Before(*due[j].StartTime)
+	})
+
+	subject := fmt.Sprintf("考试提醒：《%s》将于%s开考", due[0].ClassName, countdown(*due[0].StartTime, now))
+	if len(due) > 1 {
+		subject = fmt.Sprintf("考试提醒：近期有 %d 场考试", len(due))
+	}
+
+	body := "<p>你有以下考试即将开始：</p><ul>"
+	for _, e := range due {
+		place := e.Place
+		if place == "" {
+			place = "待定"
+		}
+		body += fmt.Sprintf(
+			"<li>《%s》%s<br>时间：%s<br>地点：%s</li>",
+			html.EscapeString(e.ClassName), countdown(*e.StartTime, now),
+			html.EscapeString(e.Time), html.EscapeString(place),
+		)
+	}
+	body += "</ul><p>请提前确认考场并携带证件。如需关闭考试提醒，请在应用内修改设置。</p>"
+
+	return t.emailService.SendEmail(ctx, user.Email, subject, body)
+}
+
+// countdown 按自然日描述距开考的时间（今天/明天/N 天后）
+func countdown(start, now time.Time) string {
+	y1, m1, d1 := now.In(start.Location()).Date()
+	y2, m2, d2 := start.Date()
+	days := int(time.Date(y2, m2, d2, 0, 0, 0, 0, time.UTC).Sub(time.Date(y1, m1, d1, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+	switch days {
+	case 0:
+		return "今天"
+	case 1:
+		return "明天"
+	default:
+		return fmt.Sprintf("%d 天后", days)
+	}
+}
@@ -0,0 +1,129 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"spider-go/internal/modules/grade"
+	"spider-go/internal/service"
+	"spider-go/internal/shared"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// gradeNotifyBatchSize 每批处理的订阅用户数
+	gradeNotifyBatchSize = 100
+	// gradeNotifyConcurrency 同时抓取成绩的最大用户数（避免给教务系统造成压力）
+	gradeNotifyConcurrency = 4
+)
+
+// GradeNotifyTask 新成绩通知任务
+type GradeNotifyTask struct {
+	gradeService grade.Service
+	userQuery    shared.UserQuery
+	emailService service.EmailService
+}
+
+// NewGradeNotifyTask 创建新成绩通知任务
+func NewGradeNotifyTask(
+	gradeService grade.Service,
+	userQuery shared.UserQuery,
+	emailService service.EmailService,
+) *GradeNotifyTask {
+	return &GradeNotifyTask{
+		gradeService: gradeService,
+		userQuery:    userQuery,
+		emailService: emailService,
+	}
+}
+
+// Name 任务名称
+func (t *GradeNotifyTask) Name() string {
+	return "新成绩通知"
+}
+
+// Cron Cron 表达式（8点到23点每30分钟执行一次）
+func (t *GradeNotifyTask) Cron() string {
+	return "*/30 8-23 * * *"
+}
+
+// Run 执行任务：重新抓取订阅用户的成绩，与快照比对后通知新出的成绩
+func (t *GradeNotifyTask) Run(ctx context.Context) error {
+	var checked, notified, failed int64
+	afterUid := 0
+
+	sem := make(chan struct{}, gradeNotifyConcurrency)
+	var wg sync.WaitGroup
+
+	for {
+		uids, err := t.gradeService.ListSubscribers(ctx, afterUid, gradeNotifyBatchSize)
+		if err != nil {
+			wg.Wait()
+			return err
+		}
+		if len(uids) == 0 {
+			break
+		}
+
+		for _, uid := range uids {
+			afterUid = uid
+
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(uid int) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				newGrades, err := t.gradeService.CheckNewGrades(ctx, uid)
+				if err != nil {
+					log.Printf("检查新成绩失败 (uid=%d): %v", uid, err)
+					atomic.AddInt64(&failed, 1)
+					return
+				}
+				atomic.AddInt64(&checked, 1)
+
+				if len(newGrades) == 0 {
+					return
+				}
+
+				if err := t.notify(ctx, uid, newGrades); err != nil {
+					log.Printf("发送新成绩通知失败 (uid=%d): %v", uid, err)
+					atomic.AddInt64(&failed, 1)
+					return
+				}
+				atomic.AddInt64(&notified, 1)
+			}(uid)
+		}
+
+		if len(uids) < gradeNotifyBatchSize {
+			break
+		}
+	}
+
+	wg.Wait()
+	log.Printf("新成绩通知完成：检查 %d 人，通知 %d 人，失败 %d 人", checked, notified, failed)
+	return nil
+}
+
+// notify 发送新成绩通知邮件
+func (t *GradeNotifyTask) notify(ctx context.Context, uid int, newGrades []grade.Grade) error {
+	user, err := t.userQuery.GetUserByUid(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("你的《%s》成绩已出：%s", newGrades[0].Subject, newGrades[0].Score)
+	if len(newGrades) > 1 {
+		subject = fmt.Sprintf("你有 %d 门新成绩已出", len(newGrades))
+	}
+
+	body := "<p>以下课程成绩已发布：</p><ul>"
+	for _, g := range newGrades {
+		body += fmt.Sprintf("<li>你的《%s》成绩已出：%s</li>", html.EscapeString(g.Subject), html.EscapeString(g.Score))
+	}
+	body += "</ul><p>如需关闭新成绩通知，请在应用内修改设置。</p>"
+
+	return t.emailService.SendEmail(ctx, user.Email, subject, body)
+}
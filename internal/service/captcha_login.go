@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"spider-go/internal/common"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// captchaChallengeTTL 验证码挑战的有效期（CAS 登录页 execution 通常几分钟后失效）
+const captchaChallengeTTL = 5 * time.Minute
+
+// maxCaptchaImageSize 验证码图片的最大字节数
+const maxCaptchaImageSize = 1 << 20
+
+// CaptchaChallenge 返回给前端的验证码挑战（放在错误响应的 data 中）
+type CaptchaChallenge struct {
+	ChallengeID string `json:"challenge_id"`
+	Image       string `json:"image"`      // data URL 格式的验证码图片
+	ExpiresIn   int    `json:"expires_in"` // 有效期（秒）
+}
+
+// pendingLogin 等待验证码的登录状态（密码仅保存 RSA 加密后的密文）
+type pendingLogin struct {
+	Uid             int                       `json:"uid"`
+	Username        string                    `json:"username"`
+	Password        string                    `json:"password"`
+	PasswordDigest  string                    `json:"password_digest"` // 学号和明文密码的摘要，绑定验证时校验再次提交的密码
+	Execution       string                    `json:"execution"`
+	FpVisitorId     string                    `json:"fp_visitor_id"`
+	Verify          bool                      `json:"verify"`
+	LoginURL        string                    `json:"login_url"` // 发起登录时所用访问方式下的地址
+	RedirectURL     string                    `json:"redirect_url"`
+	CaptchaImageURL string                    `json:"captcha_image_url"`
+	Cookies         map[string][]*http.Cookie `json:"cookies"` // 按地址保存的 cookie
+}
+
+// challengeCaptcha 拉取验证码图片，暂存登录状态（含 CAS cookie），返回带挑战信息的错误
+func (s *jwcSessionService) challengeCaptcha(ctx context.Context, client *loginClient, state *pendingLogin) error {
+	image, err := s.fetchCaptchaImage(ctx, client, state)
+	if err != nil {
+		return loginFailure(common.CodeJwcNeedCaptcha)
+	}
+
+	// 验证码与 CAS 会话绑定，cookie jar 需要随登录状态一起保存
+	state.Cookies = make(map[string][]*http.Cookie)
+	for _, rawURL := range []string{state.LoginURL, state.RedirectURL, state.CaptchaImageURL} {
+		if u, e := url.Parse(rawURL); e == nil {
+			state.Cookies[rawURL] = client.Jar.Cookies(u)
+		}
+	}
+
+	challengeID, err := randomToken()
+	if err != nil {
+		return common.NewAppError(common.CodeInternalError, "生成验证码挑战失败")
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return common.NewAppError(common.CodeInternalError, "保存登录状态失败")
+	}
+	if err := s.sessionCache.SetCaptchaChallenge(ctx, challengeID, data, captchaChallengeTTL); err != nil {
+		return common.NewAppError(common.CodeCacheError, "保存登录状态失败")
+	}
+
+	return common.NewAppError(common.CodeJwcNeedCaptcha, "教务系统要求输入验证码").WithData(&CaptchaChallenge{
+		ChallengeID: challengeID,
+		Image:       image,
+		ExpiresIn:   int(captchaChallengeTTL / time.Second),
+	})
+}
+
+// CompleteCaptchaLogin 使用暂存的登录状态和用户输入的验证码完成登录，返回登录的学号
+// 验证码错误时会重新发起挑战（返回新的 challenge_id）
+func (s *jwcSessionService) CompleteCaptchaLogin(ctx context.Context, uid int, challengeID, captcha string) (string, error) {
+	return s.completeCaptchaLogin(ctx, uid, challengeID, captcha, nil)
+}
+
+// CompleteCaptchaVerify 使用验证码完成绑定验证：学号和密码必须与发起挑战时一致，
+// 否则挑战作废且不提交登录（验证码只能证明发起挑战时的密码）
+func (s *jwcSessionService) CompleteCaptchaVerify(ctx context.Context, uid int, challengeID, captcha, username, password string) error {
+	_, err := s.completeCaptchaLogin(ctx, uid, challengeID, captcha, func(state *pendingLogin) bool {
+		return state.Verify && state.Username == username &&
+			subtle.ConstantTimeCompare([]byte(state.PasswordDigest), []byte(passwordDigest(username, password))) == 1
+	})
+	return err
+}
+
+// completeCaptchaLogin 取出暂存的登录状态（match 不为空时校验登录状态），提交验证码完成登录
+func (s *jwcSessionService) completeCaptchaLogin(ctx context.Context, uid int, challengeID, captcha string, match func(*pendingLogin) bool) (string, error) {
+	data, err := s.sessionCache.TakeCaptchaChallenge(ctx, challengeID)
+	if err != nil {
+		return "", common.NewAppError(common.CodeCacheError, "读取登录状态失败")
+	}
+
+	var state pendingLogin
+	if data == nil || json.Unmarshal(data, &state) != nil || state.Uid != uid {
+		return "", common.NewAppError(common.CodeCaptchaInvalid, "验证码已过期，请重新登录")
+	}
+	if match != nil && !match(&state) {
+		return "", common.NewAppError(common.CodeCaptchaInvalid, "账号或密码与获取验证码时不一致，请重新绑定")
+	}
+
+	client, err := s.newLoginClient()
+	if err != nil {
+		return "", err
+	}
+	for rawURL, cookies := range state.Cookies {
+		if u, e := url.Parse(rawURL); e == nil {
+			client.Jar.SetCookies(u, cookies)
+		}
+	}
+
+	err = s.submitLogin(ctx, client, &state, strings.TrimSpace(captcha))
+	if appErr, ok := err.(*common.AppError); ok && appErr.Code == common.CodeJwcNeedCaptcha {
+		// 验证码错误：CAS 会返回新的登录页，重新获取 execution 和验证码
+		if state.Execution, err = s.fetchExecution(ctx, client, state.LoginURL); err != nil {
+			return "", err
+		}
+		return "", s.challengeCaptcha(ctx, client, &state)
+	}
+
+	return state.Username, s.finishLogin(ctx, uid, state.Verify, err)
+}
+
+// fetchCaptchaImage 使用登录会话拉取验证码图片，返回 data URL
+func (s *jwcSessionService) fetchCaptchaImage(ctx context.Context, client *loginClient, state *pendingLogin) (string, error) {
+	if state.CaptchaImageURL == "" {
+		return "", common.NewAppError(common.CodeJwcLoginFailed, "未配置验证码地址")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", state.CaptchaImageURL+strconv.FormatInt(time.Now().UnixMilli(), 10), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0")
+	req.Header.Set("Referer", state.LoginURL)
+
+	res, err := client.Do(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", common.NewAppError(common.CodeJwcRequestFailed, "获取验证码失败")
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxCaptchaImageSize))
+	if err != nil || len(body) == 0 {
+		return "", common.NewAppError(common.CodeJwcRequestFailed, "获取验证码失败")
+	}
+
+	contentType := res.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") {
+		contentType = http.DetectContentType(body)
+	}
+	return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(body), nil
+}
+
+// passwordDigest 学号和明文密码的 SHA-256 摘要（暂存的登录状态只保存摘要，不保存明文）
+func passwordDigest(username, password string) string {
+	sum := sha256.Sum256([]byte(username + "\x00" + password))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomToken 生成随机令牌（验证码挑战 ID、登录锁持有者标识）
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	pkgerrors "spider-go/pkg/errors"
+	"strings"
+)
+
+// anonymousStudentID 录制时替换学号使用的占位学号
+const anonymousStudentID = "20200000001"
+
+// anonymizeRules 录制时去除页面中的个人信息（姓名、学号、身份证号）
+var anonymizeRules = []struct {
+	pattern *regexp.Regexp
+	replace string
+}{
+	{regexp.MustCompile(`(姓名[：:]\s*)[^\s<&]+`), "${1}张三"},
+	{regexp.MustCompile(`(学号[：:]\s*)\d+`), "${1}" + anonymousStudentID},
+	{regexp.MustCompile(`\b\d{17}[\dXx]\b`), "000000000000000000"},
+}
+
+// FixtureName 请求对应的夹具文件名（与主机无关，校园网和 WebVPN 录制的页面可以互相回放）
+// 格式：{路径最后一段}_{方法、路径和表单的摘要}.html
+func FixtureName(method, targetURL string, formData url.Values) string {
+	p := targetURL
+	if u, err := url.Parse(targetURL); err == nil {
+		p = u.Path
+	}
+
+	sum := sha1.Sum([]byte(strings.ToUpper(method) + " " + p + "?" + formData.Encode()))
+	base := strings.TrimSuffix(path.Base(p), path.Ext(p))
+	if base == "" || base == "." || base == "/" {
+		base = "index"
+	}
+	return base + "_" + hex.EncodeToString(sum[:4]) + ".html"
+}
+
+// recordingCrawlerService 录制模式：转发请求，并将匿名化后的响应保存为测试夹具
+type recordingCrawlerService struct {
+	inner CrawlerService
+	dir   string
+}
+
+// NewRecordingCrawlerService 创建录制模式的爬虫服务（dir 为夹具保存目录）
+func NewRecordingCrawlerService(inner CrawlerService, dir string) CrawlerService {
+	return &recordingCrawlerService{
+		inner: inner,
+		dir:   dir,
+	}
+}
+
+// FetchWithCookies 发起请求并录制响应
+func (r *recordingCrawlerService) FetchWithCookies(ctx context.Context, method, targetURL string, cookies []*http.Cookie, formData url.Values) (io.ReadCloser, error) {
+	body, err := r.inner.FetchWithCookies(ctx, method, targetURL, cookies, formData)
+	if err != nil {
+		return nil, err
+	}
+	return r.record(body, "", method, targetURL, formData)
+}
+
+// FetchWithSession 使用会话发起请求并录制响应
+func (r *recordingCrawlerService) FetchWithSession(ctx context.Context, account *JwcAccount, method, targetURL string, formData url.Values) (io.ReadCloser, error) {
+	body, err := r.inner.FetchWithSession(ctx, account, method, targetURL, formData)
+	if err != nil {
+		return nil, err
+	}
+	return r.record(body, account.Username, method, targetURL, formData)
+}
+
+// record 读取响应，保存匿名化的副本，返回原始内容（保存失败只记录日志）
+func (r *recordingCrawlerService) record(body io.ReadCloser, username, method, targetURL string, formData url.Values) (io.ReadCloser, error) {
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, maxPageSize))
+	if err != nil {
+		return nil, pkgerrors.NewAppError(pkgerrors.CodeJwcRequestFailed, err.Error())
+	}
+
+	name := FixtureName(method, targetURL, formData)
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		log.Printf("创建夹具目录失败: %v", err)
+	} else if err := os.WriteFile(filepath.Join(r.dir, name), AnonymizePage(data, username), 0o644); err != nil {
+		log.Printf("保存夹具失败 (%s): %v", name, err)
+	} else {
+		log.Printf("已录制 %s %s -> %s", method, targetURL, name)
+	}
+
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// AnonymizePage 去除页面中的个人信息（username 为当前账号的学号，为空时只做通用替换）
+func AnonymizePage(page []byte, username string) []byte {
+	if username != "" {
+		page = bytes.ReplaceAll(page, []byte(username), []byte(anonymousStudentID))
+	}
+	for _, rule := range anonymizeRules {
+		page = rule.pattern.ReplaceAll(page, []byte(rule.replace))
+	}
+	return page
+}
+
+// replayCrawlerService 回放模式：从夹具目录返回录制的响应，不访问网络
+type replayCrawlerService struct {
+	dir string
+}
+
+// NewReplayCrawlerService 创建回放模式的爬虫服务（dir 为夹具目录）
+func NewReplayCrawlerService(dir string) CrawlerService {
+	return &replayCrawlerService{dir: dir}
+}
+
+// FetchWithCookies 返回录制的响应
+func (r *replayCrawlerService) FetchWithCookies(ctx context.Context, method, targetURL string, cookies []*http.Cookie, formData url.Values) (io.ReadCloser, error) {
+	return r.replay(method, targetURL, formData)
+}
+
+// FetchWithSession 返回录制的响应
+func (r *replayCrawlerService) FetchWithSession(ctx context.Context, account *JwcAccount, method, targetURL string, formData url.Values) (io.ReadCloser, error) {
+	return r.replay(method, targetURL, formData)
+}
+
+// replay 读取夹具文件
+func (r *replayCrawlerService) replay(method, targetURL string, formData url.Values) (io.ReadCloser, error) {
+	name := FixtureName(method, targetURL, formData)
+	data, err := os.ReadFile(filepath.Join(r.dir, name))
+	if err != nil {
+		return nil, pkgerrors.NewAppError(pkgerrors.CodeJwcRequestFailed, fmt.Sprintf("未找到夹具 %s: %v", name, err))
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
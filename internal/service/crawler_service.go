@@ -1,7 +1,10 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
@@ -9,29 +12,118 @@ import (
 	pkghttpclient "spider-go/pkg/httpclient"
 )
 
+// maxPageSize 会话请求读取的最大页面大小
+const maxPageSize = 16 << 20
+
 // CrawlerService 爬虫服务接口（适配层）
 type CrawlerService interface {
 	// FetchWithCookies 使用 cookies 发起请求
 	FetchWithCookies(ctx context.Context, method, targetURL string, cookies []*http.Cookie, formData url.Values) (io.ReadCloser, error)
+	// FetchWithSession 使用用户的教务会话发起请求：没有会话时登录，会话过期时重新登录一次并重放请求
+	FetchWithSession(ctx context.Context, account *JwcAccount, method, targetURL string, formData url.Values) (io.ReadCloser, error)
+}
+
+// JwcAccount 用户的教务系统账号
+type JwcAccount struct {
+	Uid      int
+	Username string // 学号
+	Password string // 教务系统密码
 }
 
 // crawlerServiceAdapter 爬虫服务适配器
 type crawlerServiceAdapter struct {
-	crawler pkghttpclient.Crawler
+	crawler        pkghttpclient.Crawler
+	strategy       LoginStrategy
+	sessionService SessionService
 }
 
-// NewHttpCrawlerService 创建 HTTP 爬虫服务（适配 pkg/httpclient）
-func NewHttpCrawlerService() CrawlerService {
+// NewHttpCrawlerService 创建 HTTP 爬虫服务（适配 pkg/httpclient，请求地址按访问策略改写，经共享的出站客户端限流和熔断）
+func NewHttpCrawlerService(strategy LoginStrategy, sessionService SessionService, client pkghttpclient.Client) CrawlerService {
 	return &crawlerServiceAdapter{
-		crawler: pkghttpclient.NewCrawler(),
+		crawler:        pkghttpclient.NewCrawler(client),
+		strategy:       strategy,
+		sessionService: sessionService,
 	}
 }
 
 // FetchWithCookies 使用 cookies 发起请求
 func (a *crawlerServiceAdapter) FetchWithCookies(ctx context.Context, method, targetURL string, cookies []*http.Cookie, formData url.Values) (io.ReadCloser, error) {
+	// 只改写携带教务会话的请求，匿名请求（如电费查询）没有 WebVPN 会话，保持原地址
+	if len(cookies) > 0 {
+		targetURL = a.strategy.Resolve(ctx).RewriteURL(targetURL)
+	}
 	body, err := a.crawler.FetchWithCookies(ctx, method, targetURL, cookies, formData)
 	if err != nil {
-		return nil, pkgerrors.NewAppError(pkgerrors.CodeJwcRequestFailed, err.Error())
+		return nil, requestError(err)
 	}
 	return body, nil
 }
+
+// FetchWithSession 使用用户的教务会话发起请求
+// 教务系统会话经常早于缓存过期，此时请求会被重定向到登录页（HTTP 200），
+// 识别后清除缓存的会话、重新登录一次并重放请求，避免解析器把登录页报告为"页面错误"
+func (a *crawlerServiceAdapter) FetchWithSession(ctx context.Context, account *JwcAccount, method, targetURL string, formData url.Values) (io.ReadCloser, error) {
+	cookies, err := a.sessionService.AcquireCookies(ctx, account.Uid, account.Username, account.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	page, expired, err := a.fetchPage(ctx, method, targetURL, cookies, formData)
+	if err != nil {
+		return nil, err
+	}
+	if !expired {
+		return page, nil
+	}
+
+	// 会话已过期：只清除这一份失效的会话（并发请求可能已经重新登录）
+	if err := a.sessionService.ExpireSession(ctx, account.Uid, cookies); err != nil {
+		return nil, pkgerrors.NewAppError(pkgerrors.CodeCacheError, "清除会话失败")
+	}
+	cookies, err = a.sessionService.AcquireCookies(ctx, account.Uid, account.Username, account.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	page, expired, err = a.fetchPage(ctx, method, targetURL, cookies, formData)
+	if err != nil {
+		return nil, err
+	}
+	if expired {
+		return nil, pkgerrors.NewAppError(pkgerrors.CodeJwcLoginFailed, "教务系统会话无效，请稍后重试")
+	}
+	return page, nil
+}
+
+// fetchPage 发起请求并读取完整页面，同时判断会话是否已过期
+func (a *crawlerServiceAdapter) fetchPage(ctx context.Context, method, targetURL string, cookies []*http.Cookie, formData url.Values) (io.ReadCloser, bool, error) {
+	targetURL = a.strategy.Resolve(ctx).RewriteURL(targetURL)
+
+	resp, err := a.crawler.Do(ctx, method, targetURL, cookies, formData)
+	if err != nil {
+		return nil, false, requestError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, pkgerrors.NewAppError(pkgerrors.CodeJwcRequestFailed, fmt.Sprintf("unexpected status code: %d", resp.StatusCode))
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPageSize))
+	if err != nil {
+		return nil, false, pkgerrors.NewAppError(pkgerrors.CodeJwcRequestFailed, err.Error())
+	}
+
+	if isSessionExpired(resp.Request.URL, body) {
+		return nil, true, nil
+	}
+	return io.NopCloser(bytes.NewReader(body)), false, nil
+}
+
+// requestError 将请求错误转换为业务错误（熔断中单独区分，便于调用方返回缓存数据）
+func requestError(err error) error {
+	if errors.Is(err, pkghttpclient.ErrCircuitOpen) {
+		return pkgerrors.NewAppError(pkgerrors.CodeJwcUnavailable, "教务系统暂时无法访问，请稍后再试")
+	}
+	return pkgerrors.NewAppError(pkgerrors.CodeJwcRequestFailed, err.Error())
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"spider-go/internal/common"
+	"spider-go/pkg/envelope"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CredentialKey 凭据数据密钥（由主密钥加密后存储）
+type CredentialKey struct {
+	Version    int       `gorm:"primaryKey;autoIncrement:false"`
+	WrappedKey string    `gorm:"type:varchar(255);not null"`
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName 指定表名
+func (CredentialKey) TableName() string {
+	return "credential_keys"
+}
+
+// CredentialService 凭据加密服务接口（教务系统密码等敏感信息的静态加密）
+type CredentialService interface {
+	// Encrypt 使用当前数据密钥加密
+	Encrypt(ctx context.Context, plaintext string) (string, error)
+	// Decrypt 解密（兼容尚未迁移的明文）
+	Decrypt(ctx context.Context, value string) (string, error)
+	// NeedsReEncrypt 判断是否需要重新加密（明文或旧版本密钥加密）
+	NeedsReEncrypt(value string) bool
+	// RotateKey 生成新版本数据密钥并设为当前密钥
+	RotateKey(ctx context.Context) (int, error)
+	// ActiveVersion 获取当前数据密钥版本
+	ActiveVersion() int
+}
+
+// credentialServiceImpl 凭据加密服务实现
+type credentialServiceImpl struct {
+	db       *gorm.DB
+	keyRing  *envelope.KeyRing
+	reloadMu sync.Mutex
+}
+
+// NewCredentialService 创建凭据加密服务（加载已有数据密钥，首次启动时生成 v1）
+func NewCredentialService(db *gorm.DB, masterKey string) (CredentialService, error) {
+	keyRing, err := envelope.NewKeyRing(masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("创建密钥环失败: %w", err)
+	}
+
+	s := &credentialServiceImpl{
+		db:      db,
+		keyRing: keyRing,
+	}
+
+	ctx := context.Background()
+	if err := s.loadKeys(ctx); err != nil {
+		return nil, err
+	}
+
+	if s.keyRing.ActiveVersion() == 0 {
+		if _, err := s.RotateKey(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Encrypt 使用当前数据密钥加密
+func (s *credentialServiceImpl) Encrypt(ctx context.Context, plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	ciphertext, err := s.keyRing.Encrypt(plaintext)
+	if err != nil {
+		return "", common.NewAppError(common.CodeInternalError, fmt.Sprintf("加密凭据失败: %v", err))
+	}
+	return ciphertext, nil
+}
+
+// Decrypt 解密（兼容尚未迁移的明文）
+func (s *credentialServiceImpl) Decrypt(ctx context.Context, value string) (string, error) {
+	if value == "" || !envelope.IsEncrypted(value) {
+		return value, nil
+	}
+
+	// 其他实例可能已经轮换了密钥，本地没有该版本时重新加载
+	if version, _ := envelope.VersionOf(value); !s.keyRing.HasVersion(version) {
+		if err := s.loadKeys(ctx); err != nil {
+			return "", err
+		}
+	}
+
+	plaintext, err := s.keyRing.Decrypt(value)
+	if err != nil {
+		return "", common.NewAppError(common.CodeInternalError, fmt.Sprintf("解密凭据失败: %v", err))
+	}
+	return plaintext, nil
+}
+
+// NeedsReEncrypt 判断是否需要重新加密
+func (s *credentialServiceImpl) NeedsReEncrypt(value string) bool {
+	if value == "" {
+		return false
+	}
+
+	version, ok := envelope.VersionOf(value)
+	if !ok {
+		return true // 明文
+	}
+	return version < s.keyRing.ActiveVersion()
+}
+
+// RotateKey 生成新版本数据密钥并设为当前密钥
+func (s *credentialServiceImpl) RotateKey(ctx context.Context) (int, error) {
+	wrapped, err := s.keyRing.GenerateWrappedKey()
+	if err != nil {
+		return 0, common.NewAppError(common.CodeInternalError, fmt.Sprintf("生成数据密钥失败: %v", err))
+	}
+
+	var key CredentialKey
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var maxVersion int
+		if err := tx.Model(&CredentialKey{}).Select("COALESCE(MAX(version), 0)").Scan(&maxVersion).Error; err != nil {
+			return err
+		}
+
+		key = CredentialKey{
+			Version:    maxVersion + 1,
+			WrappedKey: wrapped,
+		}
+		return tx.Create(&key).Error
+	})
+	if err != nil {
+		return 0, common.NewAppError(common.CodeInternalError, fmt.Sprintf("保存数据密钥失败: %v", err))
+	}
+
+	if err := s.keyRing.AddWrappedKey(key.Version, key.WrappedKey); err != nil {
+		return 0, common.NewAppError(common.CodeInternalError, err.Error())
+	}
+
+	log.Printf("凭据数据密钥已轮换，当前版本: v%d", key.Version)
+	return key.Version, nil
+}
+
+// ActiveVersion 获取当前数据密钥版本
+func (s *credentialServiceImpl) ActiveVersion() int {
+	return s.keyRing.ActiveVersion()
+}
+
+// loadKeys 从数据库加载所有数据密钥
+func (s *credentialServiceImpl) loadKeys(ctx context.Context) error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	var keys []CredentialKey
+	if err := s.db.WithContext(ctx).Order("version ASC").Find(&keys).Error; err != nil {
+		return fmt.Errorf("加载数据密钥失败: %w", err)
+	}
+
+	for _, k := range keys {
+		if s.keyRing.HasVersion(k.Version) {
+			continue
+		}
+		if err := s.keyRing.AddWrappedKey(k.Version, k.WrappedKey); err != nil {
+			if errors.Is(err, envelope.ErrInvalidCiphertext) {
+				return fmt.Errorf("数据密钥 v%d 无法解开，请检查主密钥配置", k.Version)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package jwctest
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// updateEnv 设置该环境变量时重新生成 golden 文件：UPDATE_GOLDEN=1 go test ./...
+// 不使用 -update 参数：未导入本包的测试包不认识该参数，go test ./... -update 会直接失败
+const updateEnv = "UPDATE_GOLDEN"
+
+// ReadFixture 读取测试夹具
+func ReadFixture(t testing.TB, path string) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read fixture %s: %v", path, err)
+	}
+	return data
+}
+
+// AssertGolden 将 got 编码为 JSON 与 golden 文件比对（设置 UPDATE_GOLDEN 时改写 golden 文件）
+func AssertGolden(t testing.TB, path string, got interface{}) {
+	t.Helper()
+
+	data, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		t.Fatalf("marshal %s: %v", path, err)
+	}
+	data = append(data, '\n')
+
+	if os.Getenv(updateEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("create golden dir: %v", err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatalf("write golden %s: %v", path, err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden %s (run with UPDATE_GOLDEN=1 to create): %v", path, err)
+	}
+	if !bytes.Equal(want, data) {
+		t.Errorf("%s mismatch (run with UPDATE_GOLDEN=1 if the change is expected)\n--- want\n%s\n--- got\n%s", path, want, data)
+	}
+}
@@ -0,0 +1,275 @@
+// Package jwctest 提供模拟 CAS 统一身份认证和教务系统的 httptest 服务器，用于离线测试登录流程和爬虫
+package jwctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// 模拟服务器的路径（与真实 CAS、教务系统一致，会话过期识别依赖这些路径）
+const (
+	LoginPath        = "/cas/login"
+	PublicKeyPath    = "/cas/jwt/publicKey"
+	NeedCaptchaPath  = "/cas/needCaptcha.html"
+	CaptchaImagePath = "/cas/captcha.html"
+	RedirectPath     = "/Logon.do"
+	MainPath         = "/jsxsd/framework/xsMain.jsp"
+)
+
+// captchaImage 验证码图片（1x1 GIF）
+var captchaImage, _ = base64.StdEncoding.DecodeString("R0lGODlhAQABAIAAAAAAAP///ywAAAAAAQABAAACAUwAOw==")
+
+// Server 模拟的 CAS + 教务系统
+// 登录流程：GET /cas/login 取 execution → POST /cas/login（RSA 加密的密码）→ 302 并下发 CASTGC →
+// GET /Logon.do 校验 CASTGC 后下发 JSESSIONID → 访问 Pages 中注册的页面
+type Server struct {
+	*httptest.Server
+
+	Username string
+	Password string
+	Captcha  string // 非空时要求输入验证码
+
+	privateKey *rsa.PrivateKey
+	publicKey  string
+
+	mu       sync.Mutex
+	pages    map[string]string // 路径 -> 页面内容
+	tickets  map[string]bool   // 有效的 CASTGC
+	sessions map[string]bool   // 有效的 JSESSIONID
+	seq      int
+	logins   int
+	failures int
+}
+
+// NewServer 启动模拟服务器（使用完毕后调用 Close）
+func NewServer(username, password string) *Server {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		panic(fmt.Sprintf("jwctest: generate rsa key: %v", err))
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		panic(fmt.Sprintf("jwctest: marshal public key: %v", err))
+	}
+
+	s := &Server{
+		Username:   username,
+		Password:   password,
+		privateKey: key,
+		publicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+		pages:      make(map[string]string),
+		tickets:    make(map[string]bool),
+		sessions:   make(map[string]bool),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(LoginPath, s.handleLogin)
+	mux.HandleFunc(PublicKeyPath, s.handlePublicKey)
+	mux.HandleFunc(NeedCaptchaPath, s.handleNeedCaptcha)
+	mux.HandleFunc(CaptchaImagePath, s.handleCaptchaImage)
+	mux.HandleFunc(RedirectPath, s.handleRedirect)
+	mux.HandleFunc("/jsxsd/", s.handlePage)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// PublicKey RSA 公钥（PEM）
+func (s *Server) PublicKey() string {
+	return s.publicKey
+}
+
+// LoginURL 登录地址
+func (s *Server) LoginURL() string {
+	return s.URL + LoginPath + "?service=" + url.QueryEscape(s.RedirectURL())
+}
+
+// RedirectURL 登录成功后进入教务系统的地址
+func (s *Server) RedirectURL() string {
+	return s.URL + RedirectPath + "?method=logonByZnlkd"
+}
+
+// CaptchaURL needCaptcha 接口地址（与配置一致，以 ? 结尾）
+func (s *Server) CaptchaURL() string {
+	return s.URL + NeedCaptchaPath + "?"
+}
+
+// CaptchaImageURL 验证码图片地址（与配置一致，以 ? 结尾）
+func (s *Server) CaptchaImageURL() string {
+	return s.URL + CaptchaImagePath + "?"
+}
+
+// PageURL 教务系统页面地址
+func (s *Server) PageURL(path string) string {
+	return s.URL + path
+}
+
+// SetPage 注册教务系统页面（path 以 /jsxsd/ 开头），需持有有效会话才能访问
+func (s *Server) SetPage(path, html string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pages[path] = html
+}
+
+// ExpireSessions 使所有教务系统会话失效（模拟会话早于缓存过期）
+func (s *Server) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions = make(map[string]bool)
+}
+
+// Logins 成功登录次数
+func (s *Server) Logins() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.logins
+}
+
+// Failures 登录失败次数
+func (s *Server) Failures() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.failures
+}
+
+// handleLogin GET 返回登录页，POST 校验账号密码
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeLoginPage(w, "")
+		return
+	}
+
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("execution") == "" {
+		writeLoginPage(w, "非法请求")
+		return
+	}
+	if s.Captcha != "" && r.PostForm.Get("captcha") != s.Captcha {
+		s.fail()
+		writeLoginPage(w, "验证码错误")
+		return
+	}
+	if r.PostForm.Get("username") != s.Username || s.decryptPassword(r.PostForm.Get("password")) != s.Password {
+		s.fail()
+		writeLoginPage(w, "用户名或密码错误")
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{Name: "CASTGC", Value: s.issue(s.tickets, "TGT"), Path: "/"})
+	service := r.URL.Query().Get("service")
+	if service == "" {
+		service = s.RedirectURL()
+	}
+	http.Redirect(w, r, service, http.StatusFound)
+}
+
+// handlePublicKey 返回 RSA 公钥
+func (s *Server) handlePublicKey(w http.ResponseWriter, r *http.Request) {
+	_, _ = w.Write([]byte(s.publicKey))
+}
+
+// handleNeedCaptcha 返回是否需要验证码
+func (s *Server) handleNeedCaptcha(w http.ResponseWriter, r *http.Request) {
+	_, _ = fmt.Fprint(w, s.Captcha != "")
+}
+
+// handleCaptchaImage 返回验证码图片
+func (s *Server) handleCaptchaImage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "image/gif")
+	_, _ = w.Write(captchaImage)
+}
+
+// handleRedirect 校验 CASTGC 后下发教务系统会话，再跳转到首页
+func (s *Server) handleRedirect(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie("CASTGC")
+	if err != nil || !s.valid(s.tickets, cookie.Value) {
+		http.Redirect(w, r, s.LoginURL(), http.StatusFound)
+		return
+	}
+
+	s.mu.Lock()
+	s.logins++
+	s.mu.Unlock()
+
+	http.SetCookie(w, &http.Cookie{Name: "JSESSIONID", Value: s.issue(s.sessions, "JS"), Path: "/"})
+	http.Redirect(w, r, MainPath, http.StatusFound)
+}
+
+// handlePage 返回教务系统页面，会话无效时跳转到 CAS 登录页
+func (s *Server) handlePage(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie("JSESSIONID")
+	if err != nil || !s.valid(s.sessions, cookie.Value) {
+		http.Redirect(w, r, s.LoginURL(), http.StatusFound)
+		return
+	}
+
+	if r.URL.Path == MainPath {
+		_, _ = w.Write([]byte("<html><head><title>学生个人中心</title></head><body></body></html>"))
+		return
+	}
+
+	s.mu.Lock()
+	page, ok := s.pages[r.URL.Path]
+	s.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html;charset=UTF-8")
+	_, _ = w.Write([]byte(page))
+}
+
+// decryptPassword 解密 "__RSA__" + Base64 格式的密码
+func (s *Server) decryptPassword(encrypted string) string {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encrypted, "__RSA__"))
+	if err != nil {
+		return ""
+	}
+	plain, err := rsa.DecryptPKCS1v15(rand.Reader, s.privateKey, data)
+	if err != nil {
+		return ""
+	}
+	return string(plain)
+}
+
+// issue 生成令牌并记录为有效
+func (s *Server) issue(store map[string]bool, prefix string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	token := fmt.Sprintf("%s-%d", prefix, s.seq)
+	store[token] = true
+	return token
+}
+
+// valid 令牌是否有效
+func (s *Server) valid(store map[string]bool, token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return store[token]
+}
+
+// fail 记录一次登录失败
+func (s *Server) fail() {
+	s.mu.Lock()
+	s.failures++
+	s.mu.Unlock()
+}
+
+// writeLoginPage 输出 CAS 登录页（errMsg 非空时显示错误提示）
+func writeLoginPage(w http.ResponseWriter, errMsg string) {
+	w.Header().Set("Content-Type", "text/html;charset=UTF-8")
+	_, _ = fmt.Fprintf(w, `<html><head><title>统一身份认证</title></head><body>
+<form id="fm1" method="post">
+<span id="showErrorTip">%s</span>
+<input type="hidden" name="execution" value="e1s1"/>
+<input type="hidden" name="_eventId" value="submit"/>
+</form></body></html>`, errMsg)
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"spider-go/internal/common"
+	"spider-go/pkg/httpclient"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// loginErrorSelectors CAS 登录页中显示错误提示的元素
+var loginErrorSelectors = []string{"#showErrorTip", "#errorMsg", "#msg", ".errorMessage", ".auth_error", "span.error"}
+
+// loginFailureRule CAS 登录失败原因的识别规则
+type loginFailureRule struct {
+	code     int
+	message  string   // 返回给前端的提示
+	keywords []string // 错误提示中的关键字（小写）
+}
+
+// loginFailureRules 按顺序匹配（如"密码错误次数过多，账号已锁定"应识别为锁定而不是密码错误）
+var loginFailureRules = []loginFailureRule{
+	{
+		code:     common.CodeJwcAccountLocked,
+		message:  "教务系统账号已被锁定，请稍后再试或到统一身份认证平台解锁",
+		keywords: []string{"锁定", "冻结", "locked", "次数过多"},
+	},
+	{
+		code:     common.CodeJwcNeedCaptcha,
+		message:  "教务系统要求输入验证码",
+		keywords: []string{"验证码", "captcha"},
+	},
+	{
+		code:     common.CodeJwcPwdExpired,
+		message:  "教务系统密码已过期，请先到统一身份认证平台修改密码后重新绑定",
+		keywords: []string{"密码已过期", "密码过期", "修改初始密码", "初始密码", "expired"},
+	},
+	{
+		code:     common.CodeJwcWrongPassword,
+		message:  "教务系统账号或密码错误",
+		keywords: []string{"密码错误", "密码有误", "用户名或密码", "用户名或者密码", "账号或密码", "帐号或密码", "认证失败", "用户不存在", "用户名不存在", "账号不存在", "帐号不存在", "invalid credentials", "bad credentials"},
+	},
+	{
+		code:     common.CodeJwcMaintenance,
+		message:  "教务系统维护中，请稍后再试",
+		keywords: []string{"维护", "升级", "暂停服务", "maintenance"},
+	},
+}
+
+// loginFailure 按失败原因构造错误
+func loginFailure(code int) error {
+	for _, rule := range loginFailureRules {
+		if rule.code == code {
+			return common.NewAppError(rule.code, rule.message)
+		}
+	}
+	return common.NewAppError(common.CodeJwcLoginFailed, "登录失败")
+}
+
+// loginRequestError 登录请求失败：熔断打开时返回教务系统不可用（与 requestError 一致），其他错误返回登录失败
+func loginRequestError(err error, message string) error {
+	if errors.Is(err, httpclient.ErrCircuitOpen) {
+		return common.NewAppError(common.CodeJwcUnavailable, "教务系统暂时无法访问，请稍后再试")
+	}
+	return common.NewAppError(common.CodeJwcLoginFailed, message)
+}
+
+// isDefiniteLoginFailure 是否为已明确原因的登录失败（不应重试；熔断打开时重试只会被立即拒绝）
+func isDefiniteLoginFailure(err error) bool {
+	appErr, ok := err.(*common.AppError)
+	if !ok {
+		return false
+	}
+	if appErr.Code == common.CodeJwcUnavailable {
+		return true
+	}
+	for _, rule := range loginFailureRules {
+		if rule.code == appErr.Code {
+			return true
+		}
+	}
+	return false
+}
+
+// checkLoginResponse 检查 CAS 登录提交的响应：成功时返回 302，失败时返回带错误提示的登录页
+func checkLoginResponse(resp *http.Response) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusFound {
+		return nil
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return loginFailure(common.CodeJwcMaintenance)
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		if doc, err := goquery.NewDocumentFromReader(resp.Body); err == nil {
+			if err := classifyLoginPage(doc); err != nil {
+				return err
+			}
+			// 无法识别的提示原样返回，便于排查
+			if msg := loginErrorMessage(doc); msg != "" {
+				return common.NewAppError(common.CodeJwcLoginFailed, msg)
+			}
+		}
+	}
+
+	return common.NewAppError(common.CodeJwcLoginFailed, "重定向并非302")
+}
+
+// classifyLoginPage 根据页面中的错误提示识别失败原因，无法识别时返回 nil
+func classifyLoginPage(doc *goquery.Document) error {
+	msg := strings.ToLower(loginErrorMessage(doc))
+	if msg == "" {
+		// 维护公告页通常没有错误提示元素，只检查标题
+		msg = strings.ToLower(doc.Find("title").Text())
+		if containsAny(msg, []string{"维护", "升级", "maintenance"}) {
+			return loginFailure(common.CodeJwcMaintenance)
+		}
+		return nil
+	}
+
+	for _, rule := range loginFailureRules {
+		if containsAny(msg, rule.keywords) {
+			return common.NewAppError(rule.code, rule.message)
+		}
+	}
+	return nil
+}
+
+// loginErrorMessage 提取登录页中的错误提示
+func loginErrorMessage(doc *goquery.Document) string {
+	for _, sel := range loginErrorSelectors {
+		if msg := strings.TrimSpace(doc.Find(sel).First().Text()); msg != "" {
+			return msg
+		}
+	}
+	return ""
+}
+
+// containsAny 判断 s 是否包含任一关键字
+func containsAny(s string, keywords []string) bool {
+	for _, k := range keywords {
+		if strings.Contains(s, k) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"io"
+	"net/http"
+	"spider-go/internal/common"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// loginErrorPage 构造带错误提示的 CAS 登录页
+func loginErrorPage(selector, msg string) string {
+	selector = strings.TrimPrefix(selector, "span")
+	attr := `id="` + strings.TrimPrefix(selector, "#") + `"`
+	if strings.HasPrefix(selector, ".") {
+		attr = `class="` + strings.TrimPrefix(selector, ".") + `"`
+	}
+	return `<html><head><title>统一身份认证</title></head><body><form><span ` + attr + `>` + msg + `</span></form></body></html>`
+}
+
+func TestClassifyLoginPage(t *testing.T) {
+	tests := []struct {
+		name     string
+		page     string
+		wantCode int // 0 表示无法识别
+	}{
+		{name: "wrong password", page: loginErrorPage("#showErrorTip", "用户名或密码错误"), wantCode: common.CodeJwcWrongPassword},
+		{name: "unknown user", page: loginErrorPage("#errorMsg", "用户不存在，请确认学号"), wantCode: common.CodeJwcWrongPassword},
+		{name: "unknown account", page: loginErrorPage("#msg", "该账号不存在"), wantCode: common.CodeJwcWrongPassword},
+		{name: "english credentials", page: loginErrorPage(".errorMessage", "Invalid credentials."), wantCode: common.CodeJwcWrongPassword},
+		{name: "locked before wrong password", page: loginErrorPage("#showErrorTip", "密码错误次数过多，账号已锁定"), wantCode: common.CodeJwcAccountLocked},
+		{name: "captcha", page: loginErrorPage(".auth_error", "请输入验证码"), wantCode: common.CodeJwcNeedCaptcha},
+		{name: "password expired", page: loginErrorPage("span.error", "密码已过期，请修改密码"), wantCode: common.CodeJwcPwdExpired},
+		{name: "maintenance tip", page: loginErrorPage("#showErrorTip", "系统维护中"), wantCode: common.CodeJwcMaintenance},
+		{name: "maintenance title", page: `<html><head><title>系统升级公告</title></head><body>今晚 22:00 起暂停服务</body></html>`, wantCode: common.CodeJwcMaintenance},
+
+		// 只含"不存在"的提示与账号密码无关，不能认定为密码错误（否则会标记绑定失效）
+		{name: "service not found", page: loginErrorPage("#showErrorTip", "请求的服务不存在"), wantCode: 0},
+		{name: "page not found", page: loginErrorPage("#msg", "页面不存在或已过期"), wantCode: 0},
+		{name: "plain login page", page: `<html><head><title>统一身份认证</title></head><body><input name="execution" value="e1s1"/></body></html>`, wantCode: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.page))
+			if err != nil {
+				t.Fatalf("parse page: %v", err)
+			}
+			err = classifyLoginPage(doc)
+			if tt.wantCode == 0 {
+				if err != nil {
+					t.Errorf("got %v, want unclassified", err)
+				}
+				return
+			}
+			if appErr, ok := err.(*common.AppError); !ok || appErr.Code != tt.wantCode {
+				t.Errorf("got %v, want code %d", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestLoginFailureRuleKeywords(t *testing.T) {
+	seen := make(map[string]int)
+	for _, rule := range loginFailureRules {
+		if rule.message == "" || len(rule.keywords) == 0 {
+			t.Errorf("rule %d has no message or keywords", rule.code)
+		}
+		for _, k := range rule.keywords {
+			// 提示先转为小写再匹配，关键字必须是小写
+			if k != strings.ToLower(k) {
+				t.Errorf("keyword %q of rule %d is not lower case", k, rule.code)
+			}
+			if code, ok := seen[k]; ok {
+				t.Errorf("keyword %q used by rules %d and %d", k, code, rule.code)
+			}
+			seen[k] = rule.code
+
+			// 每个关键字都应识别为所在规则（不被前面的规则抢先匹配）
+			doc, _ := goquery.NewDocumentFromReader(strings.NewReader(loginErrorPage("#showErrorTip", k)))
+			if appErr, ok := classifyLoginPage(doc).(*common.AppError); !ok || appErr.Code != rule.code {
+				t.Errorf("keyword %q classified as %v, want %d", k, appErr, rule.code)
+			}
+		}
+	}
+
+	// 过于宽泛的关键字会把无关提示误判为密码错误
+	for _, k := range []string{"不存在", "错误", "失败"} {
+		if _, ok := seen[k]; ok {
+			t.Errorf("keyword %q is too broad", k)
+		}
+	}
+}
+
+func TestCheckLoginResponse(t *testing.T) {
+	response := func(status int, body string) *http.Response {
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body))}
+	}
+
+	tests := []struct {
+		name     string
+		resp     *http.Response
+		wantCode int // 0 表示成功
+		wantMsg  string
+	}{
+		{name: "redirect", resp: response(http.StatusFound, ""), wantCode: 0},
+		{name: "server error", resp: response(http.StatusBadGateway, ""), wantCode: common.CodeJwcMaintenance},
+		{name: "wrong password", resp: response(http.StatusOK, loginErrorPage("#showErrorTip", "账号或密码错误")), wantCode: common.CodeJwcWrongPassword},
+		{name: "unknown tip returned as is", resp: response(http.StatusOK, loginErrorPage("#showErrorTip", "请求的服务不存在")), wantCode: common.CodeJwcLoginFailed, wantMsg: "请求的服务不存在"},
+		{name: "no tip", resp: response(http.StatusOK, "<html></html>"), wantCode: common.CodeJwcLoginFailed, wantMsg: "重定向并非302"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkLoginResponse(tt.resp)
+			if tt.wantCode == 0 {
+				if err != nil {
+					t.Errorf("got %v, want success", err)
+				}
+				return
+			}
+			appErr, ok := err.(*common.AppError)
+			if !ok || appErr.Code != tt.wantCode || (tt.wantMsg != "" && appErr.Message != tt.wantMsg) {
+				t.Errorf("got %v, want code %d %q", err, tt.wantCode, tt.wantMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/url"
+	"spider-go/pkg/webvpn"
+	"sync"
+	"time"
+)
+
+// 登录策略名称
+const (
+	StrategyCampus = "campus" // 校园网直连
+	StrategyWebVPN = "webvpn" // 通过 WebVPN 访问
+	StrategyAuto   = "auto"   // 自动探测，校园网不可达时使用 WebVPN
+)
+
+// LoginStrategy 教务系统访问策略：决定 CAS 登录地址、教务系统入口以及业务地址的改写方式
+type LoginStrategy interface {
+	// Name 策略名称
+	Name() string
+	// Resolve 返回本次访问实际使用的策略（auto 策略会探测校园网是否可达）
+	Resolve(ctx context.Context) LoginStrategy
+	// LoginURL CAS 登录地址
+	LoginURL() string
+	// RedirectURL 登录后进入教务系统的地址
+	RedirectURL() string
+	// RewriteURL 将校园网地址改写为当前策略可访问的地址
+	RewriteURL(rawURL string) string
+}
+
+// LoginEndpoints 某种访问方式下的登录地址
+type LoginEndpoints struct {
+	LoginURL    string
+	RedirectURL string
+}
+
+// NewLoginStrategy 按名称创建登录策略（未知名称按校园网处理）
+// campus 为校园网地址；WebVPN 的教务系统入口由校园网地址编码得到，只有登录入口需要单独配置
+func NewLoginStrategy(name string, campus LoginEndpoints, vpnLoginURL string, translator *webvpn.Translator) LoginStrategy {
+	campusStrategy := &campusLoginStrategy{endpoints: campus, translator: translator}
+	vpnStrategy := &webvpnLoginStrategy{
+		endpoints: LoginEndpoints{
+			LoginURL:    vpnLoginURL,
+			RedirectURL: translator.Encode(campus.RedirectURL),
+		},
+		translator: translator,
+	}
+
+	switch name {
+	case StrategyWebVPN:
+		return vpnStrategy
+	case StrategyAuto:
+		auto := &autoLoginStrategy{
+			campus:   campusStrategy,
+			webvpn:   vpnStrategy,
+			probeURL: campus.RedirectURL,
+			interval: 5 * time.Minute,
+			timeout:  3 * time.Second,
+		}
+		auto.probe = auto.campusReachable
+		return auto
+	default:
+		return campusStrategy
+	}
+}
+
+// campusLoginStrategy 校园网直连
+type campusLoginStrategy struct {
+	endpoints  LoginEndpoints
+	translator *webvpn.Translator
+}
+
+// Name 策略名称
+func (s *campusLoginStrategy) Name() string {
+	return StrategyCampus
+}
+
+// Resolve 校园网策略固定返回自身
+func (s *campusLoginStrategy) Resolve(ctx context.Context) LoginStrategy {
+	return s
+}
+
+// LoginURL CAS 登录地址
+func (s *campusLoginStrategy) LoginURL() string {
+	return s.endpoints.LoginURL
+}
+
+// RedirectURL 登录后进入教务系统的地址
+func (s *campusLoginStrategy) RedirectURL() string {
+	return s.endpoints.RedirectURL
+}
+
+// RewriteURL 校园网内直接访问（页面中残留的 WebVPN 地址还原为校园网地址）
+func (s *campusLoginStrategy) RewriteURL(rawURL string) string {
+	return s.translator.Decode(rawURL)
+}
+
+// webvpnLoginStrategy 通过 WebVPN 访问，校园网地址按主机名编码规则改写
+type webvpnLoginStrategy struct {
+	endpoints  LoginEndpoints
+	translator *webvpn.Translator
+}
+
+// Name 策略名称
+func (s *webvpnLoginStrategy) Name() string {
+	return StrategyWebVPN
+}
+
+// Resolve WebVPN 策略固定返回自身
+func (s *webvpnLoginStrategy) Resolve(ctx context.Context) LoginStrategy {
+	return s
+}
+
+// LoginURL CAS 登录地址
+func (s *webvpnLoginStrategy) LoginURL() string {
+	return s.endpoints.LoginURL
+}
+
+// RedirectURL 登录后进入教务系统的地址
+func (s *webvpnLoginStrategy) RedirectURL() string {
+	return s.endpoints.RedirectURL
+}
+
+// RewriteURL 将校园网地址编码为 WebVPN 地址
+func (s *webvpnLoginStrategy) RewriteURL(rawURL string) string {
+	return s.translator.Encode(rawURL)
+}
+
+// autoLoginStrategy 定期探测校园网教务系统是否可达，不可达时回退到 WebVPN
+// 切换后旧方式缓存的会话会失效，由会话过期检测重新登录
+// 探测在后台进行且同一时间只有一个，探测期间调用方继续使用上一次的结果，不会阻塞在建立连接上
+type autoLoginStrategy struct {
+	campus   LoginStrategy
+	webvpn   LoginStrategy
+	probeURL string
+	interval time.Duration
+	timeout  time.Duration
+	probe    func() bool // 校园网是否可达
+
+	mu        sync.Mutex
+	current   LoginStrategy
+	checkedAt time.Time
+	probing   chan struct{} // 正在进行的探测，完成时关闭；为 nil 表示没有探测
+}
+
+// Name 策略名称
+func (s *autoLoginStrategy) Name() string {
+	return StrategyAuto
+}
+
+// LoginURL 当前可用策略的 CAS 登录地址
+func (s *autoLoginStrategy) LoginURL() string {
+	return s.Resolve(context.Background()).LoginURL()
+}
+
+// RedirectURL 当前可用策略的教务系统入口
+func (s *autoLoginStrategy) RedirectURL() string {
+	return s.Resolve(context.Background()).RedirectURL()
+}
+
+// RewriteURL 按当前可用策略改写地址
+func (s *autoLoginStrategy) RewriteURL(rawURL string) string {
+	return s.Resolve(context.Background()).RewriteURL(rawURL)
+}
+
+// Resolve 返回当前可用的策略（探测结果缓存 interval）
+// 结果过期时在后台重新探测并立即返回上一次的结果；只有尚无结果时才等待首次探测完成
+func (s *autoLoginStrategy) Resolve(ctx context.Context) LoginStrategy {
+	s.mu.Lock()
+	current := s.current
+	if (current == nil || time.Since(s.checkedAt) >= s.interval) && s.probing == nil {
+		s.probing = make(chan struct{})
+		go s.refresh(s.probing)
+	}
+	probing := s.probing
+	s.mu.Unlock()
+
+	if current != nil {
+		return current
+	}
+
+	select {
+	case <-probing:
+	case <-ctx.Done():
+		// 请求已取消，探测仍在后台继续；按校园网处理，请求本身会因 ctx 取消而失败
+		return s.campus
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.current
+}
+
+// refresh 探测校园网是否可达并更新当前策略（不持有锁探测）
+func (s *autoLoginStrategy) refresh(done chan struct{}) {
+	next := s.webvpn
+	if s.probe() {
+		next = s.campus
+	}
+
+	s.mu.Lock()
+	if s.current != next {
+		log.Printf("教务系统访问方式切换为: %s", next.Name())
+	}
+	s.current = next
+	s.checkedAt = time.Now()
+	s.probing = nil
+	s.mu.Unlock()
+
+	close(done)
+}
+
+// campusReachable 尝试与校园网教务系统建立 TCP 连接（不使用请求的 ctx，避免请求取消导致误判并被缓存）
+func (s *autoLoginStrategy) campusReachable() bool {
+	u, err := url.Parse(s.probeURL)
+	if err != nil || u.Hostname() == "" {
+		return false
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	dialer := net.Dialer{Timeout: s.timeout}
+	conn, err := dialer.Dial("tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
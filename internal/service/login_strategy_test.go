@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"spider-go/pkg/webvpn"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestAutoStrategy 创建 auto 策略，探测结果由 reachable 决定，每次探测等待 release
+func newTestAutoStrategy(t *testing.T, reachable *atomic.Bool, release <-chan struct{}) (*autoLoginStrategy, *atomic.Int32) {
+	t.Helper()
+
+	strategy := NewLoginStrategy(StrategyAuto, LoginEndpoints{
+		LoginURL:    "https://cas.example.edu.cn/cas/login",
+		RedirectURL: "http://jwgl.example.edu.cn/Logon.do",
+	}, "https://webvpn.example.edu.cn/login", webvpn.NewTranslator("webvpn.example.edu.cn"))
+	auto, ok := strategy.(*autoLoginStrategy)
+	if !ok {
+		t.Fatalf("NewLoginStrategy(auto) = %T", strategy)
+	}
+
+	var probes atomic.Int32
+	auto.probe = func() bool {
+		probes.Add(1)
+		<-release
+		return reachable.Load()
+	}
+	return auto, &probes
+}
+
+func TestAutoLoginStrategyFirstResolveWaitsForProbe(t *testing.T) {
+	var reachable atomic.Bool
+	release := make(chan struct{})
+	auto, probes := newTestAutoStrategy(t, &reachable, release)
+
+	// 尚无探测结果时并发调用只探测一次，全部等待结果
+	results := make(chan string, 8)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- auto.Resolve(context.Background()).Name()
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	for name := range results {
+		if name != StrategyWebVPN {
+			t.Errorf("Resolve = %s, want %s", name, StrategyWebVPN)
+		}
+	}
+	if n := probes.Load(); n != 1 {
+		t.Errorf("probed %d times, want 1", n)
+	}
+
+	// 首次探测期间请求取消时不再等待
+	auto2, _ := newTestAutoStrategy(t, &reachable, make(chan struct{}))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if got := auto2.Resolve(ctx).Name(); got != StrategyCampus {
+		t.Errorf("Resolve with canceled ctx = %s, want %s", got, StrategyCampus)
+	}
+}
+
+func TestAutoLoginStrategyRefreshDoesNotBlockCallers(t *testing.T) {
+	var reachable atomic.Bool
+	reachable.Store(true)
+	release := make(chan struct{}, 1)
+	release <- struct{}{}
+	auto, probes := newTestAutoStrategy(t, &reachable, release)
+
+	if got := auto.Resolve(context.Background()).Name(); got != StrategyCampus {
+		t.Fatalf("first Resolve = %s, want %s", got, StrategyCampus)
+	}
+
+	// 结果过期后探测阻塞（模拟建立连接超时），调用方仍立即拿到上一次的结果
+	reachable.Store(false)
+	auto.mu.Lock()
+	auto.checkedAt = time.Now().Add(-auto.interval)
+	auto.mu.Unlock()
+
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		if got := auto.Resolve(context.Background()).Name(); got != StrategyCampus {
+			t.Fatalf("Resolve during refresh = %s, want cached %s", got, StrategyCampus)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Resolve blocked for %v during refresh", elapsed)
+	}
+	waitFor(t, "refresh probe", func() bool { return probes.Load() == 2 })
+	if got := auto.Resolve(context.Background()).Name(); got != StrategyCampus || probes.Load() != 2 {
+		t.Errorf("Resolve while probing = %s after %d probes, want cached %s and a single refresh", got, probes.Load(), StrategyCampus)
+	}
+
+	// 探测完成后切换到 WebVPN
+	release <- struct{}{}
+	waitFor(t, "switch to webvpn", func() bool { return auto.Resolve(context.Background()).Name() == StrategyWebVPN })
+	if n := probes.Load(); n != 2 {
+		t.Errorf("probed %d times, want 2", n)
+	}
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"sort"
+	"spider-go/internal/cache"
+	"sync"
+	"time"
+)
+
+// 解析的教务系统页面类型
+const (
+	PageGrades      = "grades"       // 成绩列表
+	PageLevelGrades = "level_grades" // 等级考试成绩
+	PageCourseTable = "course_table" // 学期课表
+	PageExams       = "exams"        // 考试安排
+)
+
+// sampleMaxSize 单个页面样本的最大字节数（超出部分截断）
+const sampleMaxSize = 256 << 10
+
+// sampleTTL 页面样本保留时间
+const sampleTTL = 7 * 24 * time.Hour
+
+// ParseMonitor 解析健康度监控：统计各页面的解析成功率，保存失败页面样本，失败率超过阈值时邮件通知管理员
+// 教务系统改版后解析会持续失败，用于在用户反馈之前发现问题
+type ParseMonitor interface {
+	// Record 记录一次解析结果（err 为解析错误，失败时保存匿名化的页面样本；username 为学号，用于匿名化）
+	Record(ctx context.Context, page, username string, raw []byte, err error)
+
+	// Stats 各页面的解析统计
+	Stats() []ParseStats
+
+	// Samples 页面最近解析失败的样本
+	Samples(ctx context.Context, page string) ([]ParseSample, error)
+
+	// WritePrometheus 以 Prometheus 文本格式输出解析计数
+	WritePrometheus(w io.Writer) error
+}
+
+// AdminEmailLister 获取告警收件人（管理员邮箱）
+type AdminEmailLister interface {
+	ListAdminEmails(ctx context.Context) ([]string, error)
+}
+
+// ParseMonitorConfig 解析健康度监控配置
+type ParseMonitorConfig struct {
+	Window        int           // 计算失败率的最近解析次数
+	MinSamples    int           // 窗口内至少解析多少次才判断失败率
+	Threshold     float64       // 失败率告警阈值（0~1）
+	AlertCooldown time.Duration // 同一页面两次告警的最小间隔
+	SampleLimit   int           // 每种页面保留的失败样本数
+}
+
+// DefaultParseMonitorConfig 返回默认配置
+func DefaultParseMonitorConfig() *ParseMonitorConfig {
+	return &ParseMonitorConfig{
+		Window:        50,
+		MinSamples:    10,
+		Threshold:     0.5,
+		AlertCooldown: time.Hour,
+		SampleLimit:   10,
+	}
+}
+
+// ParseStats 页面解析统计
+type ParseStats struct {
+	Page          string     `json:"page"`
+	Total         uint64     `json:"total"`           // 累计解析次数（进程启动以来）
+	Failed        uint64     `json:"failed"`          // 累计失败次数
+	WindowSize    int        `json:"window_size"`     // 窗口内解析次数
+	WindowFailed  int        `json:"window_failed"`   // 窗口内失败次数
+	FailureRate   float64    `json:"failure_rate"`    // 窗口内失败率
+	LastError     string     `json:"last_error"`      // 最近一次解析错误
+	LastFailureAt *time.Time `json:"last_failure_at"` // 最近一次解析失败时间
+}
+
+// ParseSample 解析失败的页面样本（已匿名化）
+type ParseSample struct {
+	Page      string    `json:"page"`
+	Error     string    `json:"error"`
+	Truncated bool      `json:"truncated"`
+	CreatedAt time.Time `json:"created_at"`
+	HTML      string    `json:"html"`
+}
+
+// pageHealth 单个页面的解析统计
+type pageHealth struct {
+	total         uint64
+	failed        uint64
+	window        []bool // 环形缓冲，true 表示失败
+	next          int
+	filled        int
+	lastError     string
+	lastFailureAt time.Time
+	lastAlertAt   time.Time
+}
+
+// parseMonitor 解析健康度监控实现
+type parseMonitor struct {
+	cache        cache.ParseHealthCache
+	emailService EmailService
+	recipients   AdminEmailLister
+	cfg          ParseMonitorConfig
+
+	mu    sync.Mutex
+	pages map[string]*pageHealth
+}
+
+// NewParseMonitor 创建解析健康度监控
+func NewParseMonitor(healthCache cache.ParseHealthCache, emailService EmailService, recipients AdminEmailLister, cfg *ParseMonitorConfig) ParseMonitor {
+	if cfg == nil {
+		cfg = DefaultParseMonitorConfig()
+	}
+	return &parseMonitor{
+		cache:        healthCache,
+		emailService: emailService,
+		recipients:   recipients,
+		cfg:          *cfg,
+		pages:        make(map[string]*pageHealth),
+	}
+}
+
+// Record 记录一次解析结果
+func (m *parseMonitor) Record(ctx context.Context, page, username string, raw []byte, err error) {
+	now := time.Now()
+
+	m.mu.Lock()
+	h := m.page(page)
+	h.total++
+	h.window[h.next] = err != nil
+	h.next = (h.next + 1) % len(h.window)
+	if h.filled < len(h.window) {
+		h.filled++
+	}
+
+	if err == nil {
+		m.mu.Unlock()
+		return
+	}
+
+	h.failed++
+	h.lastError = err.Error()
+	h.lastFailureAt = now
+	stats := h.stats(page)
+	alert := stats.WindowSize >= m.cfg.MinSamples &&
+		stats.FailureRate >= m.cfg.Threshold &&
+		now.Sub(h.lastAlertAt) >= m.cfg.AlertCooldown
+	if alert {
+		h.lastAlertAt = now
+	}
+	m.mu.Unlock()
+
+	log.Printf("解析 %s 页面失败: %v", page, err)
+
+	// 请求结束后继续保存样本和发送告警
+	ctx = context.WithoutCancel(ctx)
+	go m.saveSample(ctx, page, username, raw, err, now)
+	if alert {
+		go m.alert(ctx, stats)
+	}
+}
+
+// page 获取页面统计（调用方持有锁）
+func (m *parseMonitor) page(page string) *pageHealth {
+	h, ok := m.pages[page]
+	if !ok {
+		h = &pageHealth{window: make([]bool, max(m.cfg.Window, 1))}
+		m.pages[page] = h
+	}
+	return h
+}
+
+// stats 生成统计（调用方持有锁）
+func (h *pageHealth) stats(page string) ParseStats {
+	s := ParseStats{
+		Page:       page,
+		Total:      h.total,
+		Failed:     h.failed,
+		WindowSize: h.filled,
+		LastError:  h.lastError,
+	}
+	for i := 0; i < h.filled; i++ {
+		if h.window[i] {
+			s.WindowFailed++
+		}
+	}
+	if s.WindowSize > 0 {
+		s.FailureRate = float64(s.WindowFailed) / float64(s.WindowSize)
+	}
+	if !h.lastFailureAt.IsZero() {
+		t := h.lastFailureAt
+		s.LastFailureAt = &t
+	}
+	return s
+}
+
+// saveSample 保存匿名化的失败页面（保存失败只记录日志）
+func (m *parseMonitor) saveSample(ctx context.Context, page, username string, raw []byte, parseErr error, now time.Time) {
+	anonymized := AnonymizePage(raw, username)
+	sample := ParseSample{
+		Page:      page,
+		Error:     parseErr.Error(),
+		Truncated: len(anonymized) > sampleMaxSize,
+		CreatedAt: now,
+	}
+	if sample.Truncated {
+		anonymized = anonymized[:sampleMaxSize]
+	}
+	sample.HTML = string(anonymized)
+
+	data, err := json.Marshal(sample)
+	if err != nil {
+		log.Printf("编码 %s 页面样本失败: %v", page, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := m.cache.SaveSample(ctx, page, data, max(m.cfg.SampleLimit, 1), sampleTTL); err != nil {
+		log.Printf("保存 %s 页面样本失败: %v", page, err)
+	}
+}
+
+// alert 邮件通知管理员（多实例部署时通过 Redis 去重）
+func (m *parseMonitor) alert(ctx context.Context, stats ParseStats) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	ok, err := m.cache.AcquireAlert(ctx, stats.Page, m.cfg.AlertCooldown)
+	if err != nil {
+		log.Printf("获取解析告警锁失败 (%s): %v", stats.Page, err)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	emails, err := m.recipients.ListAdminEmails(ctx)
+	if err != nil {
+		log.Printf("获取管理员邮箱失败: %v", err)
+		return
+	}
+
+	subject := fmt.Sprintf("教务系统 %s 页面解析失败率 %.0f%%，页面结构可能已变化", stats.Page, stats.FailureRate*100)
+	body := fmt.Sprintf(
+		"<p>最近 %d 次解析 <b>%s</b> 页面，失败 %d 次（失败率 %.0f%%，阈值 %.0f%%）。</p>"+
+			"<p>最近一次错误：%s</p>"+
+			"<p>失败页面样本（已匿名化）可通过管理接口 /api/admin/statistics/parse-health/%s/samples 查看。</p>",
+		stats.WindowSize, html.EscapeString(stats.Page), stats.WindowFailed, stats.FailureRate*100, m.cfg.Threshold*100,
+		html.EscapeString(stats.LastError), stats.Page,
+	)
+
+	for _, email := range emails {
+		if err := m.emailService.SendEmail(ctx, email, subject, body); err != nil {
+			log.Printf("发送解析告警邮件失败 (%s): %v", email, err)
+		}
+	}
+	log.Printf("已发送 %s 页面解析告警给 %d 位管理员", stats.Page, len(emails))
+}
+
+// Stats 各页面的解析统计（按页面排序）
+func (m *parseMonitor) Stats() []ParseStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]ParseStats, 0, len(m.pages))
+	for page, h := range m.pages {
+		result = append(result, h.stats(page))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Page < result[j].Page })
+	return result
+}
+
+// Samples 页面最近解析失败的样本
+func (m *parseMonitor) Samples(ctx context.Context, page string) ([]ParseSample, error) {
+	items, err := m.cache.ListSamples(ctx, page)
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]ParseSample, 0, len(items))
+	for _, item := range items {
+		var sample ParseSample
+		if err := json.Unmarshal([]byte(item), &sample); err != nil {
+			continue
+		}
+		samples = append(samples, sample)
+	}
+	return samples, nil
+}
+
+// WritePrometheus 以 Prometheus 文本格式输出解析计数
+func (m *parseMonitor) WritePrometheus(w io.Writer) error {
+	stats := m.Stats()
+
+	if _, err := fmt.Fprintln(w, "# HELP spider_parse_total JWC page parses by page type and result."); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "# TYPE spider_parse_total counter")
+	for _, s := range stats {
+		fmt.Fprintf(w, "spider_parse_total{page=%q,result=%q} %d\n", s.Page, "success", s.Total-s.Failed)
+		fmt.Fprintf(w, "spider_parse_total{page=%q,result=%q} %d\n", s.Page, "failure", s.Failed)
+	}
+
+	fmt.Fprintln(w, "# HELP spider_parse_failure_rate Parse failure rate over the recent window by page type.")
+	fmt.Fprintln(w, "# TYPE spider_parse_failure_rate gauge")
+	for _, s := range stats {
+		fmt.Fprintf(w, "spider_parse_failure_rate{page=%q} %g\n", s.Page, s.FailureRate)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryParseHealthCache 内存实现的解析健康度缓存（测试用）
+type memoryParseHealthCache struct {
+	mu      sync.Mutex
+	samples map[string][]string
+	alerts  map[string]bool
+}
+
+func (c *memoryParseHealthCache) SaveSample(ctx context.Context, page string, sample []byte, limit int, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.samples[page] = append([]string{string(sample)}, c.samples[page]...)
+	if len(c.samples[page]) > limit {
+		c.samples[page] = c.samples[page][:limit]
+	}
+	return nil
+}
+
+func (c *memoryParseHealthCache) ListSamples(ctx context.Context, page string) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.samples[page]...), nil
+}
+
+func (c *memoryParseHealthCache) AcquireAlert(ctx context.Context, page string, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.alerts[page] {
+		return false, nil
+	}
+	c.alerts[page] = true
+	return true, nil
+}
+
+// recordingEmailService 记录发出的邮件（测试用）
+type recordingEmailService struct {
+	mu   sync.Mutex
+	sent []string
+}
+
+func (e *recordingEmailService) SendVerificationCode(ctx context.Context, to string, code string) error {
+	return nil
+}
+
+func (e *recordingEmailService) SendEmail(ctx context.Context, to string, subject string, body string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.sent = append(e.sent, to+": "+subject)
+	return nil
+}
+
+func (e *recordingEmailService) count() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.sent)
+}
+
+// staticAdmins 固定的管理员邮箱（测试用）
+type staticAdmins []string
+
+func (a staticAdmins) ListAdminEmails(ctx context.Context) ([]string, error) {
+	return a, nil
+}
+
+// waitFor 等待异步任务完成
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestParseMonitorAlertsOnceAboveThreshold(t *testing.T) {
+	healthCache := &memoryParseHealthCache{samples: make(map[string][]string), alerts: make(map[string]bool)}
+	emails := &recordingEmailService{}
+	monitor := NewParseMonitor(healthCache, emails, staticAdmins{"a@example.com", "b@example.com"}, &ParseMonitorConfig{
+		Window:        10,
+		MinSamples:    4,
+		Threshold:     0.5,
+		AlertCooldown: time.Hour,
+		SampleLimit:   2,
+	})
+	ctx := context.Background()
+	page := []byte("<html><body>姓名：李四 学号：20231234567</body></html>")
+	parseErr := errors.New("表头缺少列: 绩点")
+
+	// 样本不足时不告警
+	monitor.Record(ctx, PageGrades, "20231234567", page, nil)
+	monitor.Record(ctx, PageGrades, "20231234567", page, parseErr)
+	monitor.Record(ctx, PageGrades, "20231234567", page, parseErr)
+	// 达到最少样本数且失败率 75%，告警一次；冷却期内不再告警
+	monitor.Record(ctx, PageGrades, "20231234567", page, parseErr)
+	monitor.Record(ctx, PageGrades, "20231234567", page, parseErr)
+	monitor.Record(ctx, PageExams, "20231234567", page, nil)
+
+	waitFor(t, "alert emails", func() bool { return emails.count() == 2 })
+	waitFor(t, "samples", func() bool {
+		samples, _ := monitor.Samples(ctx, PageGrades)
+		return len(samples) == 2
+	})
+	time.Sleep(20 * time.Millisecond)
+	if n := emails.count(); n != 2 {
+		t.Fatalf("sent %d emails, want 2 (one per admin)", n)
+	}
+
+	stats := monitor.Stats()
+	if len(stats) != 2 || stats[1].Page != PageGrades {
+		t.Fatalf("stats = %+v", stats)
+	}
+	if got := stats[1]; got.Total != 5 || got.Failed != 4 || got.WindowSize != 5 || got.FailureRate != 0.8 || got.LastError != parseErr.Error() {
+		t.Fatalf("grades stats = %+v", got)
+	}
+
+	samples, err := monitor.Samples(ctx, PageGrades)
+	if err != nil {
+		t.Fatalf("Samples: %v", err)
+	}
+	for _, s := range samples {
+		if strings.Contains(s.HTML, "20231234567") || strings.Contains(s.HTML, "李四") {
+			t.Fatalf("sample not anonymized: %s", s.HTML)
+		}
+		if s.Error != parseErr.Error() {
+			t.Fatalf("sample error = %q", s.Error)
+		}
+	}
+
+	var metrics strings.Builder
+	if err := monitor.WritePrometheus(&metrics); err != nil {
+		t.Fatalf("WritePrometheus: %v", err)
+	}
+	for _, want := range []string{
+		`spider_parse_total{page="grades",result="success"} 1`,
+		`spider_parse_total{page="grades",result="failure"} 4`,
+		`spider_parse_total{page="exams",result="success"} 1`,
+	} {
+		if !strings.Contains(metrics.String(), want) {
+			t.Errorf("metrics missing %q:\n%s", want, metrics.String())
+		}
+	}
+}
@@ -1,11 +1,13 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"spider-go/internal/common"
+	"spider-go/pkg/httpclient"
 	"sync"
 	"time"
 )
@@ -27,13 +29,15 @@ type rsaKeyServiceImpl struct {
 	rsaKeyURL     string
 	lastUpdatedAt time.Time
 	httpTimeout   time.Duration
+	client        httpclient.Client
 }
 
 // NewRSAKeyService 创建 RSA 公钥服务
-func NewRSAKeyService(rsaKeyURL string) RSAKeyService {
+func NewRSAKeyService(rsaKeyURL string, client httpclient.Client) RSAKeyService {
 	return &rsaKeyServiceImpl{
 		rsaKeyURL:   rsaKeyURL,
 		httpTimeout: 10 * time.Second,
+		client:      client,
 	}
 }
 
@@ -46,8 +50,10 @@ func (s *rsaKeyServiceImpl) GetPublicKey() string {
 
 // FetchAndUpdate 从服务器获取并更新 RSA 公钥
 func (s *rsaKeyServiceImpl) FetchAndUpdate() error {
-	client := &http.Client{Timeout: s.httpTimeout}
-	resp, err := client.Get(s.rsaKeyURL)
+	ctx, cancel := context.WithTimeout(context.Background(), s.httpTimeout)
+	defer cancel()
+
+	resp, err := s.client.Get(ctx, s.rsaKeyURL)
 	if err != nil {
 		return common.NewAppError(common.CodeJwcRequestFailed, fmt.Sprintf("获取 RSA 公钥失败: %v", err))
 	}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"spider-go/internal/common"
+	"time"
+)
+
+const (
+	// loginLockTTL 登录锁有效期（需覆盖一次完整的 CAS 登录及重定向）
+	loginLockTTL = 60 * time.Second
+	// loginWaitTimeout 等待其他实例完成登录的最长时间
+	loginWaitTimeout = 60 * time.Second
+	// loginPollInterval 等待期间检查会话缓存的间隔
+	loginPollInterval = 500 * time.Millisecond
+)
+
+// loginCall 进程内正在进行的登录（同一 uid 的并发请求共享结果）
+type loginCall struct {
+	done    chan struct{}
+	cookies []*http.Cookie
+	err     error
+}
+
+// AcquireCookies 获取缓存的 cookies，没有时登录
+// 同一 uid 在进程内只发起一次登录，跨实例通过 Redis 锁互斥，其余请求等待登录结果，
+// 避免 App 启动时多个接口同时触发登录、累计失败次数导致账号被锁定
+func (s *jwcSessionService) AcquireCookies(ctx context.Context, uid int, username, password string) ([]*http.Cookie, error) {
+	cookies, err := s.sessionCache.GetCookies(ctx, uid)
+	if err != nil {
+		return nil, common.NewAppError(common.CodeCacheError, "缓存错误")
+	}
+	if len(cookies) > 0 {
+		return cookies, nil
+	}
+
+	s.flightMu.Lock()
+	if call, ok := s.flights[uid]; ok {
+		s.flightMu.Unlock()
+		select {
+		case <-call.done:
+			return call.cookies, call.err
+		case <-ctx.Done():
+			return nil, common.NewAppError(common.CodeJwcLoginFailed, "等待登录超时")
+		}
+	}
+	call := &loginCall{done: make(chan struct{})}
+	s.flights[uid] = call
+	s.flightMu.Unlock()
+
+	// 其他请求在等待本次登录的结果，发起请求的客户端断开也要完成登录
+	call.cookies, call.err = s.loginExclusive(context.WithoutCancel(ctx), uid, username, password)
+
+	s.flightMu.Lock()
+	delete(s.flights, uid)
+	s.flightMu.Unlock()
+	close(call.done)
+
+	return call.cookies, call.err
+}
+
+// loginExclusive 持有 Redis 登录锁时登录，锁被其他实例持有时等待其结果
+func (s *jwcSessionService) loginExclusive(ctx context.Context, uid int, username, password string) ([]*http.Cookie, error) {
+	token, err := randomToken()
+	if err != nil {
+		return nil, common.NewAppError(common.CodeInternalError, "生成登录锁失败")
+	}
+
+	locked, err := s.sessionCache.AcquireLoginLock(ctx, uid, token, loginLockTTL)
+	if err != nil {
+		// Redis 不可用时退化为仅进程内互斥
+		log.Printf("获取登录锁失败 (uid=%d): %v", uid, err)
+		return s.loginAndGetCookies(ctx, uid, username, password)
+	}
+	if !locked {
+		return s.waitForLogin(ctx, uid)
+	}
+	defer func() {
+		if err := s.sessionCache.ReleaseLoginLock(ctx, uid, token); err != nil {
+			log.Printf("释放登录锁失败 (uid=%d): %v", uid, err)
+		}
+	}()
+
+	// 拿到锁后再检查一次，其他实例可能刚完成登录
+	if cookies, err := s.sessionCache.GetCookies(ctx, uid); err == nil && len(cookies) > 0 {
+		return cookies, nil
+	}
+
+	return s.loginAndGetCookies(ctx, uid, username, password)
+}
+
+// loginAndGetCookies 登录并读取缓存的 cookies
+func (s *jwcSessionService) loginAndGetCookies(ctx context.Context, uid int, username, password string) ([]*http.Cookie, error) {
+	if err := s.LoginAndCache(ctx, uid, username, password); err != nil {
+		return nil, err
+	}
+
+	cookies, err := s.sessionCache.GetCookies(ctx, uid)
+	if err != nil || len(cookies) == 0 {
+		return nil, common.NewAppError(common.CodeJwcLoginFailed, "获取会话失败")
+	}
+	return cookies, nil
+}
+
+// waitForLogin 等待其他实例完成登录（锁释放后仍没有会话说明对方登录失败，不再重复尝试）
+func (s *jwcSessionService) waitForLogin(ctx context.Context, uid int) ([]*http.Cookie, error) {
+	ticker := time.NewTicker(loginPollInterval)
+	defer ticker.Stop()
+	deadline := time.Now().Add(loginWaitTimeout)
+
+	for time.Now().Before(deadline) {
+		<-ticker.C
+
+		cookies, err := s.sessionCache.GetCookies(ctx, uid)
+		if err == nil && len(cookies) > 0 {
+			return cookies, nil
+		}
+
+		locked, err := s.sessionCache.IsLoginLocked(ctx, uid)
+		if err != nil || locked {
+			continue
+		}
+
+		// 锁已释放：最后确认一次会话，再根据失效标记给出原因
+		if cookies, err := s.sessionCache.GetCookies(ctx, uid); err == nil && len(cookies) > 0 {
+			return cookies, nil
+		}
+		if invalid, err := s.sessionCache.IsCredentialInvalid(ctx, uid); err == nil && invalid {
+			return nil, common.NewAppError(common.CodeJwcBindInvalid, "教务系统绑定已失效，请重新绑定")
+		}
+		return nil, common.NewAppError(common.CodeJwcLoginFailed, "登录失败，请稍后重试")
+	}
+
+	return nil, common.NewAppError(common.CodeJwcLoginFailed, "等待登录超时，请稍后重试")
+}
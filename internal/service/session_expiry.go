@@ -0,0 +1,56 @@
+package service
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+)
+
+// loginPathMarkers 登录页地址特征（CAS、统一身份认证、教务系统自身登录页）
+var loginPathMarkers = []string{"/cas/login", "/authserver/login", "/jsxsd/xk/logintoxk", "/jsxsd/framework/login"}
+
+// loginFormMarkers 登录表单特征（小写）
+var loginFormMarkers = [][]byte{
+	[]byte(`name="execution"`),
+	[]byte(`name='execution'`),
+	[]byte(`/jsxsd/xk/logintoxk`),
+}
+
+// loginRedirectMarkers 脚本跳转到登录页的特征（只在很短的跳转页中检查，正常页面可能带有登录链接）
+var loginRedirectMarkers = [][]byte{
+	[]byte(`/cas/login?service=`),
+	[]byte(`/authserver/login?service=`),
+}
+
+// isSessionExpired 根据最终地址（跟随重定向后）和页面内容判断会话是否已过期
+func isSessionExpired(finalURL *url.URL, body []byte) bool {
+	if finalURL != nil {
+		path := strings.ToLower(finalURL.Path)
+		for _, marker := range loginPathMarkers {
+			if strings.Contains(path, marker) {
+				return true
+			}
+		}
+	}
+
+	// 登录表单只检查页面开头
+	head := body
+	if len(head) > 8192 {
+		head = head[:8192]
+	}
+	head = bytes.ToLower(head)
+	for _, marker := range loginFormMarkers {
+		if bytes.Contains(head, marker) {
+			return true
+		}
+	}
+
+	if len(body) < 2048 {
+		for _, marker := range loginRedirectMarkers {
+			if bytes.Contains(head, marker) {
+				return true
+			}
+		}
+	}
+	return false
+}
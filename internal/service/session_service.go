@@ -11,12 +11,16 @@ import (
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
 	"spider-go/internal/cache"
 	"spider-go/internal/common"
+	"spider-go/pkg/httpclient"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
@@ -27,157 +31,241 @@ import (
 type SessionService interface {
 	// LoginAndCache 登录教务系统并缓存会话
 	LoginAndCache(ctx context.Context, uid int, username, password string) error
+	// AcquireCookies 获取缓存的 cookies，没有时登录（同一账号的并发请求只登录一次）
+	AcquireCookies(ctx context.Context, uid int, username, password string) ([]*http.Cookie, error)
 	// GetCachedCookies 获取缓存的 cookies
 	GetCachedCookies(ctx context.Context, uid int) ([]*http.Cookie, error)
 	// InvalidateSession 清除会话缓存
 	InvalidateSession(ctx context.Context, uid int) error
+	// ExpireSession 会话已过期时清除缓存（缓存已被其他请求重新登录替换时保留）
+	ExpireSession(ctx context.Context, uid int, stale []*http.Cookie) error
+	// VerifyCredentials 使用新的账号密码登录并缓存会话（用于绑定，失败不影响现有绑定状态）
+	VerifyCredentials(ctx context.Context, uid int, username, password string) error
+	// IsCredentialInvalid 检查已绑定的教务密码是否已失效
+	IsCredentialInvalid(ctx context.Context, uid int) (bool, error)
+	// ClearCredentialInvalid 清除绑定失效标记（用于解除绑定）
+	ClearCredentialInvalid(ctx context.Context, uid int) error
+	// CompleteCaptchaLogin 提交验证码完成被挑战的登录，返回登录的学号
+	CompleteCaptchaLogin(ctx context.Context, uid int, challengeID, captcha string) (string, error)
+	// CompleteCaptchaVerify 提交验证码完成被挑战的绑定验证（学号和密码必须与发起挑战时一致）
+	CompleteCaptchaVerify(ctx context.Context, uid int, challengeID, captcha, username, password string) error
 }
 
 // jwcSessionService 教务系统会话服务实现
 type jwcSessionService struct {
 	sessionCache    cache.SessionCache
 	rsaKeyService   RSAKeyService
-	mode            string // 登录模式：campus 或 webvpn
-	loginURL        string
-	redirectURL     string
+	client          httpclient.Client // 共享的出站客户端（登录时派生独立 cookie jar 的会话客户端）
+	strategy        LoginStrategy     // 访问策略：校园网、WebVPN 或自动探测
 	captchaURL      string
 	captchaImageURL string
-	timeout         time.Duration
 	cacheExpire     time.Duration
+
+	flightMu sync.Mutex
+	flights  map[int]*loginCall // 进程内正在进行的登录
 }
 
 // NewJwcSessionService 创建教务系统会话服务
 func NewJwcSessionService(
 	sessionCache cache.SessionCache,
 	rsaKeyService RSAKeyService,
-	mode string,
-	loginURL string,
-	redirectURL string,
+	client httpclient.Client,
+	strategy LoginStrategy,
 	captchaURL string,
 	captchaImageURL string,
 ) SessionService {
 	return &jwcSessionService{
 		sessionCache:    sessionCache,
 		rsaKeyService:   rsaKeyService,
-		mode:            mode,
-		loginURL:        loginURL,
-		redirectURL:     redirectURL,
+		client:          client,
+		strategy:        strategy,
 		captchaURL:      captchaURL,
 		captchaImageURL: captchaImageURL,
-		timeout:         30 * time.Second,
 		cacheExpire:     time.Hour,
+		flights:         make(map[int]*loginCall),
 	}
 }
 
-// LoginAndCache 登录教务系统并缓存会话（带重试机制，根据模式选择登录方法）
+// LoginAndCache 使用已绑定的账号密码登录教务系统并缓存会话
+// 账号密码错误（通常是学生修改了密码）或密码过期时标记绑定失效，之后不再尝试登录，避免反复提交错误密码导致账号被锁定
 func (s *jwcSessionService) LoginAndCache(ctx context.Context, uid int, username, password string) error {
+	if invalid, err := s.sessionCache.IsCredentialInvalid(ctx, uid); err == nil && invalid {
+		return common.NewAppError(common.CodeJwcBindInvalid, "教务系统绑定已失效，请重新绑定")
+	}
+
+	return s.finishLogin(ctx, uid, false, s.login(ctx, uid, username, password, false))
+}
+
+// VerifyCredentials 使用新的账号密码登录并缓存会话，成功后清除绑定失效标记
+func (s *jwcSessionService) VerifyCredentials(ctx context.Context, uid int, username, password string) error {
+	return s.finishLogin(ctx, uid, true, s.login(ctx, uid, username, password, true))
+}
+
+// finishLogin 处理登录结果：绑定验证成功后清除失效标记；自动登录时密码错误或过期则标记绑定失效
+func (s *jwcSessionService) finishLogin(ctx context.Context, uid int, verify bool, err error) error {
+	if verify {
+		if err != nil {
+			return err
+		}
+		return s.sessionCache.ClearCredentialInvalid(ctx, uid)
+	}
+
+	if appErr, ok := err.(*common.AppError); ok {
+		switch appErr.Code {
+		case common.CodeJwcWrongPassword:
+			_ = s.sessionCache.MarkCredentialInvalid(ctx, uid)
+			_ = s.sessionCache.DeleteCookies(ctx, uid)
+			return common.NewAppError(common.CodeJwcBindInvalid, "教务系统密码已修改，请重新绑定")
+		case common.CodeJwcPwdExpired:
+			_ = s.sessionCache.MarkCredentialInvalid(ctx, uid)
+			_ = s.sessionCache.DeleteCookies(ctx, uid)
+		}
+	}
+	return err
+}
+
+// IsCredentialInvalid 检查已绑定的教务密码是否已失效
+func (s *jwcSessionService) IsCredentialInvalid(ctx context.Context, uid int) (bool, error) {
+	return s.sessionCache.IsCredentialInvalid(ctx, uid)
+}
+
+// ClearCredentialInvalid 清除绑定失效标记
+func (s *jwcSessionService) ClearCredentialInvalid(ctx context.Context, uid int) error {
+	return s.sessionCache.ClearCredentialInvalid(ctx, uid)
+}
+
+// login 登录教务系统并缓存会话（带重试机制；账号密码错误时不重试）
+func (s *jwcSessionService) login(ctx context.Context, uid int, username, password string, verify bool) error {
 	var err error
 	// 重试 1 次
 	for i := 0; i < 1; i++ {
-		// 根据模式选择登录函数
-		if s.mode == "webvpn" {
-			err = s.loginAndCacheOnceByWebVPN(ctx, uid, username, password)
-		} else {
-			err = s.loginAndCacheOnce(ctx, uid, username, password)
-		}
+		err = s.loginAndCacheOnce(ctx, uid, username, password, verify)
 
 		if err == nil {
 			return nil
 		}
 
+		// 已明确失败原因（密码错误、账号锁定、需要验证码等），重试只会增加被锁定的风险
+		if isDefiniteLoginFailure(err) {
+			return err
+		}
+
 		// 重试间隔
 		time.Sleep(time.Second * time.Duration(i+1))
 	}
 	return common.NewAppError(common.CodeJwcLoginFailed, fmt.Sprintf("登录失败，请重试，连续三次失败将被锁定: %v", err))
 }
 
-// loginAndCacheOnce 单次登录逻辑
-func (s *jwcSessionService) loginAndCacheOnce(ctx context.Context, uid int, username, password string) error {
-	// 创建 cookie jar
-	jar, err := cookiejar.New(&cookiejar.Options{
-		PublicSuffixList: publicsuffix.List,
-	})
-	if err != nil {
-		return common.NewAppError(common.CodeJwcLoginFailed, "创建会话失败")
-	}
+// loginAndCacheOnce 单次登录逻辑（verify 表示本次登录用于绑定验证）
+func (s *jwcSessionService) loginAndCacheOnce(ctx context.Context, uid int, username, password string, verify bool) error {
+	// 整个登录流程使用同一种访问方式（auto 策略可能在两次登录之间切换）
+	strategy := s.strategy.Resolve(ctx)
 
-	client := &http.Client{
-		Jar:     jar,
-		Timeout: s.timeout,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse // 禁止自动跳转（CAS 必须手动）
-		},
+	client, err := s.newLoginClient()
+	if err != nil {
+		return err
 	}
 
 	// 1. 请求登录页获取 execution
-	res, err := client.Get(s.loginURL)
+	execution, err := s.fetchExecution(ctx, client, strategy.LoginURL())
 	if err != nil {
-		return common.NewAppError(common.CodeJwcLoginFailed, "连接教务系统失败")
+		return err
 	}
-	defer res.Body.Close()
 
-	if res.StatusCode != http.StatusOK {
-		return common.NewAppError(common.CodeJwcLoginFailed, fmt.Sprintf("响应异常: %d", res.StatusCode))
+	// 2. 密码加密
+	encryptedPwd, err := s.encryptPassword(password)
+	if err != nil {
+		return common.NewAppError(common.CodeJwcLoginFailed, fmt.Sprintf("密码加密失败: %v", err))
 	}
 
-	doc, err := goquery.NewDocumentFromReader(res.Body)
+	fpVisitorId, err := s.GenerateRandomFingerPrintHash()
 	if err != nil {
-		return common.NewAppError(common.CodeJwcParseFailed, "解析登录页面失败")
+		return common.NewAppError(common.CodeInternalError, "生成设备指纹失败")
 	}
 
-	execution := doc.Find("input[name='execution']").AttrOr("value", "")
-	if execution == "" {
-		return common.NewAppError(common.CodeJwcLoginFailed, "找不到 execution")
+	state := &pendingLogin{
+		LoginURL:        strategy.LoginURL(),
+		RedirectURL:     strategy.RedirectURL(),
+		CaptchaImageURL: strategy.RewriteURL(s.captchaImageURL),
+		Uid:             uid,
+		Username:        username,
+		Password:        encryptedPwd,
+		PasswordDigest:  passwordDigest(username, password),
+		Execution:       execution,
+		FpVisitorId:     fpVisitorId,
+		Verify:          verify,
 	}
 
-	// 2. 密码加密
-	encryptedPwd, err := s.encryptPassword(password)
-	if err != nil {
-		return common.NewAppError(common.CodeJwcLoginFailed, fmt.Sprintf("密码加密失败: %v", err))
+	// 需要验证码时直接提交必然失败且会累计失败次数，暂存登录状态交给用户输入验证码
+	if s.needCaptcha(ctx, client, strategy.RewriteURL(s.captchaURL), username) {
+		return s.challengeCaptcha(ctx, client, state)
 	}
 
-	fpVisitorId, err := s.GenerateRandomFingerPrintHash()
+	return s.submitLogin(ctx, client, state, "")
+}
+
+// loginClient 登录用的 HTTP 客户端（共享连接池，独立 cookie jar）
+type loginClient struct {
+	httpclient.Client
+	Jar http.CookieJar
+}
+
+// newLoginClient 创建登录用的 HTTP 客户端（独立 cookie jar，禁止自动跳转）
+func (s *jwcSessionService) newLoginClient() (*loginClient, error) {
+	jar, err := cookiejar.New(&cookiejar.Options{
+		PublicSuffixList: publicsuffix.List,
+	})
 	if err != nil {
-		return common.NewAppError(common.CodeInternalError, "生成设备指纹失败")
+		return nil, common.NewAppError(common.CodeJwcLoginFailed, "创建会话失败")
 	}
 
+	return &loginClient{
+		// 禁止自动跳转（CAS 必须手动）
+		Client: s.client.Session(httpclient.SessionOptions{Jar: jar, NoRedirect: true}),
+		Jar:    jar,
+	}, nil
+}
+
+// submitLogin 提交 CAS 登录表单，成功后跟随重定向进入教务系统并缓存会话
+func (s *jwcSessionService) submitLogin(ctx context.Context, client *loginClient, state *pendingLogin, captcha string) error {
 	form := url.Values{
-		"username":    {username},
-		"password":    {encryptedPwd},
-		"execution":   {execution},
-		"fpVisitorId": {fpVisitorId},
+		"username":    {state.Username},
+		"password":    {state.Password},
+		"execution":   {state.Execution},
+		"fpVisitorId": {state.FpVisitorId},
 		"rememberMe":  {"on"},
 		"_eventId":    {"submit"},
 		"failN":       {"0"},
 		"submit1":     {"login1"},
 	}
+	if captcha != "" {
+		form.Set("captcha", captcha)
+	}
 
 	// 3. 构造 POST 请求
-	req, err := http.NewRequest("POST", s.loginURL, strings.NewReader(form.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", state.LoginURL, strings.NewReader(form.Encode()))
 	if err != nil {
 		return common.NewAppError(common.CodeJwcLoginFailed, "构造登录请求失败")
 	}
 
 	req.Header.Set("User-Agent", "Mozilla/5.0")
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("Referer", s.loginURL)
+	req.Header.Set("Referer", state.LoginURL)
 
-	resp, err := client.Do(req)
+	resp, err := client.Do(ctx, req)
 	if err != nil {
-		return common.NewAppError(common.CodeJwcLoginFailed, "登录失败")
+		return loginRequestError(err, "登录失败")
 	}
 
-	resp.Body.Close()
-
-	if resp.StatusCode != 302 {
-		return common.NewAppError(common.CodeJwcLoginFailed, "重定向并非302")
+	if err := checkLoginResponse(resp); err != nil {
+		return err
 	}
 
 	//直接不处理重定向，用这个tgc的cookie去get教务系统，触发下一条重定向链，get全自动重定向
 
-	finalResp, finalURL, err := s.followGET(client, s.redirectURL, 8)
+	finalResp, finalURL, err := s.followGET(ctx, client, state.RedirectURL, 8)
 	if err != nil {
-		return common.NewAppError(common.CodeJwcLoginFailed, "跟随重定向失败")
+		return loginRequestError(err, "跟随重定向失败")
 	}
 	defer finalResp.Body.Close()
 
@@ -187,28 +275,83 @@ func (s *jwcSessionService) loginAndCacheOnce(ctx context.Context, uid int, user
 	cookies := client.Jar.Cookies(base)
 
 	if len(cookies) == 0 {
-		if u, e := url.Parse(s.redirectURL); e == nil {
+		if u, e := url.Parse(state.RedirectURL); e == nil {
 			cookies = client.Jar.Cookies(u)
 		}
 	}
 
 	// 7. 存入缓存
-	if err := s.sessionCache.SetCookies(ctx, uid, cookies, s.cacheExpire); err != nil {
+	if err := s.sessionCache.SetCookies(ctx, state.Uid, cookies, s.cacheExpire); err != nil {
 		return common.NewAppError(common.CodeCacheError, "缓存会话失败")
 	}
 
 	return nil
 }
 
-func (s *jwcSessionService) followGET(client *http.Client, start string, maxHops int) (*http.Response, string, error) {
+// fetchExecution 请求 CAS 登录页并提取 execution
+func (s *jwcSessionService) fetchExecution(ctx context.Context, client *loginClient, loginURL string) (string, error) {
+	res, err := client.Get(ctx, loginURL)
+	if err != nil {
+		return "", loginRequestError(err, "连接教务系统失败")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusInternalServerError {
+		return "", loginFailure(common.CodeJwcMaintenance)
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", common.NewAppError(common.CodeJwcLoginFailed, fmt.Sprintf("响应异常: %d", res.StatusCode))
+	}
+
+	doc, err := goquery.NewDocumentFromReader(res.Body)
+	if err != nil {
+		return "", common.NewAppError(common.CodeJwcParseFailed, "解析登录页面失败")
+	}
+
+	execution := doc.Find("input[name='execution']").AttrOr("value", "")
+	if execution == "" {
+		// 维护期间登录页会被替换为公告页
+		if err := classifyLoginPage(doc); err != nil {
+			return "", err
+		}
+		return "", common.NewAppError(common.CodeJwcLoginFailed, "找不到 execution")
+	}
+
+	return execution, nil
+}
+
+// needCaptcha 调用 CAS needCaptcha 接口判断该账号是否需要验证码（接口不可用时按不需要处理）
+func (s *jwcSessionService) needCaptcha(ctx context.Context, client *loginClient, captchaURL, username string) bool {
+	if captchaURL == "" {
+		return false
+	}
+
+	query := url.Values{
+		"username": {username},
+		"_":        {strconv.FormatInt(time.Now().UnixMilli(), 10)},
+	}
+	res, err := client.Get(ctx, captchaURL+query.Encode())
+	if err != nil {
+		return false
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1024))
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(string(body)) == "true"
+}
+
+func (s *jwcSessionService) followGET(ctx context.Context, client *loginClient, start string, maxHops int) (*http.Response, string, error) {
 	cur := start
 	var lastReqURL *url.URL
 
 	for i := 0; i < maxHops; i++ {
-		req, _ := http.NewRequest("GET", cur, nil)
+		req, _ := http.NewRequestWithContext(ctx, "GET", cur, nil)
 		req.Header.Set("User-Agent", "Mozilla/5.0")
 
-		resp, err := client.Do(req)
+		resp, err := client.Do(ctx, req)
 		if err != nil {
 			return nil, cur, err
 		}
@@ -253,6 +396,27 @@ func (s *jwcSessionService) InvalidateSession(ctx context.Context, uid int) erro
 	return s.sessionCache.DeleteCookies(ctx, uid)
 }
 
+// ExpireSession 会话已过期时清除缓存（缓存已被其他请求重新登录替换时保留）
+func (s *jwcSessionService) ExpireSession(ctx context.Context, uid int, stale []*http.Cookie) error {
+	cookies, err := s.sessionCache.GetCookies(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if len(cookies) == 0 || cookieString(cookies) != cookieString(stale) {
+		return nil
+	}
+	return s.sessionCache.DeleteCookies(ctx, uid)
+}
+
+// cookieString 将 cookies 拼接为 name=value 串，用于比较两份会话是否相同
+func cookieString(cookies []*http.Cookie) string {
+	parts := make([]string, 0, len(cookies))
+	for _, c := range cookies {
+		parts = append(parts, c.Name+"="+c.Value)
+	}
+	return strings.Join(parts, "; ")
+}
+
 // encryptPassword 使用 RSA 公钥加密密码
 func (s *jwcSessionService) encryptPassword(password string) (string, error) {
 	// 从 RSA Key Service 获取公钥
@@ -336,111 +500,3 @@ func (s *jwcSessionService) ReplaceClientID(rawURL, newClientID string) (string,
 
 	return u.String(), nil
 }
-
-func (s *jwcSessionService) loginAndCacheOnceByWebVPN(ctx context.Context, uid int, username, password string) error {
-	// 创建 cookie jar
-	jar, err := cookiejar.New(&cookiejar.Options{
-		PublicSuffixList: publicsuffix.List,
-	})
-	if err != nil {
-		return common.NewAppError(common.CodeJwcLoginFailed, "创建会话失败")
-	}
-
-	client := &http.Client{
-		Jar:     jar,
-		Timeout: s.timeout,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse // 禁止自动跳转（CAS 必须手动）
-		},
-	}
-
-	// 1. 请求登录页获取 execution
-	res, err := client.Get(s.loginURL)
-	if err != nil {
-		return common.NewAppError(common.CodeJwcLoginFailed, "连接教务系统失败")
-	}
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusOK {
-		return common.NewAppError(common.CodeJwcLoginFailed, fmt.Sprintf("响应异常: %d", res.StatusCode))
-	}
-
-	doc, err := goquery.NewDocumentFromReader(res.Body)
-	if err != nil {
-		return common.NewAppError(common.CodeJwcParseFailed, "解析登录页面失败")
-	}
-
-	execution := doc.Find("input[name='execution']").AttrOr("value", "")
-	if execution == "" {
-		return common.NewAppError(common.CodeJwcLoginFailed, "找不到 execution")
-	}
-
-	// 2. 密码加密
-	encryptedPwd, err := s.encryptPassword(password)
-	if err != nil {
-		return common.NewAppError(common.CodeJwcLoginFailed, fmt.Sprintf("密码加密失败: %v", err))
-	}
-
-	fpVisitorId, err := s.GenerateRandomFingerPrintHash()
-	if err != nil {
-		return common.NewAppError(common.CodeInternalError, "生成设备指纹失败")
-	}
-
-	form := url.Values{
-		"username":    {username},
-		"password":    {encryptedPwd},
-		"execution":   {execution},
-		"fpVisitorId": {fpVisitorId},
-		"rememberMe":  {"on"},
-		"_eventId":    {"submit"},
-		"failN":       {"0"},
-		"submit1":     {"login1"},
-	}
-
-	// 3. 构造 POST 请求
-	req, err := http.NewRequest("POST", s.loginURL, strings.NewReader(form.Encode()))
-	if err != nil {
-		return common.NewAppError(common.CodeJwcLoginFailed, "构造登录请求失败")
-	}
-
-	req.Header.Set("User-Agent", "Mozilla/5.0")
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("Referer", s.loginURL)
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return common.NewAppError(common.CodeJwcLoginFailed, "登录失败")
-	}
-
-	resp.Body.Close()
-
-	if resp.StatusCode != 302 {
-		return common.NewAppError(common.CodeJwcLoginFailed, "重定向并非302")
-	}
-
-	//直接不处理重定向，用这个tgc的cookie去get教务系统，触发下一条重定向链，get全自动重定向
-
-	finalResp, finalURL, err := s.followGET(client, s.redirectURL, 8)
-	if err != nil {
-		return common.NewAppError(common.CodeJwcLoginFailed, "跟随重定向失败")
-	}
-	defer finalResp.Body.Close()
-
-	// 6. 提取并缓存 cookies
-	uFinal, _ := url.Parse(finalURL)
-	base := &url.URL{Scheme: uFinal.Scheme, Host: uFinal.Host, Path: "/"}
-	cookies := client.Jar.Cookies(base)
-
-	if len(cookies) == 0 {
-		if u, e := url.Parse(s.redirectURL); e == nil {
-			cookies = client.Jar.Cookies(u)
-		}
-	}
-
-	// 7. 存入缓存
-	if err := s.sessionCache.SetCookies(ctx, uid, cookies, s.cacheExpire); err != nil {
-		return common.NewAppError(common.CodeCacheError, "缓存会话失败")
-	}
-
-	return nil
-}
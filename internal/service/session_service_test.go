@@ -0,0 +1,425 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"spider-go/internal/common"
+	"spider-go/internal/service/jwctest"
+	"spider-go/pkg/httpclient"
+	"spider-go/pkg/webvpn"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memorySessionCache 内存实现的会话缓存（测试用）
+type memorySessionCache struct {
+	mu         sync.Mutex
+	cookies    map[int][]*http.Cookie
+	invalid    map[int]bool
+	challenges map[string][]byte
+	locks      map[int]string
+}
+
+func newMemorySessionCache() *memorySessionCache {
+	return &memorySessionCache{
+		cookies:    make(map[int][]*http.Cookie),
+		invalid:    make(map[int]bool),
+		challenges: make(map[string][]byte),
+		locks:      make(map[int]string),
+	}
+}
+
+func (c *memorySessionCache) GetCookies(ctx context.Context, uid int) ([]*http.Cookie, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cookies[uid], nil
+}
+
+func (c *memorySessionCache) SetCookies(ctx context.Context, uid int, cookies []*http.Cookie, expiration time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cookies[uid] = cookies
+	return nil
+}
+
+func (c *memorySessionCache) DeleteCookies(ctx context.Context, uid int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.cookies, uid)
+	return nil
+}
+
+func (c *memorySessionCache) HasCookies(ctx context.Context, uid int) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.cookies[uid]) > 0, nil
+}
+
+func (c *memorySessionCache) MarkCredentialInvalid(ctx context.Context, uid int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invalid[uid] = true
+	return nil
+}
+
+func (c *memorySessionCache) IsCredentialInvalid(ctx context.Context, uid int) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.invalid[uid], nil
+}
+
+func (c *memorySessionCache) ClearCredentialInvalid(ctx context.Context, uid int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.invalid, uid)
+	return nil
+}
+
+func (c *memorySessionCache) SetCaptchaChallenge(ctx context.Context, challengeID string, data []byte, expiration time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.challenges[challengeID] = data
+	return nil
+}
+
+func (c *memorySessionCache) TakeCaptchaChallenge(ctx context.Context, challengeID string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data := c.challenges[challengeID]
+	delete(c.challenges, challengeID)
+	return data, nil
+}
+
+func (c *memorySessionCache) AcquireLoginLock(ctx context.Context, uid int, token string, expiration time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.locks[uid]; ok {
+		return false, nil
+	}
+	c.locks[uid] = token
+	return true, nil
+}
+
+func (c *memorySessionCache) ReleaseLoginLock(ctx context.Context, uid int, token string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.locks[uid] == token {
+		delete(c.locks, uid)
+	}
+	return nil
+}
+
+func (c *memorySessionCache) IsLoginLocked(ctx context.Context, uid int) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.locks[uid]
+	return ok, nil
+}
+
+// testEnv 连接模拟 CAS/教务系统的会话服务和爬虫服务
+type testEnv struct {
+	server   *jwctest.Server
+	cache    *memorySessionCache
+	sessions SessionService
+	crawler  CrawlerService
+}
+
+func newTestEnv(t *testing.T, username, password string) *testEnv {
+	t.Helper()
+
+	server := jwctest.NewServer(username, password)
+	t.Cleanup(server.Close)
+
+	client := httpclient.New(nil, nil)
+	rsaKeyService := NewRSAKeyService(server.URL+jwctest.PublicKeyPath, client)
+	if err := rsaKeyService.FetchAndUpdate(); err != nil {
+		t.Fatalf("FetchAndUpdate: %v", err)
+	}
+
+	strategy := NewLoginStrategy(StrategyCampus, LoginEndpoints{LoginURL: server.LoginURL(), RedirectURL: server.RedirectURL()}, "", webvpn.NewTranslator(""))
+	cache := newMemorySessionCache()
+	sessions := NewJwcSessionService(cache, rsaKeyService, client, strategy, server.CaptchaURL(), server.CaptchaImageURL())
+
+	return &testEnv{
+		server:   server,
+		cache:    cache,
+		sessions: sessions,
+		crawler:  NewHttpCrawlerService(strategy, sessions, client),
+	}
+}
+
+func appErrorCode(err error) int {
+	if appErr, ok := err.(*common.AppError); ok {
+		return appErr.Code
+	}
+	return -1
+}
+
+func readAll(t *testing.T, body io.ReadCloser) string {
+	t.Helper()
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	return string(data)
+}
+
+func TestLoginAndCache(t *testing.T) {
+	env := newTestEnv(t, "20200000001", "secret")
+	ctx := context.Background()
+
+	if err := env.sessions.LoginAndCache(ctx, 1, "20200000001", "secret"); err != nil {
+		t.Fatalf("LoginAndCache: %v", err)
+	}
+
+	cookies, _ := env.cache.GetCookies(ctx, 1)
+	if !strings.Contains(cookieString(cookies), "JSESSIONID=") {
+		t.Errorf("cached cookies = %q, want JSESSIONID", cookieString(cookies))
+	}
+	if got := env.server.Logins(); got != 1 {
+		t.Errorf("logins = %d, want 1", got)
+	}
+}
+
+func TestLoginAndCacheWrongPasswordMarksInvalid(t *testing.T) {
+	env := newTestEnv(t, "20200000001", "secret")
+	ctx := context.Background()
+
+	err := env.sessions.LoginAndCache(ctx, 1, "20200000001", "changed")
+	if code := appErrorCode(err); code != common.CodeJwcBindInvalid {
+		t.Fatalf("LoginAndCache error = %v, want CodeJwcBindInvalid", err)
+	}
+
+	// 绑定已失效，不再提交密码
+	err = env.sessions.LoginAndCache(ctx, 1, "20200000001", "changed")
+	if code := appErrorCode(err); code != common.CodeJwcBindInvalid {
+		t.Fatalf("second LoginAndCache error = %v, want CodeJwcBindInvalid", err)
+	}
+	if got := env.server.Failures(); got != 1 {
+		t.Errorf("failed logins = %d, want 1", got)
+	}
+}
+
+func TestCaptchaChallenge(t *testing.T) {
+	env := newTestEnv(t, "20200000001", "secret")
+	env.server.Captcha = "a1b2"
+	ctx := context.Background()
+
+	err := env.sessions.VerifyCredentials(ctx, 1, "20200000001", "secret")
+	appErr, ok := err.(*common.AppError)
+	if !ok || appErr.Code != common.CodeJwcNeedCaptcha {
+		t.Fatalf("VerifyCredentials error = %v, want CodeJwcNeedCaptcha", err)
+	}
+	challenge, ok := appErr.Data.(*CaptchaChallenge)
+	if !ok || !strings.HasPrefix(challenge.Image, "data:image/gif;base64,") {
+		t.Fatalf("challenge = %+v", appErr.Data)
+	}
+
+	// 验证码错误时重新发起挑战
+	_, err = env.sessions.CompleteCaptchaLogin(ctx, 1, challenge.ChallengeID, "xxxx")
+	appErr, ok = err.(*common.AppError)
+	if !ok || appErr.Code != common.CodeJwcNeedCaptcha {
+		t.Fatalf("CompleteCaptchaLogin with wrong captcha error = %v, want CodeJwcNeedCaptcha", err)
+	}
+	challenge = appErr.Data.(*CaptchaChallenge)
+
+	username, err := env.sessions.CompleteCaptchaLogin(ctx, 1, challenge.ChallengeID, "a1b2")
+	if err != nil || username != "20200000001" {
+		t.Fatalf("CompleteCaptchaLogin = %q, %v", username, err)
+	}
+	if has, _ := env.cache.HasCookies(ctx, 1); !has {
+		t.Error("session not cached after captcha login")
+	}
+
+	// 挑战只能使用一次
+	_, err = env.sessions.CompleteCaptchaLogin(ctx, 1, challenge.ChallengeID, "a1b2")
+	if code := appErrorCode(err); code != common.CodeCaptchaInvalid {
+		t.Errorf("reused challenge error = %v, want CodeCaptchaInvalid", err)
+	}
+}
+
+func TestAcquireCookiesLogsInOnce(t *testing.T) {
+	env := newTestEnv(t, "20200000001", "secret")
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := env.sessions.AcquireCookies(ctx, 1, "20200000001", "secret"); err != nil {
+				t.Errorf("AcquireCookies: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := env.server.Logins(); got != 1 {
+		t.Errorf("logins = %d, want 1", got)
+	}
+}
+
+func TestFetchWithSessionReloginsAfterExpiry(t *testing.T) {
+	env := newTestEnv(t, "20200000001", "secret")
+	env.server.SetPage("/jsxsd/kscj/cjcx_list", "<html><title>学生个人考试成绩</title></html>")
+	ctx := context.Background()
+	account := &JwcAccount{Uid: 1, Username: "20200000001", Password: "secret"}
+	pageURL := env.server.PageURL("/jsxsd/kscj/cjcx_list")
+
+	body, err := env.crawler.FetchWithSession(ctx, account, "POST", pageURL, url.Values{"kksj": {""}})
+	if err != nil {
+		t.Fatalf("FetchWithSession: %v", err)
+	}
+	if page := readAll(t, body); !strings.Contains(page, "学生个人考试成绩") {
+		t.Fatalf("page = %q", page)
+	}
+
+	// 会话在服务端失效（缓存仍在）：识别登录页后重新登录并重放
+	env.server.ExpireSessions()
+	body, err = env.crawler.FetchWithSession(ctx, account, "POST", pageURL, url.Values{"kksj": {""}})
+	if err != nil {
+		t.Fatalf("FetchWithSession after expiry: %v", err)
+	}
+	if page := readAll(t, body); !strings.Contains(page, "学生个人考试成绩") {
+		t.Fatalf("page after expiry = %q", page)
+	}
+	if got := env.server.Logins(); got != 2 {
+		t.Errorf("logins = %d, want 2", got)
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	env := newTestEnv(t, "20201234567", "secret")
+	env.server.SetPage("/jsxsd/kscj/cjcx_list", `<html><title>学生个人考试成绩</title>
+<div>学号：20201234567&nbsp;姓名：李雷</div><div>身份证号：43010119990101123X</div>
+<table id="dataList"><tr><td>1</td><td>2023-2024-1</td></tr></table></html>`)
+	ctx := context.Background()
+	account := &JwcAccount{Uid: 1, Username: "20201234567", Password: "secret"}
+	pageURL := env.server.PageURL("/jsxsd/kscj/cjcx_list")
+	form := url.Values{"kksj": {"2023-2024-1"}}
+
+	dir := t.TempDir()
+	recorder := NewRecordingCrawlerService(env.crawler, dir)
+	body, err := recorder.FetchWithSession(ctx, account, "POST", pageURL, form)
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	original := readAll(t, body)
+	if !strings.Contains(original, "李雷") {
+		t.Fatalf("recorder must return the original page, got %q", original)
+	}
+
+	recorded, err := os.ReadFile(filepath.Join(dir, FixtureName("POST", pageURL, form)))
+	if err != nil {
+		t.Fatalf("fixture not written: %v", err)
+	}
+	for _, secret := range []string{"20201234567", "李雷", "43010119990101123X"} {
+		if strings.Contains(string(recorded), secret) {
+			t.Errorf("fixture still contains %q", secret)
+		}
+	}
+
+	// 回放与主机无关（WebVPN 地址可以回放校园网录制的页面）
+	replay := NewReplayCrawlerService(dir)
+	vpnURL := "https://http-jwgl-csuft-edu-cn-80.webvpn.csuft.edu.cn/jsxsd/kscj/cjcx_list"
+	body, err = replay.FetchWithSession(ctx, account, "POST", vpnURL, form)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if got := readAll(t, body); got != string(recorded) {
+		t.Errorf("replayed page differs from fixture")
+	}
+
+	if _, err := replay.FetchWithSession(ctx, account, "POST", pageURL, url.Values{"kksj": {"2024-2025-1"}}); appErrorCode(err) != common.CodeJwcRequestFailed {
+		t.Errorf("missing fixture error = %v, want CodeJwcRequestFailed", err)
+	}
+}
+
+func TestCaptchaVerifyRequiresSamePassword(t *testing.T) {
+	env := newTestEnv(t, "20200000001", "secret")
+	env.server.Captcha = "a1b2"
+	ctx := context.Background()
+
+	challengeOf := func() *CaptchaChallenge {
+		t.Helper()
+		err := env.sessions.VerifyCredentials(ctx, 1, "20200000001", "secret")
+		appErr, ok := err.(*common.AppError)
+		if !ok || appErr.Code != common.CodeJwcNeedCaptcha {
+			t.Fatalf("VerifyCredentials error = %v, want CodeJwcNeedCaptcha", err)
+		}
+		return appErr.Data.(*CaptchaChallenge)
+	}
+
+	// 获取验证码后换了密码或学号：挑战作废，不提交登录
+	for _, tc := range []struct{ username, password string }{
+		{"20200000001", "other"},
+		{"20200000002", "secret"},
+	} {
+		challenge := challengeOf()
+		err := env.sessions.CompleteCaptchaVerify(ctx, 1, challenge.ChallengeID, "a1b2", tc.username, tc.password)
+		if code := appErrorCode(err); code != common.CodeCaptchaInvalid {
+			t.Fatalf("CompleteCaptchaVerify(%s, %s) error = %v, want CodeCaptchaInvalid", tc.username, tc.password, err)
+		}
+		if _, err := env.sessions.CompleteCaptchaLogin(ctx, 1, challenge.ChallengeID, "a1b2"); appErrorCode(err) != common.CodeCaptchaInvalid {
+			t.Fatalf("challenge reusable after mismatch: %v", err)
+		}
+	}
+	if got := env.server.Logins(); got != 0 {
+		t.Fatalf("logins = %d, want 0", got)
+	}
+
+	challenge := challengeOf()
+	if err := env.sessions.CompleteCaptchaVerify(ctx, 1, challenge.ChallengeID, "a1b2", "20200000001", "secret"); err != nil {
+		t.Fatalf("CompleteCaptchaVerify: %v", err)
+	}
+	if got := env.server.Logins(); got != 1 {
+		t.Errorf("logins = %d, want 1", got)
+	}
+}
+
+func TestLoginReturnsUnavailableWhenCircuitOpen(t *testing.T) {
+	env := newTestEnv(t, "20200000001", "secret")
+	ctx := context.Background()
+
+	// CAS 返回 502：第一次登录触发熔断，之后的登录请求不再发出
+	var sent int32
+	guard := httpclient.NewGuard(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&sent, 1)
+		return &http.Response{StatusCode: http.StatusBadGateway, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+	}), &httpclient.GuardConfig{FailureThreshold: 1, OpenTimeout: time.Minute})
+	client := httpclient.New(&httpclient.Config{Timeout: time.Second}, guard)
+	strategy := NewLoginStrategy(StrategyCampus, LoginEndpoints{LoginURL: env.server.LoginURL(), RedirectURL: env.server.RedirectURL()}, "", webvpn.NewTranslator(""))
+	sessions := NewJwcSessionService(newMemorySessionCache(), NewRSAKeyService("", client), client, strategy, "", "")
+
+	if err := sessions.LoginAndCache(ctx, 1, "20200000001", "secret"); appErrorCode(err) != common.CodeJwcMaintenance {
+		t.Fatalf("first LoginAndCache error = %v, want CodeJwcMaintenance", err)
+	}
+
+	start := time.Now()
+	err := sessions.LoginAndCache(ctx, 1, "20200000001", "secret")
+	if code := appErrorCode(err); code != common.CodeJwcUnavailable {
+		t.Fatalf("LoginAndCache with open circuit error = %v, want CodeJwcUnavailable", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("open circuit login took %v, want no retry delay", elapsed)
+	}
+	if got := atomic.LoadInt32(&sent); got != 1 {
+		t.Errorf("requests sent = %d, want 1", got)
+	}
+}
+
+// roundTripFunc 用函数实现 http.RoundTripper（测试用）
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,133 @@
+package shared
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// TableColumns 按表头文字定位的列（教务系统调整列顺序或增删列时不受影响）
+// 多行表头的列名为 "上级/下级"，如 "分数类成绩/总成绩"；下级表头文字唯一时也可以直接使用
+type TableColumns struct {
+	index map[string]int
+	width int
+}
+
+// ambiguousColumn 同名表头出现在多列，只能使用完整的 "上级/下级" 列名
+const ambiguousColumn = -2
+
+// MapTableColumns 读取表格开头由 th 组成的表头行（支持 colspan/rowspan），并检查 required 中的列是否都存在
+// 缺少列时返回错误，说明页面结构已变化
+func MapTableColumns(table *goquery.Selection, required ...string) (*TableColumns, error) {
+	var headerRows []*goquery.Selection
+	table.Find("tr").EachWithBreak(func(_ int, tr *goquery.Selection) bool {
+		if tr.Find("th").Length() == 0 || tr.Find("td").Length() > 0 {
+			return false
+		}
+		headerRows = append(headerRows, tr)
+		return true
+	})
+	if len(headerRows) == 0 {
+		return nil, fmt.Errorf("未找到表头")
+	}
+
+	// 展开 colspan/rowspan，得到每行每列的表头文字
+	grid := make([]map[int]string, len(headerRows))
+	for i := range grid {
+		grid[i] = make(map[int]string)
+	}
+	width := 0
+	for r, tr := range headerRows {
+		col := 0
+		tr.Find("th").Each(func(_ int, th *goquery.Selection) {
+			for _, taken := grid[r][col]; taken; _, taken = grid[r][col] {
+				col++
+			}
+			text := normalizeHeader(th.Text())
+			colspan := spanAttr(th, "colspan")
+			rowspan := spanAttr(th, "rowspan")
+			for dr := 0; dr < rowspan && r+dr < len(grid); dr++ {
+				for dc := 0; dc < colspan; dc++ {
+					grid[r+dr][col+dc] = text
+				}
+			}
+			col += colspan
+		})
+		if col > width {
+			width = col
+		}
+	}
+
+	c := &TableColumns{index: make(map[string]int), width: width}
+	for col := 0; col < width; col++ {
+		var path []string
+		for r := range grid {
+			text := grid[r][col]
+			if text == "" || (len(path) > 0 && path[len(path)-1] == text) {
+				continue
+			}
+			path = append(path, text)
+		}
+		if len(path) == 0 {
+			continue
+		}
+
+		c.index[strings.Join(path, "/")] = col
+		leaf := path[len(path)-1]
+		if existing, ok := c.index[leaf]; ok && existing != col {
+			c.index[leaf] = ambiguousColumn
+		} else if !ok {
+			c.index[leaf] = col
+		}
+	}
+
+	var missing []string
+	for _, name := range required {
+		if c.Index(name) < 0 {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("表头缺少列: %s", strings.Join(missing, "、"))
+	}
+	return c, nil
+}
+
+// Index 列下标（不存在时返回 -1）
+func (c *TableColumns) Index(name string) int {
+	col, ok := c.index[name]
+	if !ok || col < 0 {
+		return -1
+	}
+	return col
+}
+
+// Width 表头的总列数（列数不足的数据行通常是合计或提示行）
+func (c *TableColumns) Width() int {
+	return c.width
+}
+
+// Text 数据行中某列的文本（列不存在时返回空字符串）
+func (c *TableColumns) Text(tds *goquery.Selection, name string) string {
+	col := c.Index(name)
+	if col < 0 {
+		return ""
+	}
+	return tds.Eq(col).Text()
+}
+
+// normalizeHeader 去掉表头文字中的空白（含 &nbsp;）
+func normalizeHeader(s string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(s, " ", " ")), "")
+}
+
+// spanAttr 读取 colspan/rowspan（缺省或无效时为 1）
+func spanAttr(sel *goquery.Selection, name string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(sel.AttrOr(name, "1")))
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
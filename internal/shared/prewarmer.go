@@ -0,0 +1,11 @@
+package shared
+
+import "context"
+
+// Prewarmer 数据预热接口（用于跨模块调用）
+// 由成绩、课程、考试模块实现：忽略已有缓存，从教务系统重新抓取用户数据并写入缓存，
+// 使高峰期的请求命中缓存和已登录的会话，而不是同时触发大量 CAS 登录
+type Prewarmer interface {
+	// Prewarm 刷新用户的数据缓存
+	Prewarm(ctx context.Context, uid int) error
+}
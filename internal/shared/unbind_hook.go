@@ -0,0 +1,11 @@
+package shared
+
+import "context"
+
+// JwcUnbindHook 解除教务系统绑定钩子（用于跨模块调用）
+// 由成绩、课程、考试模块实现：用户解除绑定后清理各模块中依赖该教务账号的数据和订阅，
+// 避免定时任务继续为未绑定的用户抓取，或旧的订阅地址继续返回上一个账号的数据
+type JwcUnbindHook interface {
+	// OnJwcUnbind 清理用户的教务数据（需可重复调用）
+	OnJwcUnbind(ctx context.Context, uid int) error
+}
@@ -3,6 +3,7 @@ package shared
 import (
 	"context"
 	"errors"
+	"spider-go/internal/service"
 
 	"gorm.io/gorm"
 )
@@ -16,7 +17,7 @@ type UserInfo struct {
 	Uid   int    `gorm:"column:uid"`
 	Email string `gorm:"column:email"`
 	Sid   string `gorm:"column:sid"`  // 学号
-	Spwd  string `gorm:"column:spwd"` // 教务系统密码
+	Spwd  string `gorm:"column:spwd"` // 教务系统密码（查询结果为解密后的明文）
 }
 
 // TableName 指定表名
@@ -26,7 +27,7 @@ func (UserInfo) TableName() string {
 
 // UserQuery 用户查询接口（用于跨模块访问用户数据）
 type UserQuery interface {
-	// GetUserByUid 根据UID获取用户信息
+	// GetUserByUid 根据UID获取用户信息（教务系统密码已解密）
 	GetUserByUid(ctx context.Context, uid int) (*UserInfo, error)
 	// GetAllUserEmails 获取所有用户的邮箱
 	GetAllUserEmails(ctx context.Context) ([]string, error)
@@ -34,12 +35,16 @@ type UserQuery interface {
 
 // userQuery 用户查询实现
 type userQuery struct {
-	db *gorm.DB
+	db                *gorm.DB
+	credentialService service.CredentialService
 }
 
 // NewUserQuery 创建用户查询服务
-func NewUserQuery(db *gorm.DB) UserQuery {
-	return &userQuery{db: db}
+func NewUserQuery(db *gorm.DB, credentialService service.CredentialService) UserQuery {
+	return &userQuery{
+		db:                db,
+		credentialService: credentialService,
+	}
 }
 
 // GetUserByUid 根据UID获取用户信息
@@ -51,6 +56,13 @@ func (q *userQuery) GetUserByUid(ctx context.Context, uid int) (*UserInfo, error
 		}
 		return nil, err
 	}
+
+	spwd, err := q.credentialService.Decrypt(ctx, user.Spwd)
+	if err != nil {
+		return nil, err
+	}
+	user.Spwd = spwd
+
 	return &user, nil
 }
 
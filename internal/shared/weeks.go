@@ -0,0 +1,67 @@
+package shared
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseWeeks 解析周次字符串，返回升序去重的周次列表
+// 支持 "1-16(周)"、"1,3,5-7(周)"，以及带 单/双 的区间如 "1-15单(周)"
+func ParseWeeks(weeksStr string) []int {
+	// 去掉 "(周)" 后缀
+	if idx := strings.Index(weeksStr, "("); idx >= 0 {
+		weeksStr = weeksStr[:idx]
+	}
+	weeksStr = strings.TrimSpace(weeksStr)
+	if weeksStr == "" {
+		return nil
+	}
+
+	seen := make(map[int]struct{})
+	parts := strings.Split(weeksStr, ",")
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		// 单双周
+		parity := 0
+		switch {
+		case strings.Contains(part, "单"):
+			parity = 1
+		case strings.Contains(part, "双"):
+			parity = 2
+		}
+		part = strings.NewReplacer("单", "", "双", "", "周", "").Replace(part)
+
+		if strings.Contains(part, "-") {
+			se := strings.SplitN(part, "-", 2)
+			start, err1 := strconv.Atoi(strings.TrimSpace(se[0]))
+			end, err2 := strconv.Atoi(strings.TrimSpace(se[1]))
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			for w := start; w <= end; w++ {
+				if parity == 1 && w%2 == 0 || parity == 2 && w%2 == 1 {
+					continue
+				}
+				seen[w] = struct{}{}
+			}
+		} else {
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				continue
+			}
+			seen[n] = struct{}{}
+		}
+	}
+
+	weeks := make([]int, 0, len(seen))
+	for w := range seen {
+		weeks = append(weeks, w)
+	}
+	sort.Ints(weeks)
+	return weeks
+}
@@ -83,8 +83,37 @@ func initScheduler(container *app.Container) *scheduler.Scheduler {
 	// 添加 RSA 公钥刷新任务
 	s.AddTask(tasks.NewRSARefreshTask(container.RSAKeyService))
 
-	// 添加数据预热任务（暂时禁用）
-	s.AddTask(tasks.NewDataPrewarmTask())
+	// 添加凭据密钥轮换任务
+	s.AddTask(tasks.NewCredentialRotateTask(container.CredentialService, container.UserModule.GetService(), container.TaskLockCache))
+
+	// 添加低电费提醒任务
+	s.AddTask(tasks.NewElectricityAlertTask(
+		container.ElectricityModule.GetService(),
+		container.UserQuery,
+		container.EmailService,
+	))
+
+	// 添加新成绩通知任务
+	s.AddTask(tasks.NewGradeNotifyTask(
+		container.GradeModule.GetService(),
+		container.UserQuery,
+		container.EmailService,
+	))
+
+	// 添加考试提醒任务
+	s.AddTask(tasks.NewExamReminderTask(
+		container.ExamModule.GetService(),
+		container.UserQuery,
+		container.EmailService,
+	))
+
+	// 添加数据预热任务（上课高峰前刷新活跃用户的成绩、课表和考试安排）
+	s.AddTask(tasks.NewDataPrewarmTask(
+		container.DAUCache,
+		container.CourseModule.GetService(),
+		container.ExamModule.GetService(),
+		container.GradeModule.GetService(),
+	))
 
 	return s
 }
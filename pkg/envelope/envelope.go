@@ -0,0 +1,212 @@
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 密文格式：enc:v{版本号}:{base64(nonce||密文)}
+const cipherPrefix = "enc:v"
+
+var (
+	ErrEmptyMasterKey    = errors.New("master key is empty")
+	ErrUnknownKeyVersion = errors.New("unknown data key version")
+	ErrNoActiveKey       = errors.New("no active data key")
+	ErrInvalidCiphertext = errors.New("invalid ciphertext")
+)
+
+// KeyRing 版本化数据密钥环（信封加密）
+// 数据密钥（DEK）用于加密业务数据，DEK 本身由主密钥（KEK）加密后持久化
+type KeyRing struct {
+	mu     sync.RWMutex
+	master cipher.AEAD
+	keys   map[int]cipher.AEAD
+	active int
+}
+
+// NewKeyRing 使用主密钥创建密钥环
+// 主密钥可以是任意字符串，内部通过 SHA-256 派生为 AES-256 密钥
+func NewKeyRing(masterKey string) (*KeyRing, error) {
+	if masterKey == "" {
+		return nil, ErrEmptyMasterKey
+	}
+
+	sum := sha256.Sum256([]byte(masterKey))
+	master, err := newGCM(sum[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyRing{
+		master: master,
+		keys:   make(map[int]cipher.AEAD),
+	}, nil
+}
+
+// GenerateWrappedKey 生成一个新的随机数据密钥，返回经主密钥加密后的形式（用于持久化）
+func (r *KeyRing) GenerateWrappedKey() (string, error) {
+	dek := make([]byte, 32)
+	if _, err := rand.Read(dek); err != nil {
+		return "", fmt.Errorf("generate data key: %w", err)
+	}
+	return seal(r.master, dek)
+}
+
+// AddWrappedKey 解开并加载一个持久化的数据密钥，版本号最大的密钥自动成为当前密钥
+func (r *KeyRing) AddWrappedKey(version int, wrapped string) error {
+	dek, err := open(r.master, wrapped)
+	if err != nil {
+		return fmt.Errorf("unwrap data key v%d: %w", version, err)
+	}
+
+	aead, err := newGCM(dek)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.keys[version] = aead
+	if version > r.active {
+		r.active = version
+	}
+	return nil
+}
+
+// ActiveVersion 获取当前用于加密的数据密钥版本（0 表示尚未加载任何密钥）
+func (r *KeyRing) ActiveVersion() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.active
+}
+
+// HasVersion 检查是否已加载指定版本的数据密钥
+func (r *KeyRing) HasVersion(version int) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.keys[version]
+	return ok
+}
+
+// Encrypt 使用当前数据密钥加密明文
+func (r *KeyRing) Encrypt(plaintext string) (string, error) {
+	r.mu.RLock()
+	version := r.active
+	aead, ok := r.keys[version]
+	r.mu.RUnlock()
+
+	if !ok {
+		return "", ErrNoActiveKey
+	}
+
+	sealed, err := seal(aead, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+
+	return cipherPrefix + strconv.Itoa(version) + ":" + sealed, nil
+}
+
+// Decrypt 解密密文（根据密文中的版本号选择数据密钥）
+func (r *KeyRing) Decrypt(ciphertext string) (string, error) {
+	version, payload, err := split(ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.RLock()
+	aead, ok := r.keys[version]
+	r.mu.RUnlock()
+
+	if !ok {
+		return "", ErrUnknownKeyVersion
+	}
+
+	plain, err := open(aead, payload)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// IsEncrypted 判断字符串是否为本包生成的密文
+func IsEncrypted(value string) bool {
+	_, _, err := split(value)
+	return err == nil
+}
+
+// VersionOf 获取密文使用的数据密钥版本
+func VersionOf(ciphertext string) (int, bool) {
+	version, _, err := split(ciphertext)
+	if err != nil {
+		return 0, false
+	}
+	return version, true
+}
+
+// split 拆分密文为版本号和负载
+func split(ciphertext string) (int, string, error) {
+	if !strings.HasPrefix(ciphertext, cipherPrefix) {
+		return 0, "", ErrInvalidCiphertext
+	}
+
+	rest := strings.TrimPrefix(ciphertext, cipherPrefix)
+	idx := strings.Index(rest, ":")
+	if idx <= 0 {
+		return 0, "", ErrInvalidCiphertext
+	}
+
+	version, err := strconv.Atoi(rest[:idx])
+	if err != nil || version <= 0 {
+		return 0, "", ErrInvalidCiphertext
+	}
+
+	return version, rest[idx+1:], nil
+}
+
+// newGCM 创建 AES-GCM
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal 加密并输出 base64(nonce||密文)
+func seal(aead cipher.AEAD, plaintext []byte) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	out := aead.Seal(nonce, nonce, plaintext, nil)
+	return base64.StdEncoding.EncodeToString(out), nil
+}
+
+// open 解密 base64(nonce||密文)
+func open(aead cipher.AEAD, payload string) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+
+	nonceSize := aead.NonceSize()
+	if len(raw) < nonceSize {
+		return nil, ErrInvalidCiphertext
+	}
+
+	plain, err := aead.Open(nil, raw[:nonceSize], raw[nonceSize:], nil)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	return plain, nil
+}
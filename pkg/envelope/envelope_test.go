@@ -0,0 +1,154 @@
+package envelope
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// newTestKeyRing 创建加载了指定数量数据密钥的密钥环，返回各版本的持久化形式
+func newTestKeyRing(t *testing.T, masterKey string, versions int) (*KeyRing, map[int]string) {
+	t.Helper()
+
+	ring, err := NewKeyRing(masterKey)
+	if err != nil {
+		t.Fatalf("NewKeyRing: %v", err)
+	}
+	wrapped := make(map[int]string)
+	for v := 1; v <= versions; v++ {
+		w, err := ring.GenerateWrappedKey()
+		if err != nil {
+			t.Fatalf("GenerateWrappedKey: %v", err)
+		}
+		if err := ring.AddWrappedKey(v, w); err != nil {
+			t.Fatalf("AddWrappedKey(%d): %v", v, err)
+		}
+		wrapped[v] = w
+	}
+	return ring, wrapped
+}
+
+func TestKeyRingRoundTrip(t *testing.T) {
+	ring, wrapped := newTestKeyRing(t, "master-secret", 2)
+
+	for _, plaintext := range []string{"Passw0rd!", "", "中文密码#2024", strings.Repeat("x", 1024)} {
+		ciphertext, err := ring.Encrypt(plaintext)
+		if err != nil {
+			t.Fatalf("Encrypt(%q): %v", plaintext, err)
+		}
+		if !strings.HasPrefix(ciphertext, "enc:v2:") {
+			t.Errorf("ciphertext %q not encrypted with the newest key", ciphertext)
+		}
+		got, err := ring.Decrypt(ciphertext)
+		if err != nil || got != plaintext {
+			t.Errorf("Decrypt = %q, %v, want %q", got, err, plaintext)
+		}
+	}
+
+	// 同一明文每次加密的密文不同（随机 nonce）
+	a, _ := ring.Encrypt("same")
+	b, _ := ring.Encrypt("same")
+	if a == b {
+		t.Error("two encryptions produced the same ciphertext")
+	}
+
+	// 重启后用同一主密钥加载持久化的数据密钥，可以解密旧版本密文
+	restarted, err := NewKeyRing("master-secret")
+	if err != nil {
+		t.Fatalf("NewKeyRing: %v", err)
+	}
+	for v, w := range wrapped {
+		if err := restarted.AddWrappedKey(v, w); err != nil {
+			t.Fatalf("AddWrappedKey(%d): %v", v, err)
+		}
+	}
+	if got, err := restarted.Decrypt(a); err != nil || got != "same" {
+		t.Errorf("Decrypt after restart = %q, %v", got, err)
+	}
+	if restarted.ActiveVersion() != 2 || !restarted.HasVersion(1) {
+		t.Errorf("ActiveVersion = %d, HasVersion(1) = %v", restarted.ActiveVersion(), restarted.HasVersion(1))
+	}
+}
+
+func TestKeyRingWrongMasterKey(t *testing.T) {
+	_, wrapped := newTestKeyRing(t, "master-secret", 1)
+
+	other, err := NewKeyRing("another-secret")
+	if err != nil {
+		t.Fatalf("NewKeyRing: %v", err)
+	}
+	if err := other.AddWrappedKey(1, wrapped[1]); !errors.Is(err, ErrInvalidCiphertext) {
+		t.Fatalf("AddWrappedKey with wrong master key = %v, want ErrInvalidCiphertext", err)
+	}
+	if other.HasVersion(1) || other.ActiveVersion() != 0 {
+		t.Error("key loaded despite wrong master key")
+	}
+	if _, err := other.Encrypt("x"); !errors.Is(err, ErrNoActiveKey) {
+		t.Errorf("Encrypt without keys = %v, want ErrNoActiveKey", err)
+	}
+
+	if _, err := NewKeyRing(""); !errors.Is(err, ErrEmptyMasterKey) {
+		t.Errorf("NewKeyRing(\"\") = %v, want ErrEmptyMasterKey", err)
+	}
+}
+
+func TestKeyRingTamperedCiphertext(t *testing.T) {
+	ring, _ := newTestKeyRing(t, "master-secret", 1)
+	ciphertext, err := ring.Encrypt("Passw0rd!")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	payload := strings.TrimPrefix(ciphertext, "enc:v1:")
+	raw, _ := base64.StdEncoding.DecodeString(payload)
+	flipped := append([]byte(nil), raw...)
+	flipped[len(flipped)-1] ^= 0x01
+
+	tests := []struct {
+		name       string
+		ciphertext string
+		want       error
+	}{
+		{name: "flipped tag byte", ciphertext: "enc:v1:" + base64.StdEncoding.EncodeToString(flipped), want: ErrInvalidCiphertext},
+		{name: "truncated below nonce", ciphertext: "enc:v1:" + base64.StdEncoding.EncodeToString(raw[:8]), want: ErrInvalidCiphertext},
+		{name: "not base64", ciphertext: "enc:v1:!!!", want: ErrInvalidCiphertext},
+		{name: "unknown version", ciphertext: "enc:v9:" + payload, want: ErrUnknownKeyVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ring.Decrypt(tt.ciphertext); !errors.Is(err, tt.want) {
+				t.Errorf("Decrypt = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionOf(t *testing.T) {
+	tests := []struct {
+		value   string
+		version int
+		ok      bool
+	}{
+		{value: "enc:v1:abc", version: 1, ok: true},
+		{value: "enc:v12:abc", version: 12, ok: true},
+		{value: "enc:v3:", version: 3, ok: true},
+		{value: "plaintext", ok: false},
+		{value: "", ok: false},
+		{value: "enc:v:abc", ok: false},
+		{value: "enc:v0:abc", ok: false},
+		{value: "enc:v-1:abc", ok: false},
+		{value: "enc:vx:abc", ok: false},
+		{value: "enc:v1", ok: false},
+		{value: "ENC:v1:abc", ok: false},
+	}
+	for _, tt := range tests {
+		version, ok := VersionOf(tt.value)
+		if version != tt.version || ok != tt.ok {
+			t.Errorf("VersionOf(%q) = %d, %v, want %d, %v", tt.value, version, ok, tt.version, tt.ok)
+		}
+		if IsEncrypted(tt.value) != tt.ok {
+			t.Errorf("IsEncrypted(%q) = %v, want %v", tt.value, !tt.ok, tt.ok)
+		}
+	}
+}
@@ -18,13 +18,22 @@ const (
 	CodeJwcLoginFailed    = 40004 // 教务系统登录失败
 	CodeJwcParseFailed    = 40005 // 教务系统解析失败
 	CodeJwcRequestFailed  = 40006 // 教务系统请求失败
+	CodeDormNotBound      = 40007 // 宿舍未绑定
+	CodeJwcBindInvalid    = 40008 // 教务系统绑定已失效（密码已修改，需重新绑定）
+	CodeJwcWrongPassword  = 40009 // 教务系统账号或密码错误
+	CodeJwcAccountLocked  = 40010 // 教务系统账号已锁定
+	CodeJwcNeedCaptcha    = 40011 // 教务系统要求验证码
+	CodeJwcPwdExpired     = 40012 // 教务系统密码已过期
+	CodeJwcMaintenance    = 50301 // 教务系统维护中
+	CodeJwcUnavailable    = 50302 // 教务系统暂时无法访问（熔断中）
 	CodeCacheError        = 50001 // 缓存错误
 )
 
 // AppError 应用错误
 type AppError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"` // 附加数据（如验证码挑战），随错误响应返回
 }
 
 // Error 实现 error 接口
@@ -32,6 +41,12 @@ func (e *AppError) Error() string {
 	return e.Message
 }
 
+// WithData 附加随错误响应返回的数据
+func (e *AppError) WithData(data interface{}) *AppError {
+	e.Data = data
+	return e
+}
+
 // NewAppError 创建应用错误
 func NewAppError(code int, message string) *AppError {
 	return &AppError{
@@ -0,0 +1,131 @@
+package httpclient
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	const cooldown = 20 * time.Millisecond
+
+	// 每一步的操作及操作后期望的 Allow / IsOpen 结果
+	type step struct {
+		name      string
+		do        func(b *circuitBreaker)
+		check     bool // 操作后调用 Allow
+		wantAllow bool
+		wantOpen  bool
+	}
+	allow := func(want, open bool) step {
+		return step{name: "allow", check: true, wantAllow: want, wantOpen: open}
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "closed to open after threshold failures",
+			steps: []step{
+				{name: "failure", do: (*circuitBreaker).Failure},
+				allow(true, false),
+				{name: "failure", do: (*circuitBreaker).Failure},
+				{name: "failure", do: (*circuitBreaker).Failure, wantOpen: true},
+				allow(false, true),
+			},
+		},
+		{
+			name: "success resets consecutive failures",
+			steps: []step{
+				{name: "failure", do: (*circuitBreaker).Failure},
+				{name: "failure", do: (*circuitBreaker).Failure},
+				{name: "success", do: (*circuitBreaker).Success},
+				{name: "failure", do: (*circuitBreaker).Failure},
+				{name: "failure", do: (*circuitBreaker).Failure},
+				allow(true, false),
+			},
+		},
+		{
+			name: "open to half-open to closed on probe success",
+			steps: []step{
+				{name: "failure", do: (*circuitBreaker).Failure},
+				{name: "failure", do: (*circuitBreaker).Failure},
+				{name: "failure", do: (*circuitBreaker).Failure, wantOpen: true},
+				{name: "cooldown", do: func(*circuitBreaker) { time.Sleep(cooldown + 5*time.Millisecond) }, wantOpen: true},
+				{name: "probe", check: true, wantAllow: true, wantOpen: true},
+				{name: "second probe", check: true, wantAllow: false, wantOpen: true},
+				{name: "success", do: (*circuitBreaker).Success},
+				allow(true, false),
+				allow(true, false),
+			},
+		},
+		{
+			name: "half-open probe failure reopens",
+			steps: []step{
+				{name: "failure", do: (*circuitBreaker).Failure},
+				{name: "failure", do: (*circuitBreaker).Failure},
+				{name: "failure", do: (*circuitBreaker).Failure, wantOpen: true},
+				{name: "cooldown", do: func(*circuitBreaker) { time.Sleep(cooldown + 5*time.Millisecond) }, wantOpen: true},
+				{name: "probe", check: true, wantAllow: true, wantOpen: true},
+				{name: "failure", do: (*circuitBreaker).Failure, wantOpen: true},
+				allow(false, true),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newCircuitBreaker(3, cooldown)
+			for i, s := range tt.steps {
+				if s.do != nil {
+					s.do(b)
+				}
+				if s.check {
+					if got := b.Allow(); got != s.wantAllow {
+						t.Fatalf("step %d (%s): Allow() = %v, want %v", i, s.name, got, s.wantAllow)
+					}
+				}
+				if got := b.IsOpen(); got != s.wantOpen {
+					t.Fatalf("step %d (%s): IsOpen() = %v, want %v", i, s.name, got, s.wantOpen)
+				}
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerSingleProbe(t *testing.T) {
+	b := newCircuitBreaker(1, 10*time.Millisecond)
+	b.Failure()
+	time.Sleep(15 * time.Millisecond)
+
+	if !b.Allow() {
+		t.Fatal("first request after cooldown should be allowed as the probe")
+	}
+	for i := 0; i < 3; i++ {
+		if b.Allow() {
+			t.Fatalf("request %d allowed while the probe is in flight", i)
+		}
+	}
+
+	// 探测请求未发出（被限流或取消）时归还名额
+	b.release()
+	if !b.Allow() {
+		t.Fatal("probe slot not returned by release")
+	}
+	if b.Allow() {
+		t.Fatal("second probe allowed")
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	b := newCircuitBreaker(0, time.Second)
+	if b != nil {
+		t.Fatal("threshold 0 should disable the breaker")
+	}
+	for i := 0; i < 10; i++ {
+		b.Failure()
+	}
+	if !b.Allow() || b.IsOpen() {
+		t.Fatal("disabled breaker should always allow")
+	}
+}
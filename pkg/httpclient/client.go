@@ -1,59 +1,191 @@
 package httpclient
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 )
 
 // Client HTTP客户端接口
 type Client interface {
+	// Do 执行HTTP请求（幂等请求在连接错误或 502/503/504 时按退避重试）
 	Do(ctx context.Context, req *http.Request) (*http.Response, error)
+	// Get 执行GET请求
 	Get(ctx context.Context, url string) (*http.Response, error)
+	// Post 执行POST请求（url.Values 按表单提交，string/[]byte/io.Reader 原样提交，其他类型编码为 JSON）
 	Post(ctx context.Context, url string, body interface{}) (*http.Response, error)
+	// Session 创建共享连接池的会话客户端（独立 cookie jar，可禁止自动跳转）
+	Session(opts SessionOptions) Client
 }
 
 // Config HTTP客户端配置
 type Config struct {
-	Timeout         time.Duration
-	MaxIdleConns    int
-	IdleConnTimeout time.Duration
+	Timeout             time.Duration // 单个请求超时（ctx 已设置截止时间时以 ctx 为准）
+	MaxIdleConns        int           // 最大空闲连接数
+	MaxIdleConnsPerHost int           // 每个主机的最大空闲连接数
+	IdleConnTimeout     time.Duration // 空闲连接保持时间
+	Proxy               string        // 出站代理地址（为空时读取 HTTP_PROXY 等环境变量）
+	MaxRetries          int           // 幂等请求的最大重试次数（0 表示不重试）
+	RetryBackoff        time.Duration // 首次重试等待时间（之后按指数增长）
 }
 
 // DefaultConfig 返回默认配置
 func DefaultConfig() *Config {
 	return &Config{
-		Timeout:         30 * time.Second,
-		MaxIdleConns:    100,
-		IdleConnTimeout: 90 * time.Second,
+		Timeout:             30 * time.Second,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 20,
+		IdleConnTimeout:     90 * time.Second,
+		MaxRetries:          2,
+		RetryBackoff:        200 * time.Millisecond,
 	}
 }
 
+// SessionOptions 会话客户端选项
+type SessionOptions struct {
+	Jar        http.CookieJar // 会话 cookie jar
+	NoRedirect bool           // 禁止自动跳转（返回 3xx 响应由调用方处理）
+}
+
 // client HTTP客户端实现
 type client struct {
 	httpClient *http.Client
+	cfg        Config
+}
+
+// NewTransport 按配置创建连接池（所有客户端共享，代理地址无效时返回错误）
+func NewTransport(cfg *Config) (*http.Transport, error) {
+	if cfg == nil {
+		cfg = DefaultConfig()
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = cfg.MaxIdleConns
+	transport.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	transport.IdleConnTimeout = cfg.IdleConnTimeout
+
+	if cfg.Proxy != "" {
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy url: %q", cfg.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	return transport, nil
 }
 
-// New 创建新的HTTP客户端
-func New(cfg *Config) Client {
+// New 创建新的HTTP客户端（transport 通常为包装了连接池的 Guard，为空时使用默认 Transport）
+func New(cfg *Config, transport http.RoundTripper) Client {
 	if cfg == nil {
 		cfg = DefaultConfig()
 	}
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
 
 	return &client{
-		httpClient: &http.Client{
-			Timeout: cfg.Timeout,
-			Transport: &http.Transport{
-				MaxIdleConns:    cfg.MaxIdleConns,
-				IdleConnTimeout: cfg.IdleConnTimeout,
-			},
-		},
+		httpClient: &http.Client{Transport: transport},
+		cfg:        *cfg,
+	}
+}
+
+// Session 创建共享连接池的会话客户端
+func (c *client) Session(opts SessionOptions) Client {
+	httpClient := &http.Client{
+		Transport: c.httpClient.Transport,
+		Jar:       opts.Jar,
+	}
+	if opts.NoRedirect {
+		httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+
+	return &client{
+		httpClient: httpClient,
+		cfg:        c.cfg,
 	}
 }
 
 // Do 执行HTTP请求
 func (c *client) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
-	return c.httpClient.Do(req.WithContext(ctx))
+	attempts := 1
+	if c.cfg.MaxRetries > 0 && isIdempotent(req) && (req.Body == nil || req.GetBody != nil) {
+		attempts += c.cfg.MaxRetries
+	}
+
+	var (
+		resp *http.Response
+		err  error
+	)
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			if err := c.backoff(ctx, i); err != nil {
+				return nil, err
+			}
+			if req.GetBody != nil {
+				body, bodyErr := req.GetBody()
+				if bodyErr != nil {
+					return nil, bodyErr
+				}
+				req.Body = body
+			}
+		}
+
+		resp, err = c.doOnce(ctx, req)
+		if i == attempts-1 || !shouldRetry(ctx, resp, err) {
+			break
+		}
+		if resp != nil {
+			// 丢弃响应体以便连接复用
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+	}
+	return resp, err
+}
+
+// doOnce 发出一次请求（ctx 没有截止时间时使用配置的超时，响应体关闭后才释放）
+func (c *client) doOnce(ctx context.Context, req *http.Request) (*http.Response, error) {
+	cancel := context.CancelFunc(func() {})
+	if _, ok := ctx.Deadline(); !ok && c.cfg.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.cfg.Timeout)
+	}
+
+	resp, err := c.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	resp.Body = &cancelOnCloseBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// backoff 第 n 次重试前等待（指数退避加随机抖动）
+func (c *client) backoff(ctx context.Context, n int) error {
+	delay := c.cfg.RetryBackoff << (n - 1)
+	if delay > 0 {
+		delay += time.Duration(rand.Int63n(int64(delay)/2 + 1))
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // Get 执行GET请求
@@ -62,11 +194,82 @@ func (c *client) Get(ctx context.Context, url string) (*http.Response, error) {
 	if err != nil {
 		return nil, err
 	}
-	return c.httpClient.Do(req)
+	return c.Do(ctx, req)
 }
 
 // Post 执行POST请求
 func (c *client) Post(ctx context.Context, url string, body interface{}) (*http.Response, error) {
-	// TODO: 实现POST请求
-	return nil, nil
+	reader, contentType, err := encodeBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return c.Do(ctx, req)
+}
+
+// encodeBody 按类型编码请求体，返回请求体和 Content-Type
+func encodeBody(body interface{}) (io.Reader, string, error) {
+	switch b := body.(type) {
+	case nil:
+		return nil, "", nil
+	case url.Values:
+		return strings.NewReader(b.Encode()), "application/x-www-form-urlencoded", nil
+	case string:
+		return strings.NewReader(b), "", nil
+	case []byte:
+		return bytes.NewReader(b), "", nil
+	case io.Reader:
+		return b, "", nil
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to encode json body: %w", err)
+		}
+		return bytes.NewReader(data), "application/json", nil
+	}
+}
+
+// isIdempotent 请求是否可以安全重试（幂等方法，或带 Idempotency-Key 的请求）
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+// shouldRetry 连接错误、超时和 502/503/504 可重试；调用方取消或熔断中不重试
+func shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, ErrCircuitOpen) && !errors.Is(err, context.Canceled)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// cancelOnCloseBody 关闭响应体时释放请求的超时 ctx
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close 关闭响应体并释放 ctx
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
 }
@@ -7,36 +7,45 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
-	"time"
 )
 
 // Crawler HTTP 爬虫客户端接口
 type Crawler interface {
 	// FetchWithCookies 使用 cookies 发起请求
 	FetchWithCookies(ctx context.Context, method, targetURL string, cookies []*http.Cookie, formData url.Values) (io.ReadCloser, error)
+	// Do 使用 cookies 发起请求并返回完整响应（不检查状态码，resp.Request.URL 为跟随重定向后的地址）
+	Do(ctx context.Context, method, targetURL string, cookies []*http.Cookie, formData url.Values) (*http.Response, error)
 }
 
-// crawler 爬虫客户端实现
+// crawler 爬虫客户端实现（所有请求共用一个 Client，复用连接）
 type crawler struct {
-	timeout time.Duration
+	client Client
 }
 
-// NewCrawler 创建 HTTP 爬虫客户端
-func NewCrawler() Crawler {
+// NewCrawler 创建 HTTP 爬虫客户端（client 为共享的出站客户端，跟随重定向、不保存 cookie）
+func NewCrawler(client Client) Crawler {
 	return &crawler{
-		timeout: 30 * time.Second,
+		client: client,
 	}
 }
 
-// NewCrawlerWithTimeout 创建带自定义超时的 HTTP 爬虫客户端
-func NewCrawlerWithTimeout(timeout time.Duration) Crawler {
-	return &crawler{
-		timeout: timeout,
+// FetchWithCookies 使用 cookies 发起请求
+func (c *crawler) FetchWithCookies(ctx context.Context, method, targetURL string, cookies []*http.Cookie, formData url.Values) (io.ReadCloser, error) {
+	resp, err := c.Do(ctx, method, targetURL, cookies, formData)
+	if err != nil {
+		return nil, err
 	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return resp.Body, nil
 }
 
-// FetchWithCookies 使用 cookies 发起请求
-func (c *crawler) FetchWithCookies(ctx context.Context, method, targetURL string, cookies []*http.Cookie, formData url.Values) (io.ReadCloser, error) {
+// Do 使用 cookies 发起请求并返回完整响应
+func (c *crawler) Do(ctx context.Context, method, targetURL string, cookies []*http.Cookie, formData url.Values) (*http.Response, error) {
 	var body io.Reader
 	if formData != nil {
 		body = strings.NewReader(formData.Encode())
@@ -58,19 +67,10 @@ func (c *crawler) FetchWithCookies(ctx context.Context, method, targetURL string
 		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	}
 
-	client := &http.Client{
-		Timeout: c.timeout,
-	}
-
-	resp, err := client.Do(req)
+	resp, err := c.client.Do(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		resp.Body.Close()
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
-	return resp.Body, nil
+	return resp, nil
 }
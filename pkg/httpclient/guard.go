@@ -0,0 +1,226 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// GuardConfig 出站请求保护配置（为 0 的项表示不限制）
+type GuardConfig struct {
+	GlobalRate       float64       // 全局每秒请求数
+	GlobalBurst      int           // 全局突发请求数
+	HostRate         float64       // 单个主机每秒请求数
+	HostBurst        int           // 单个主机突发请求数
+	MaxConcurrent    int           // 同时进行的请求数（含读取响应体）
+	FailureThreshold int           // 连续失败多少次后熔断
+	OpenTimeout      time.Duration // 熔断后多久放行探测请求
+}
+
+// DefaultGuardConfig 返回默认的出站请求保护配置
+func DefaultGuardConfig() *GuardConfig {
+	return &GuardConfig{
+		GlobalRate:       50,
+		GlobalBurst:      100,
+		HostRate:         20,
+		HostBurst:        40,
+		MaxConcurrent:    64,
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+	}
+}
+
+// Guard 所有出站请求共享的保护层（http.RoundTripper）：
+// 全局及单主机令牌桶限流、有界并发、按主机熔断，并按结果计数
+type Guard struct {
+	base    http.RoundTripper
+	cfg     GuardConfig
+	global  *tokenBucket
+	slots   chan struct{}
+	metrics *Metrics
+
+	mu       sync.Mutex
+	hosts    map[string]*tokenBucket
+	breakers map[string]*circuitBreaker
+}
+
+// NewGuard 创建出站请求保护层（base 为空时使用 http.DefaultTransport）
+func NewGuard(base http.RoundTripper, cfg *GuardConfig) *Guard {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if cfg == nil {
+		cfg = DefaultGuardConfig()
+	}
+
+	g := &Guard{
+		base:     base,
+		cfg:      *cfg,
+		global:   newTokenBucket(cfg.GlobalRate, cfg.GlobalBurst),
+		metrics:  newMetrics(),
+		hosts:    make(map[string]*tokenBucket),
+		breakers: make(map[string]*circuitBreaker),
+	}
+	if cfg.MaxConcurrent > 0 {
+		g.slots = make(chan struct{}, cfg.MaxConcurrent)
+	}
+	g.metrics.breakers = g.breakerStates
+	return g
+}
+
+// Metrics 出站请求指标
+func (g *Guard) Metrics() *Metrics {
+	return g.metrics
+}
+
+// RoundTrip 依次经过熔断、限流、并发池后发出请求
+func (g *Guard) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Hostname()
+	ctx := req.Context()
+
+	breaker := g.breakerFor(host)
+	if !breaker.Allow() {
+		g.metrics.inc(host, OutcomeCircuitOpen)
+		return nil, ErrCircuitOpen
+	}
+
+	if err := g.acquire(ctx, host); err != nil {
+		// 请求未发出，不影响熔断状态
+		breaker.release()
+		g.metrics.inc(host, OutcomeRateLimited)
+		return nil, err
+	}
+
+	resp, err := g.base.RoundTrip(req)
+	outcome := classify(resp, err)
+	g.metrics.inc(host, outcome)
+
+	switch outcome {
+	case OutcomeTimeout, OutcomeServerError, OutcomeError:
+		// 调用方主动取消不代表服务端异常
+		if errors.Is(err, context.Canceled) {
+			breaker.release()
+		} else {
+			breaker.Failure()
+		}
+	default:
+		breaker.Success()
+	}
+
+	if err != nil {
+		g.releaseSlot()
+		return nil, err
+	}
+
+	// 响应体读取完毕（关闭）后才归还并发名额
+	resp.Body = &slotReleasingBody{ReadCloser: resp.Body, release: g.releaseSlot}
+	return resp, nil
+}
+
+// acquire 等待全局令牌、主机令牌和并发名额
+func (g *Guard) acquire(ctx context.Context, host string) error {
+	if err := g.global.Wait(ctx); err != nil {
+		return err
+	}
+	if err := g.hostBucket(host).Wait(ctx); err != nil {
+		return err
+	}
+	if g.slots == nil {
+		return nil
+	}
+	select {
+	case g.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// releaseSlot 归还并发名额
+func (g *Guard) releaseSlot() {
+	if g.slots != nil {
+		<-g.slots
+	}
+}
+
+// hostBucket 获取主机的令牌桶
+func (g *Guard) hostBucket(host string) *tokenBucket {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	bucket, ok := g.hosts[host]
+	if !ok {
+		bucket = newTokenBucket(g.cfg.HostRate, g.cfg.HostBurst)
+		g.hosts[host] = bucket
+	}
+	return bucket
+}
+
+// breakerFor 获取主机的熔断器
+func (g *Guard) breakerFor(host string) *circuitBreaker {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	breaker, ok := g.breakers[host]
+	if !ok {
+		breaker = newCircuitBreaker(g.cfg.FailureThreshold, g.cfg.OpenTimeout)
+		g.breakers[host] = breaker
+	}
+	return breaker
+}
+
+// breakerStates 各主机熔断器是否打开
+func (g *Guard) breakerStates() map[string]bool {
+	g.mu.Lock()
+	breakers := make(map[string]*circuitBreaker, len(g.breakers))
+	for host, b := range g.breakers {
+		breakers[host] = b
+	}
+	g.mu.Unlock()
+
+	states := make(map[string]bool, len(breakers))
+	for host, b := range breakers {
+		if b != nil {
+			states[host] = b.IsOpen()
+		}
+	}
+	return states
+}
+
+// classify 按响应或错误归类请求结果
+func classify(resp *http.Response, err error) string {
+	if err != nil {
+		var netErr net.Error
+		if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+			return OutcomeTimeout
+		}
+		return OutcomeError
+	}
+
+	switch {
+	case resp.StatusCode >= 500:
+		return OutcomeServerError
+	case resp.StatusCode >= 400:
+		return OutcomeClientError
+	default:
+		return OutcomeSuccess
+	}
+}
+
+// slotReleasingBody 关闭响应体时归还并发名额（只归还一次）
+type slotReleasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+// Close 关闭响应体并归还并发名额
+func (b *slotReleasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,107 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// roundTripFunc 用函数实现 http.RoundTripper（测试用）
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// staticTransport 固定返回指定状态码，并统计发出的请求数
+func staticTransport(status int, calls *int32) http.RoundTripper {
+	return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(calls, 1)
+		return &http.Response{
+			StatusCode: status,
+			Body:       io.NopCloser(strings.NewReader("ok")),
+			Request:    req,
+		}, nil
+	})
+}
+
+func newRequest(t *testing.T, ctx context.Context) *http.Request {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://jwc.example.com/", nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	return req
+}
+
+func TestGuardReleasesSlotWhenBodyClosed(t *testing.T) {
+	var calls int32
+	g := NewGuard(staticTransport(http.StatusOK, &calls), &GuardConfig{MaxConcurrent: 1})
+
+	resp, err := g.RoundTrip(newRequest(t, context.Background()))
+	if err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+
+	// 响应体未关闭时并发名额仍被占用
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := g.RoundTrip(newRequest(t, ctx)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("RoundTrip while slot held = %v, want DeadlineExceeded", err)
+	}
+
+	// 重复关闭只归还一次名额
+	resp.Body.Close()
+	resp.Body.Close()
+
+	resp, err = g.RoundTrip(newRequest(t, context.Background()))
+	if err != nil {
+		t.Fatalf("RoundTrip after close: %v", err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := g.RoundTrip(newRequest(t, ctx)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("double Close released more than one slot: %v", err)
+	}
+	resp.Body.Close()
+
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("requests sent = %d, want 2", got)
+	}
+}
+
+func TestGuardOpensCircuitPerHost(t *testing.T) {
+	var calls int32
+	g := NewGuard(staticTransport(http.StatusBadGateway, &calls), &GuardConfig{
+		FailureThreshold: 2,
+		OpenTimeout:      time.Minute,
+	})
+
+	for i := 0; i < 2; i++ {
+		resp, err := g.RoundTrip(newRequest(t, context.Background()))
+		if err != nil {
+			t.Fatalf("RoundTrip %d: %v", i, err)
+		}
+		resp.Body.Close()
+	}
+
+	if _, err := g.RoundTrip(newRequest(t, context.Background())); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("RoundTrip after failures = %v, want ErrCircuitOpen", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("requests sent = %d, want 2 (open circuit must not send)", got)
+	}
+
+	// 其他主机不受影响
+	req, _ := http.NewRequest(http.MethodGet, "http://other.example.com/", nil)
+	resp, err := g.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip to other host: %v", err)
+	}
+	resp.Body.Close()
+}
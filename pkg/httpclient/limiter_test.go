@@ -0,0 +1,53 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketWait(t *testing.T) {
+	tests := []struct {
+		name     string
+		rate     float64
+		burst    int
+		requests int
+		minWait  time.Duration // 全部请求取得令牌的最短耗时
+		maxWait  time.Duration
+	}{
+		{name: "within burst", rate: 10, burst: 3, requests: 3, minWait: 0, maxWait: 20 * time.Millisecond},
+		{name: "waits for refill", rate: 50, burst: 2, requests: 4, minWait: 30 * time.Millisecond, maxWait: 200 * time.Millisecond},
+		{name: "burst below one", rate: 100, burst: 0, requests: 3, minWait: 15 * time.Millisecond, maxWait: 200 * time.Millisecond},
+		{name: "unlimited", rate: 0, burst: 1, requests: 100, minWait: 0, maxWait: 20 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTokenBucket(tt.rate, tt.burst)
+			start := time.Now()
+			for i := 0; i < tt.requests; i++ {
+				if err := b.Wait(context.Background()); err != nil {
+					t.Fatalf("Wait %d: %v", i, err)
+				}
+			}
+			elapsed := time.Since(start)
+			if elapsed < tt.minWait || elapsed > tt.maxWait {
+				t.Errorf("%d requests took %v, want between %v and %v", tt.requests, elapsed, tt.minWait, tt.maxWait)
+			}
+		})
+	}
+}
+
+func TestTokenBucketWaitCanceled(t *testing.T) {
+	b := newTokenBucket(1, 1)
+	if err := b.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := b.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait with empty bucket = %v, want DeadlineExceeded", err)
+	}
+}
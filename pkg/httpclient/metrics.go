@@ -0,0 +1,118 @@
+package httpclient
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// 请求结果分类
+const (
+	OutcomeSuccess     = "success"      // 2xx/3xx
+	OutcomeClientError = "client_error" // 4xx
+	OutcomeServerError = "server_error" // 5xx
+	OutcomeTimeout     = "timeout"      // 超时
+	OutcomeError       = "error"        // 连接失败等其他错误
+	OutcomeRateLimited = "rate_limited" // 等待令牌或并发名额时请求被取消
+	OutcomeCircuitOpen = "circuit_open" // 熔断中被拒绝
+)
+
+// metricKey 指标标签
+type metricKey struct {
+	host    string
+	outcome string
+}
+
+// Metrics 出站请求计数（Prometheus 文本格式输出）
+type Metrics struct {
+	mu       sync.Mutex
+	requests map[metricKey]uint64
+	breakers func() map[string]bool
+}
+
+// newMetrics 创建指标
+func newMetrics() *Metrics {
+	return &Metrics{requests: make(map[metricKey]uint64)}
+}
+
+// inc 按主机和结果计数
+func (m *Metrics) inc(host, outcome string) {
+	m.mu.Lock()
+	m.requests[metricKey{host: host, outcome: outcome}]++
+	m.mu.Unlock()
+}
+
+// Snapshot 返回当前计数（host -> outcome -> count）
+func (m *Metrics) Snapshot() map[string]map[string]uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make(map[string]map[string]uint64)
+	for k, v := range m.requests {
+		if result[k.host] == nil {
+			result[k.host] = make(map[string]uint64)
+		}
+		result[k.host][k.outcome] = v
+	}
+	return result
+}
+
+// WritePrometheus 以 Prometheus 文本格式输出指标
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	keys := make([]metricKey, 0, len(m.requests))
+	for k := range m.requests {
+		keys = append(keys, k)
+	}
+	counts := make(map[metricKey]uint64, len(m.requests))
+	for k, v := range m.requests {
+		counts[k] = v
+	}
+	m.mu.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].host != keys[j].host {
+			return keys[i].host < keys[j].host
+		}
+		return keys[i].outcome < keys[j].outcome
+	})
+
+	if _, err := fmt.Fprintln(w, "# HELP spider_outbound_requests_total Outbound HTTP requests by host and outcome."); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "# TYPE spider_outbound_requests_total counter")
+	for _, k := range keys {
+		fmt.Fprintf(w, "spider_outbound_requests_total{host=%q,outcome=%q} %d\n", k.host, k.outcome, counts[k])
+	}
+
+	if m.breakers == nil {
+		return nil
+	}
+	states := m.breakers()
+	hosts := make([]string, 0, len(states))
+	for host := range states {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	fmt.Fprintln(w, "# HELP spider_outbound_circuit_open Whether the circuit breaker for a host is open (1) or closed (0).")
+	fmt.Fprintln(w, "# TYPE spider_outbound_circuit_open gauge")
+	for _, host := range hosts {
+		open := 0
+		if states[host] {
+			open = 1
+		}
+		fmt.Fprintf(w, "spider_outbound_circuit_open{host=%q} %d\n", host, open)
+	}
+	return nil
+}
+
+// Handler 返回输出指标的 HTTP 处理器
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = m.WritePrometheus(w)
+	})
+}
@@ -0,0 +1,93 @@
+package ical
+
+import (
+	"strings"
+	"time"
+)
+
+// Location 日历事件统一使用北京时间（不依赖系统时区数据）
+var Location = time.FixedZone("CST", 8*3600)
+
+// TZID 事件时间使用的时区标识
+const TZID = "Asia/Shanghai"
+
+// Calendar iCalendar 文本构造器（自动折行，使用 CRLF 换行）
+type Calendar struct {
+	b strings.Builder
+}
+
+// NewCalendar 创建日历并写入日历头与北京时区定义
+func NewCalendar(prodID, name string) *Calendar {
+	c := &Calendar{}
+	c.Line("BEGIN:VCALENDAR")
+	c.Line("VERSION:2.0")
+	c.Line("PRODID:" + prodID)
+	c.Line("CALSCALE:GREGORIAN")
+	c.Line("METHOD:PUBLISH")
+	c.Line("X-WR-CALNAME:" + EscapeText(name))
+	c.Line("X-WR-TIMEZONE:" + TZID)
+	c.Line("BEGIN:VTIMEZONE")
+	c.Line("TZID:" + TZID)
+	c.Line("BEGIN:STANDARD")
+	c.Line("DTSTART:19700101T000000")
+	c.Line("TZOFFSETFROM:+0800")
+	c.Line("TZOFFSETTO:+0800")
+	c.Line("TZNAME:CST")
+	c.Line("END:STANDARD")
+	c.Line("END:VTIMEZONE")
+	return c
+}
+
+// Line 写入一行内容
+func (c *Calendar) Line(line string) {
+	c.b.WriteString(FoldLine(line))
+	c.b.WriteString("\r\n")
+}
+
+// Bytes 结束日历并返回完整文本
+func (c *Calendar) Bytes() []byte {
+	c.Line("END:VCALENDAR")
+	return []byte(c.b.String())
+}
+
+// FormatLocal 格式化为 iCalendar 本地时间（配合 TZID 使用）
+func FormatLocal(t time.Time) string {
+	return t.In(Location).Format("20060102T150405")
+}
+
+// FormatUTC 格式化为 iCalendar UTC 时间（用于 DTSTAMP 等）
+func FormatUTC(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// EscapeText 转义 iCalendar 文本值
+func EscapeText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// FoldLine 按 RFC 5545 将超过 75 字节的行折叠（不拆分 UTF-8 字符）
+func FoldLine(line string) string {
+	const limit = 75
+	if len(line) <= limit {
+		return line
+	}
+
+	var b strings.Builder
+	lineLen := 0
+	for _, r := range line {
+		size := len(string(r))
+		if lineLen+size > limit {
+			b.WriteString("\r\n ")
+			lineLen = 1
+		}
+		b.WriteRune(r)
+		lineLen += size
+	}
+	return b.String()
+}
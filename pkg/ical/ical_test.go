@@ -0,0 +1,93 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "高等数学", want: "高等数学"},
+		{in: "A;B,C", want: `A\;B\,C`},
+		{in: `C:\path`, want: `C:\\path`},
+		{in: "第1-2节\n教师：张三", want: `第1-2节\n教师：张三`},
+		{in: "line1\r\nline2", want: `line1\nline2`},
+		{in: `\;`, want: `\\\;`},
+	}
+	for _, tt := range tests {
+		if got := EscapeText(tt.in); got != tt.want {
+			t.Errorf("EscapeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFoldLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{name: "short", line: "SUMMARY:高等数学"},
+		{name: "exactly 75 bytes", line: "DESCRIPTION:" + strings.Repeat("x", 63)},
+		{name: "ascii", line: "DESCRIPTION:" + strings.Repeat("abcdefghij", 20)},
+		{name: "multi-byte", line: "DESCRIPTION:" + strings.Repeat("教学楼", 40)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folded := FoldLine(tt.line)
+			if len(tt.line) <= 75 && folded != tt.line {
+				t.Fatalf("line of %d bytes was folded: %q", len(tt.line), folded)
+			}
+
+			parts := strings.Split(folded, "\r\n")
+			for i, part := range parts {
+				if len(part) > 75 {
+					t.Errorf("part %d is %d bytes", i, len(part))
+				}
+				if !utf8.ValidString(part) {
+					t.Errorf("part %d splits a UTF-8 character: %q", i, part)
+				}
+				if i > 0 && !strings.HasPrefix(part, " ") {
+					t.Errorf("continuation %d does not start with a space: %q", i, part)
+				}
+			}
+
+			// 展开（去掉 CRLF 和紧随的一个空格）后与原行一致
+			if unfolded := strings.ReplaceAll(folded, "\r\n ", ""); unfolded != tt.line {
+				t.Errorf("unfolded = %q, want %q", unfolded, tt.line)
+			}
+		})
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	cal := NewCalendar("-//spider-go//test//CN", "课程表 2024-2025-1")
+	cal.Line("BEGIN:VEVENT")
+	cal.Line("DTSTART;TZID=" + TZID + ":" + FormatLocal(time.Date(2024, 9, 2, 0, 0, 0, 0, time.UTC)))
+	cal.Line("DTSTAMP:" + FormatUTC(time.Date(2024, 9, 2, 8, 0, 0, 0, Location)))
+	cal.Line("SUMMARY:" + EscapeText("物理, 实验; 1"))
+	cal.Line("END:VEVENT")
+	text := string(cal.Bytes())
+
+	if !strings.HasPrefix(text, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n") || !strings.HasSuffix(text, "END:VCALENDAR\r\n") {
+		t.Errorf("calendar not wrapped in VCALENDAR: %q", text)
+	}
+	if strings.Contains(strings.ReplaceAll(text, "\r\n", ""), "\n") {
+		t.Error("calendar contains bare LF line endings")
+	}
+	for _, want := range []string{
+		"X-WR-CALNAME:课程表 2024-2025-1\r\n",
+		"TZID:Asia/Shanghai\r\n",
+		"DTSTART;TZID=Asia/Shanghai:20240902T080000\r\n", // UTC 零点即北京时间 8 点
+		"DTSTAMP:20240902T000000Z\r\n",
+		`SUMMARY:物理\, 实验\; 1` + "\r\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("calendar missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package webvpn
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Translator 按 WebVPN 的主机名编码规则在校园网地址与 WebVPN 地址之间互相转换
+// 例如 http://jwgl.csuft.edu.cn/jsxsd/ <-> https://http-jwgl-csuft-edu-cn-80.webvpn.csuft.edu.cn/jsxsd/
+// 编码后的主机名为 {协议}-{主机名中的 . 替换为 -}-{端口}.{WebVPN 主机名}，路径和查询参数不变
+type Translator struct {
+	host   string // WebVPN 主机名，如 webvpn.csuft.edu.cn
+	domain string // 可通过 WebVPN 访问的校园网域名，如 csuft.edu.cn
+}
+
+// NewTranslator 创建 WebVPN 地址转换器（校园网域名取 WebVPN 主机名去掉第一段）
+func NewTranslator(host string) *Translator {
+	host = strings.ToLower(strings.TrimSpace(host))
+	domain := host
+	if i := strings.Index(host, "."); i >= 0 {
+		domain = host[i+1:]
+	}
+	return &Translator{host: host, domain: domain}
+}
+
+// Host WebVPN 主机名
+func (t *Translator) Host() string {
+	return t.host
+}
+
+// IsVPNHost 判断是否为 WebVPN 编码后的主机名
+func (t *Translator) IsVPNHost(host string) bool {
+	return t.host != "" && strings.HasSuffix(strings.ToLower(host), "."+t.host)
+}
+
+// Covers 判断主机是否在校园网域名下（可以通过 WebVPN 访问）
+func (t *Translator) Covers(host string) bool {
+	host = strings.ToLower(host)
+	if t.domain == "" || host == t.host || t.IsVPNHost(host) {
+		return false
+	}
+	return host == t.domain || strings.HasSuffix(host, "."+t.domain)
+}
+
+// Encode 将校园网地址改写为 WebVPN 地址（无法解析、已是 WebVPN 地址或不在校园网域名下时原样返回）
+func (t *Translator) Encode(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" || !t.Covers(u.Hostname()) {
+		return rawURL
+	}
+
+	encoded := *u
+	encoded.Scheme = "https"
+	encoded.Host = t.EncodeHost(u.Scheme, u.Hostname(), u.Port())
+	return encoded.String()
+}
+
+// Decode 将 WebVPN 地址还原为校园网地址（不是 WebVPN 地址时原样返回）
+func (t *Translator) Decode(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || !t.IsVPNHost(u.Hostname()) {
+		return rawURL
+	}
+
+	scheme, host, port, ok := t.DecodeHost(u.Hostname())
+	if !ok {
+		return rawURL
+	}
+
+	decoded := *u
+	decoded.Scheme = scheme
+	decoded.Host = host
+	if port != defaultPort(scheme) {
+		decoded.Host = host + ":" + port
+	}
+	return decoded.String()
+}
+
+// EncodeHost 编码主机名（端口为空时使用协议默认端口）
+func (t *Translator) EncodeHost(scheme, host, port string) string {
+	scheme = strings.ToLower(scheme)
+	if port == "" {
+		port = defaultPort(scheme)
+	}
+	return scheme + "-" + strings.ReplaceAll(strings.ToLower(host), ".", "-") + "-" + port + "." + t.host
+}
+
+// DecodeHost 解码 WebVPN 主机名，返回协议、原主机名和端口
+// 编码规则不转义原主机名中的 -，因此含 - 的主机名无法还原（校园网域名下的主机名均不含 -）
+func (t *Translator) DecodeHost(vpnHost string) (scheme, host, port string, ok bool) {
+	if !t.IsVPNHost(vpnHost) {
+		return "", "", "", false
+	}
+	encoded := strings.TrimSuffix(strings.ToLower(vpnHost), "."+t.host)
+
+	parts := strings.Split(encoded, "-")
+	if len(parts) < 3 {
+		return "", "", "", false
+	}
+
+	scheme = parts[0]
+	port = parts[len(parts)-1]
+	if scheme != "http" && scheme != "https" || port == "" {
+		return "", "", "", false
+	}
+	host = strings.Join(parts[1:len(parts)-1], ".")
+	return scheme, host, port, true
+}
+
+// defaultPort 协议默认端口
+func defaultPort(scheme string) string {
+	if scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
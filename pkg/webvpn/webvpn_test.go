@@ -0,0 +1,133 @@
+package webvpn
+
+import "testing"
+
+func TestEncode(t *testing.T) {
+	tr := NewTranslator("webvpn.csuft.edu.cn")
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "http default port", in: "http://jwgl.csuft.edu.cn/jsxsd/kscj/cjcx_list", want: "https://http-jwgl-csuft-edu-cn-80.webvpn.csuft.edu.cn/jsxsd/kscj/cjcx_list"},
+		{name: "https default port", in: "https://cas.csuft.edu.cn/cas/login?service=x", want: "https://https-cas-csuft-edu-cn-443.webvpn.csuft.edu.cn/cas/login?service=x"},
+		{name: "explicit port", in: "http://jwgl.csuft.edu.cn:8080/jsxsd/", want: "https://http-jwgl-csuft-edu-cn-8080.webvpn.csuft.edu.cn/jsxsd/"},
+		{name: "explicit default port", in: "https://cas.csuft.edu.cn:443/cas/", want: "https://https-cas-csuft-edu-cn-443.webvpn.csuft.edu.cn/cas/"},
+		{name: "upper case host", in: "HTTP://JWGL.CSUFT.EDU.CN/jsxsd/", want: "https://http-jwgl-csuft-edu-cn-80.webvpn.csuft.edu.cn/jsxsd/"},
+		{name: "campus root domain", in: "http://csuft.edu.cn/", want: "https://http-csuft-edu-cn-80.webvpn.csuft.edu.cn/"},
+
+		{name: "outside campus domain", in: "https://www.example.com/a", want: "https://www.example.com/a"},
+		{name: "suffix but not subdomain", in: "http://evilcsuft.edu.cn/", want: "http://evilcsuft.edu.cn/"},
+		{name: "already webvpn", in: "https://http-jwgl-csuft-edu-cn-80.webvpn.csuft.edu.cn/jsxsd/", want: "https://http-jwgl-csuft-edu-cn-80.webvpn.csuft.edu.cn/jsxsd/"},
+		{name: "webvpn host itself", in: "https://webvpn.csuft.edu.cn/login", want: "https://webvpn.csuft.edu.cn/login"},
+		{name: "relative url", in: "/jsxsd/xskb/xskb_list.do", want: "/jsxsd/xskb/xskb_list.do"},
+		{name: "unparsable", in: "http://[::1", want: "http://[::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tr.Encode(tt.in); got != tt.want {
+				t.Errorf("Encode(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+
+	// 未配置 WebVPN 主机名时不改写
+	if got := NewTranslator("").Encode("http://jwgl.csuft.edu.cn/"); got != "http://jwgl.csuft.edu.cn/" {
+		t.Errorf("Encode without host = %q", got)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tr := NewTranslator("webvpn.csuft.edu.cn")
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "http default port", in: "https://http-jwgl-csuft-edu-cn-80.webvpn.csuft.edu.cn/jsxsd/", want: "http://jwgl.csuft.edu.cn/jsxsd/"},
+		{name: "https default port", in: "https://https-cas-csuft-edu-cn-443.webvpn.csuft.edu.cn/cas/login?service=x", want: "https://cas.csuft.edu.cn/cas/login?service=x"},
+		{name: "explicit port", in: "https://http-jwgl-csuft-edu-cn-8080.webvpn.csuft.edu.cn/jsxsd/", want: "http://jwgl.csuft.edu.cn:8080/jsxsd/"},
+		{name: "https on port 80", in: "https://https-jwgl-csuft-edu-cn-80.webvpn.csuft.edu.cn/", want: "https://jwgl.csuft.edu.cn:80/"},
+		{name: "not webvpn", in: "http://jwgl.csuft.edu.cn/jsxsd/", want: "http://jwgl.csuft.edu.cn/jsxsd/"},
+		{name: "other webvpn", in: "https://http-jwgl-csuft-edu-cn-80.webvpn.example.com/", want: "https://http-jwgl-csuft-edu-cn-80.webvpn.example.com/"},
+		{name: "unknown scheme", in: "https://ftp-files-csuft-edu-cn-21.webvpn.csuft.edu.cn/", want: "https://ftp-files-csuft-edu-cn-21.webvpn.csuft.edu.cn/"},
+		{name: "too few parts", in: "https://http-80.webvpn.csuft.edu.cn/", want: "https://http-80.webvpn.csuft.edu.cn/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tr.Decode(tt.in); got != tt.want {
+				t.Errorf("Decode(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	tr := NewTranslator("webvpn.csuft.edu.cn")
+
+	for _, raw := range []string{
+		"http://jwgl.csuft.edu.cn/jsxsd/kscj/cjcx_list",
+		"https://cas.csuft.edu.cn/cas/login?service=https%3A%2F%2Fportal.csuft.edu.cn%2F",
+		"http://jwgl.csuft.edu.cn:8080/jsxsd/?a=1&b=2#frag",
+		"https://portal.csuft.edu.cn/main.html",
+	} {
+		encoded := tr.Encode(raw)
+		if encoded == raw {
+			t.Errorf("Encode(%q) did not rewrite", raw)
+			continue
+		}
+		if got := tr.Decode(encoded); got != raw {
+			t.Errorf("Decode(Encode(%q)) = %q", raw, got)
+		}
+	}
+}
+
+func TestDecodeHost(t *testing.T) {
+	tr := NewTranslator("webvpn.csuft.edu.cn")
+
+	tests := []struct {
+		vpnHost string
+		scheme  string
+		host    string
+		port    string
+		ok      bool
+	}{
+		{vpnHost: "http-jwgl-csuft-edu-cn-80.webvpn.csuft.edu.cn", scheme: "http", host: "jwgl.csuft.edu.cn", port: "80", ok: true},
+		{vpnHost: "HTTPS-CAS-CSUFT-EDU-CN-443.WEBVPN.CSUFT.EDU.CN", scheme: "https", host: "cas.csuft.edu.cn", port: "443", ok: true},
+		{vpnHost: "http-jwgl-csuft-edu-cn-8080.webvpn.csuft.edu.cn", scheme: "http", host: "jwgl.csuft.edu.cn", port: "8080", ok: true},
+		{vpnHost: "jwgl.csuft.edu.cn"},
+		{vpnHost: "webvpn.csuft.edu.cn"},
+		{vpnHost: "http-80.webvpn.csuft.edu.cn"},
+		{vpnHost: "ftp-files-csuft-edu-cn-21.webvpn.csuft.edu.cn"},
+		{vpnHost: "http-jwgl-csuft-edu-cn-.webvpn.csuft.edu.cn"},
+	}
+	for _, tt := range tests {
+		scheme, host, port, ok := tr.DecodeHost(tt.vpnHost)
+		if scheme != tt.scheme || host != tt.host || port != tt.port || ok != tt.ok {
+			t.Errorf("DecodeHost(%q) = %q, %q, %q, %v, want %q, %q, %q, %v",
+				tt.vpnHost, scheme, host, port, ok, tt.scheme, tt.host, tt.port, tt.ok)
+		}
+	}
+
+	if got := tr.EncodeHost("HTTP", "JWGL.csuft.edu.cn", ""); got != "http-jwgl-csuft-edu-cn-80.webvpn.csuft.edu.cn" {
+		t.Errorf("EncodeHost = %q", got)
+	}
+}
+
+func TestHyphenatedHostIsNotReversible(t *testing.T) {
+	tr := NewTranslator("webvpn.csuft.edu.cn")
+
+	// 编码规则不转义主机名中的 -，编码后与 my.lib.csuft.edu.cn 无法区分，解码得到的主机名不同于原主机名
+	encoded := tr.Encode("http://my-lib.csuft.edu.cn/index")
+	if encoded != "https://http-my-lib-csuft-edu-cn-80.webvpn.csuft.edu.cn/index" {
+		t.Fatalf("Encode = %q", encoded)
+	}
+	if other := tr.Encode("http://my.lib.csuft.edu.cn/index"); other != encoded {
+		t.Errorf("Encode(my.lib) = %q, want the same host as my-lib", other)
+	}
+	if got := tr.Decode(encoded); got != "http://my.lib.csuft.edu.cn/index" {
+		t.Errorf("Decode = %q, want the dotted host (known limitation)", got)
+	}
+}